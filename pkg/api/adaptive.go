@@ -0,0 +1,189 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// adaptiveInitialLimit is the conservative number of concurrent requests allowed before any latency is observed.
+	adaptiveInitialLimit = 4
+	// adaptiveMaxLimit is the upper bound on the number of concurrent requests.
+	adaptiveMaxLimit = 256
+	// adaptiveTolerance is how much slower than the minimum latency a response can be before it indicates queueing.
+	adaptiveTolerance = 2.0
+	// adaptiveMinRTTSamples is the number of samples the minimum latency is kept before it is measured again.
+	adaptiveMinRTTSamples = 100
+)
+
+// WithAdaptiveConcurrency limits the number of requests the client has in flight at once using a limit that adjusts
+// itself to the capacity of the server: starting from a conservative limit, the limit grows while response latency
+// stays close to the lowest latency observed and shrinks when latency rises, multiplicatively backing off on
+// timeouts, "429 Too Many Requests" and "5xx" responses. Additional requests block until the limit allows them or
+// their context is done. Each attempt of a retried request is limited separately; a request made using `DoStream`
+// remains in flight until the response body is closed.
+func WithAdaptiveConcurrency() Option {
+	return func(c *httpClient) {
+		c.adaptive = true
+	}
+}
+
+// WithConcurrencyLimitObserver is invoked with the new limit whenever the adaptive concurrency limit changes, for
+// example to export it as a metric. It has no effect unless `WithAdaptiveConcurrency` is also used.
+func WithConcurrencyLimitObserver(observer func(limit int)) Option {
+	return func(c *httpClient) {
+		c.concurrencyLimitObserver = observer
+	}
+}
+
+// adaptiveLimiter is an AIMD concurrency limit driven by latency and errors.
+type adaptiveLimiter struct {
+	observer func(limit int)
+
+	mu       sync.Mutex
+	limit    float64
+	inflight int
+	waiters  []chan struct{}
+	minRTT   time.Duration
+	samples  int
+}
+
+func newAdaptiveLimiter(observer func(int)) *adaptiveLimiter {
+	return &adaptiveLimiter{limit: adaptiveInitialLimit, observer: observer}
+}
+
+// acquire blocks until the limit allows another request or the context is done.
+func (l *adaptiveLimiter) acquire(ctx context.Context) error {
+	l.mu.Lock()
+	if l.inflight < int(l.limit) {
+		l.inflight++
+		l.mu.Unlock()
+		return nil
+	}
+	ch := make(chan struct{})
+	l.waiters = append(l.waiters, ch)
+	l.mu.Unlock()
+
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		for i, w := range l.waiters {
+			if w == ch {
+				l.waiters = append(l.waiters[:i], l.waiters[i+1:]...)
+				return ctx.Err()
+			}
+		}
+
+		// The slot was handed over concurrently, pass it on
+		l.inflight--
+		l.wake()
+		return ctx.Err()
+	}
+}
+
+// release ends a request, adjusting the limit using the outcome unless the request was dropped (e.g. cancelled).
+func (l *adaptiveLimiter) release(rtt time.Duration, failed, dropped bool) {
+	l.mu.Lock()
+	before := int(l.limit)
+	l.inflight--
+	if !dropped {
+		l.update(rtt, failed)
+	}
+	l.wake()
+	after := int(l.limit)
+	l.mu.Unlock()
+
+	if l.observer != nil && after != before {
+		l.observer(after)
+	}
+}
+
+// update adjusts the limit using a single sample.
+func (l *adaptiveLimiter) update(rtt time.Duration, failed bool) {
+	if failed {
+		l.limit /= 2
+	} else {
+		l.samples++
+		if l.minRTT == 0 || rtt < l.minRTT || l.samples%adaptiveMinRTTSamples == 0 {
+			l.minRTT = rtt
+		}
+
+		if float64(rtt) > adaptiveTolerance*float64(l.minRTT) {
+			// Latency is rising, requests are queueing on the server
+			l.limit *= 0.9
+		} else if l.inflight+1 >= int(l.limit)/2 {
+			// Only probe upwards while the limit is actually being used
+			l.limit += 1 / l.limit
+		}
+	}
+
+	if l.limit < 1 {
+		l.limit = 1
+	} else if l.limit > adaptiveMaxLimit {
+		l.limit = adaptiveMaxLimit
+	}
+}
+
+// wake hands free slots to waiting requests.
+func (l *adaptiveLimiter) wake() {
+	for len(l.waiters) > 0 && l.inflight < int(l.limit) {
+		close(l.waiters[0])
+		l.waiters = l.waiters[1:]
+		l.inflight++
+	}
+}
+
+// adaptiveConcurrencyTransport limits concurrent requests using an adaptive limit.
+type adaptiveConcurrencyTransport struct {
+	limiter *adaptiveLimiter
+	clock   Clock
+	base    http.RoundTripper
+}
+
+// RoundTrip waits for the limit to allow the request, measuring the latency until the response is received.
+func (t *adaptiveConcurrencyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.acquire(req.Context()); err != nil {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, err
+	}
+
+	clock := clockOf(t.clock)
+	start := clock.Now()
+	resp, err := transport(t.base).RoundTrip(req)
+	rtt := clock.Now().Sub(start)
+	if err != nil {
+		t.limiter.release(rtt, true, errors.Is(req.Context().Err(), context.Canceled))
+		return nil, err
+	}
+
+	failed := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	var once sync.Once
+	resp.Body = &releaseBody{ReadCloser: resp.Body, release: func() {
+		once.Do(func() { t.limiter.release(rtt, failed, false) })
+	}}
+	return resp, nil
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdaptiveLimiter(t *testing.T) {
+	var observed []int
+	l := newAdaptiveLimiter(func(limit int) { observed = append(observed, limit) })
+	ctx := context.Background()
+
+	// Stable latency under load probes upwards
+	for i := 0; i < 100; i++ {
+		for j := 0; j < int(l.limit); j++ {
+			require.NoError(t, l.acquire(ctx))
+		}
+		for j := int(l.limit); j > 0; j-- {
+			l.release(10*time.Millisecond, false, false)
+		}
+	}
+	assert.Greater(t, int(l.limit), adaptiveInitialLimit)
+	peak := int(l.limit)
+
+	// Failures back off multiplicatively
+	require.NoError(t, l.acquire(ctx))
+	l.release(10*time.Millisecond, true, false)
+	assert.Equal(t, peak/2, int(l.limit))
+
+	// Rising latency backs off gradually
+	limit := l.limit
+	require.NoError(t, l.acquire(ctx))
+	l.release(time.Second, false, false)
+	assert.Less(t, l.limit, limit)
+
+	// Dropped requests are ignored
+	limit = l.limit
+	require.NoError(t, l.acquire(ctx))
+	l.release(time.Millisecond, true, true)
+	assert.Equal(t, limit, l.limit)
+	assert.Equal(t, 0, l.inflight)
+
+	if assert.NotEmpty(t, observed) {
+		assert.Equal(t, int(l.limit), observed[len(observed)-1])
+	}
+}
+
+func TestAdaptiveLimiter_Waiters(t *testing.T) {
+	l := newAdaptiveLimiter(nil)
+	for i := 0; i < adaptiveInitialLimit; i++ {
+		require.NoError(t, l.acquire(context.Background()))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, l.acquire(ctx))
+
+	done := make(chan error)
+	go func() { done <- l.acquire(context.Background()) }()
+	time.Sleep(10 * time.Millisecond)
+	l.release(time.Millisecond, false, false)
+	assert.NoError(t, <-done)
+	assert.Equal(t, adaptiveInitialLimit, l.inflight)
+}
+
+func TestWithAdaptiveConcurrency(t *testing.T) {
+	// The server becomes overloaded with more than 8 concurrent requests
+	var current, peak int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&current, 1)
+		defer atomic.AddInt32(&current, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		if n > 8 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		time.Sleep(2 * time.Millisecond)
+	}))
+	defer ts.Close()
+
+	var limit int32 = adaptiveInitialLimit
+	client := newTestClient(t, ts, WithAdaptiveConcurrency(), WithConcurrencyLimitObserver(func(l int) {
+		atomic.StoreInt32(&limit, int32(l))
+	}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				req, err := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+				if !assert.NoError(t, err) {
+					return
+				}
+				_, _, err = client.Do(context.Background(), req)
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	l := atomic.LoadInt32(&limit)
+	assert.GreaterOrEqual(t, l, int32(1))
+	assert.LessOrEqual(t, l, int32(16))
+	assert.LessOrEqual(t, atomic.LoadInt32(&peak), int32(20), "concurrency was not limited")
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apitest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/thestormforge/optimize-go/pkg/api"
+)
+
+// FakeClock is a clock whose time only changes when it is advanced, for use with `api.WithClock`.
+type FakeClock struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	now    time.Time
+	timers map[*fakeTimer]struct{}
+}
+
+var _ api.Clock = &FakeClock{}
+
+// NewFakeClock returns a new fake clock set to the supplied time.
+func NewFakeClock(now time.Time) *FakeClock {
+	c := &FakeClock{now: now, timers: make(map[*fakeTimer]struct{})}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// Now returns the current time of the clock.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward, firing any timers (and waking any sleepers) that are due.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	for t := range c.timers {
+		if !t.when.After(c.now) {
+			c.fire(t)
+		}
+	}
+}
+
+// BlockUntil waits for at least `n` timers (including sleepers) to be waiting on the clock. This is used to ensure a
+// goroutine has started waiting before the clock is advanced.
+func (c *FakeClock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.timers) < n {
+		c.cond.Wait()
+	}
+}
+
+// Sleep blocks until the clock is advanced by the specified amount of time or the context is done.
+func (c *FakeClock) Sleep(ctx context.Context, d time.Duration) error {
+	t := c.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C():
+		return nil
+	}
+}
+
+// NewTimer returns a timer that fires once the clock is advanced by the specified amount of time.
+func (c *FakeClock) NewTimer(d time.Duration) api.Timer {
+	t := &fakeTimer{clock: c, c: make(chan time.Time, 1)}
+	t.Reset(d)
+	return t
+}
+
+// fire delivers the current time to a timer and stops it, the caller must hold the lock.
+func (c *FakeClock) fire(t *fakeTimer) {
+	delete(c.timers, t)
+	select {
+	case t.c <- c.now:
+	default:
+	}
+}
+
+// fakeTimer is a timer driven by a fake clock.
+type fakeTimer struct {
+	clock *FakeClock
+	when  time.Time
+	c     chan time.Time
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	_, active := t.clock.timers[t]
+	delete(t.clock.timers, t)
+	return active
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	_, active := t.clock.timers[t]
+	t.when = t.clock.now.Add(d)
+	if d <= 0 {
+		t.clock.fire(t)
+		return active
+	}
+	t.clock.timers[t] = struct{}{}
+	t.clock.cond.Broadcast()
+	return active
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apitest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thestormforge/optimize-go/pkg/api"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+
+	timer := clock.NewTimer(time.Minute)
+	clock.Advance(59 * time.Second)
+	assert.Empty(t, timer.C())
+	clock.Advance(time.Second)
+	if assert.Len(t, timer.C(), 1) {
+		assert.Equal(t, start.Add(time.Minute), <-timer.C())
+	}
+	assert.False(t, timer.Stop())
+
+	assert.False(t, timer.Reset(time.Minute))
+	assert.True(t, timer.Stop())
+	clock.Advance(time.Hour)
+	assert.Empty(t, timer.C())
+
+	done := make(chan error)
+	go func() { done <- clock.Sleep(context.Background(), time.Second) }()
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	assert.NoError(t, <-done)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.True(t, errors.Is(clock.Sleep(ctx, time.Second), context.Canceled))
+}
+
+func TestFakeClock_Retry(t *testing.T) {
+	var attempts int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer ts.Close()
+
+	endpoints, err := api.Endpoints(ts.URL, nil)
+	require.NoError(t, err)
+	clock := NewFakeClock(time.Now())
+	client, err := api.NewClient(context.Background(), api.StaticTokenConfig("token", endpoints),
+		api.WithRetry(3, time.Hour), api.WithClock(clock))
+	require.NoError(t, err)
+
+	done := make(chan *http.Response)
+	go func() {
+		req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+		resp, _, err := client.Do(context.Background(), req)
+		assert.NoError(t, err)
+		done <- resp
+	}()
+
+	// Each backoff is at most the (doubling) base delay, no real time passes
+	for _, d := range []time.Duration{time.Hour, 2 * time.Hour} {
+		clock.BlockUntil(1)
+		clock.Advance(d)
+	}
+	if resp := <-done; assert.NotNil(t, resp) {
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+	assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+}
+
+func TestFakeClock_PollOperation(t *testing.T) {
+	clock := NewFakeClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	m := NewMock().WithClock(clock).On(http.MethodGet, "/operations/1",
+		Response{StatusCode: http.StatusAccepted},
+		Response{StatusCode: http.StatusAccepted, Header: http.Header{"Retry-After": {"Wed, 01 Jan 2020 02:00:00 GMT"}}},
+		JSONResponse(http.StatusOK, `{"done":true}`),
+	)
+
+	done := make(chan error)
+	go func() {
+		_, _, err := api.PollOperation(context.Background(), m, m.URL("/operations/1").String(), time.Hour)
+		done <- err
+	}()
+
+	// The interval is used first, then the Retry-After date relative to the clock
+	for _, d := range []time.Duration{time.Hour, time.Hour} {
+		clock.BlockUntil(1)
+		clock.Advance(d)
+	}
+	assert.NoError(t, <-done)
+	assert.Len(t, m.Requests(), 3)
+}
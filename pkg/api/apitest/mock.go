@@ -0,0 +1,220 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package apitest provides utilities for testing code that uses the API client.
+package apitest
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/thestormforge/optimize-go/pkg/api"
+)
+
+// MockBaseURL is the base URL used to resolve endpoints of the mock client.
+const MockBaseURL = "http://api.example.com"
+
+// Response is a canned response returned by the mock client.
+type Response struct {
+	// StatusCode is the response status code, defaults to 200.
+	StatusCode int
+	// Header contains the response headers.
+	Header http.Header
+	// Body is the response body.
+	Body []byte
+	// Delay is the amount of time to wait before responding; the context may expire while waiting.
+	Delay time.Duration
+	// Err is returned instead of a response.
+	Err error
+}
+
+// JSONResponse returns a canned response with a JSON body.
+func JSONResponse(statusCode int, body string) Response {
+	return Response{
+		StatusCode: statusCode,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       []byte(body),
+	}
+}
+
+// Request is a request that was received by the mock client.
+type Request struct {
+	// Method is the HTTP method of the request.
+	Method string
+	// URL is the location of the request.
+	URL *url.URL
+	// Header contains the request headers.
+	Header http.Header
+	// Body is the request body.
+	Body []byte
+}
+
+// Mock is an API client that returns canned responses.
+type Mock struct {
+	mu        sync.Mutex
+	responses map[string][]Response
+	requests  []Request
+	clock     api.Clock
+}
+
+var _ api.Client = &Mock{}
+
+// NewMock returns a new mock client. Requests for which no response has been registered produce a "404 Not Found".
+func NewMock() *Mock {
+	return &Mock{responses: make(map[string][]Response)}
+}
+
+// On registers responses for a method and URL path. Responses are returned in order; the last response is repeated
+// for any additional requests.
+func (m *Mock) On(method, path string, responses ...Response) *Mock {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	k := key(method, path)
+	m.responses[k] = append(m.responses[k], responses...)
+	return m
+}
+
+// WithClock sets the clock reported to code that waits using the mock client (see `api.ClientClock`), e.g. a
+// `FakeClock` to control polling.
+func (m *Mock) WithClock(clock api.Clock) *Mock {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.clock = clock
+	return m
+}
+
+// Clock returns the clock of the mock client, nil if it uses the real clock.
+func (m *Mock) Clock() api.Clock {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.clock
+}
+
+// Requests returns all of the requests received by the mock client.
+func (m *Mock) Requests() []Request {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Request(nil), m.requests...)
+}
+
+// URL returns the location of the specified endpoint.
+func (m *Mock) URL(endpoint string) *url.URL {
+	u, _ := url.Parse(MockBaseURL + endpoint)
+	return u
+}
+
+// Do returns the next registered response for the request.
+func (m *Mock) Do(ctx context.Context, req *http.Request) (*http.Response, []byte, error) {
+	resp, body, err := m.DoStream(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer body.Close()
+	b, err := ioutil.ReadAll(body)
+	return resp, b, err
+}
+
+// DoStream returns the next registered response for the request.
+func (m *Mock) DoStream(ctx context.Context, req *http.Request) (*http.Response, io.ReadCloser, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	r, err := m.record(req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if r.Delay > 0 {
+		t := time.NewTimer(r.Delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, nil, ctx.Err()
+		case <-t.C:
+		}
+	}
+
+	if r.Err != nil {
+		return nil, nil, r.Err
+	}
+
+	resp := &http.Response{
+		Status:        http.StatusText(r.StatusCode),
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        r.Header.Clone(),
+		Body:          ioutil.NopCloser(bytes.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+		Request:       req.WithContext(ctx),
+	}
+	if resp.StatusCode == 0 {
+		resp.StatusCode = http.StatusOK
+		resp.Status = http.StatusText(http.StatusOK)
+	}
+	if resp.Header == nil {
+		resp.Header = http.Header{}
+	}
+	return resp, resp.Body, nil
+}
+
+// record captures the request and returns the response to use.
+func (m *Mock) record(req *http.Request) (Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return Response{}, err
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests = append(m.requests, Request{
+		Method: req.Method,
+		URL:    req.URL,
+		Header: req.Header.Clone(),
+		Body:   body,
+	})
+
+	k := key(req.Method, req.URL.Path)
+	rs := m.responses[k]
+	switch len(rs) {
+	case 0:
+		return Response{StatusCode: http.StatusNotFound}, nil
+	case 1:
+		return rs[0], nil
+	default:
+		m.responses[k] = rs[1:]
+		return rs[0], nil
+	}
+}
+
+func key(method, path string) string {
+	return strings.ToUpper(method) + " " + path
+}
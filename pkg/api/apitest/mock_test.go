@@ -0,0 +1,70 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apitest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMock(t *testing.T) {
+	m := NewMock().
+		On(http.MethodPost, "/experiments/foo", Response{StatusCode: http.StatusServiceUnavailable}, JSONResponse(http.StatusCreated, `{}`)).
+		On(http.MethodGet, "/slow", Response{Delay: time.Hour}).
+		On(http.MethodGet, "/broken", Response{Err: errors.New("boom")})
+
+	do := func(ctx context.Context, method, ep string) (*http.Response, []byte, error) {
+		req, err := http.NewRequest(method, m.URL(ep).String(), strings.NewReader("payload"))
+		require.NoError(t, err)
+		return m.Do(ctx, req)
+	}
+
+	resp, _, err := do(context.Background(), http.MethodPost, "/experiments/foo")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	resp, body, err := do(context.Background(), http.MethodPost, "/experiments/foo")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "{}", string(body))
+
+	resp, _, err = do(context.Background(), http.MethodGet, "/unknown")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, _, err = do(ctx, http.MethodGet, "/slow")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	_, _, err = do(context.Background(), http.MethodGet, "/broken")
+	assert.EqualError(t, err, "boom")
+
+	reqs := m.Requests()
+	require.Len(t, reqs, 5)
+	assert.Equal(t, http.MethodPost, reqs[0].Method)
+	assert.Equal(t, "/experiments/foo", reqs[0].URL.Path)
+	assert.Equal(t, "payload", string(reqs[0].Body))
+}
@@ -0,0 +1,346 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apitest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Mode controls the behavior of a recorder.
+type Mode int
+
+const (
+	// ModeReplayOrRecord replays interactions from an existing cassette, or records a new cassette if one does not exist.
+	ModeReplayOrRecord Mode = iota
+	// ModeRecord always records interactions, replacing any existing cassette.
+	ModeRecord
+	// ModeReplay only replays interactions, failing if the cassette does not exist.
+	ModeReplay
+)
+
+// Interaction is a recorded request and response pair.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// BodyEncodingBase64 is the encoding of recorded bodies that are not valid UTF-8 text (e.g. compressed bodies).
+const BodyEncodingBase64 = "base64"
+
+// RecordedRequest is the recorded form of a request.
+type RecordedRequest struct {
+	Method       string      `json:"method"`
+	URL          string      `json:"url"`
+	Header       http.Header `json:"header,omitempty"`
+	Body         string      `json:"body,omitempty"`
+	BodyEncoding string      `json:"bodyEncoding,omitempty"`
+}
+
+// RecordedResponse is the recorded form of a response.
+type RecordedResponse struct {
+	StatusCode   int         `json:"statusCode"`
+	Header       http.Header `json:"header,omitempty"`
+	Body         string      `json:"body,omitempty"`
+	BodyEncoding string      `json:"bodyEncoding,omitempty"`
+}
+
+// RecorderOption is used to customize a recorder.
+type RecorderOption func(*Recorder)
+
+// WithMode sets the recording mode, use `ModeRecord` to force a cassette to be re-recorded.
+func WithMode(mode Mode) RecorderOption {
+	return func(r *Recorder) {
+		r.mode = mode
+	}
+}
+
+// WithMatchHeaders includes the named request headers when matching requests to recorded interactions; by default
+// only the method, URL path and query parameters are matched.
+func WithMatchHeaders(names ...string) RecorderOption {
+	return func(r *Recorder) {
+		r.matchHeaders = append(r.matchHeaders, names...)
+	}
+}
+
+// WithScrubber sets the function used to remove sensitive information from interactions before they are written. The
+// default scrubber removes the Authorization header.
+func WithScrubber(scrub func(*Interaction)) RecorderOption {
+	return func(r *Recorder) {
+		r.scrub = scrub
+	}
+}
+
+// ScrubAuthorization removes the Authorization header from the recorded request.
+func ScrubAuthorization(i *Interaction) {
+	i.Request.Header.Del("Authorization")
+}
+
+// Recorder is a transport that records interactions to a cassette file or replays interactions from a cassette file.
+type Recorder struct {
+	filename     string
+	base         http.RoundTripper
+	mode         Mode
+	matchHeaders []string
+	scrub        func(*Interaction)
+
+	mu           sync.Mutex
+	recording    bool
+	interactions []Interaction
+	used         []bool
+}
+
+// NewRecorder returns a new recorder for the specified cassette file. The file is written as JSON if it has a ".json"
+// extension, otherwise YAML is used. When recording, requests are sent using the supplied base transport (or the
+// default transport if the base is nil); the cassette is not written until the recorder is stopped.
+func NewRecorder(filename string, base http.RoundTripper, opts ...RecorderOption) (*Recorder, error) {
+	r := &Recorder{
+		filename: filename,
+		base:     base,
+		scrub:    ScrubAuthorization,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	switch r.mode {
+	case ModeRecord:
+		r.recording = true
+	case ModeReplayOrRecord, ModeReplay:
+		data, err := ioutil.ReadFile(filename)
+		if os.IsNotExist(err) && r.mode == ModeReplayOrRecord {
+			r.recording = true
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if err := yaml.Unmarshal(data, &r.interactions); err != nil {
+			return nil, fmt.Errorf("apitest: invalid cassette %s: %w", filename, err)
+		}
+		r.used = make([]bool, len(r.interactions))
+	}
+
+	return r, nil
+}
+
+// Recording returns true if the recorder is recording new interactions.
+func (r *Recorder) Recording() bool {
+	return r.recording
+}
+
+// RoundTrip records or replays an interaction.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if r.recording {
+		return r.record(req)
+	}
+	return r.replay(req)
+}
+
+// Stop writes the cassette file if the recorder is recording.
+func (r *Recorder) Stop() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.recording {
+		return nil
+	}
+
+	interactions := make([]Interaction, 0, len(r.interactions))
+	for _, i := range r.interactions {
+		i.Request.Header = i.Request.Header.Clone()
+		i.Response.Header = i.Response.Header.Clone()
+		if r.scrub != nil {
+			r.scrub(&i)
+		}
+		interactions = append(interactions, i)
+	}
+
+	var data []byte
+	var err error
+	if strings.EqualFold(filepath.Ext(r.filename), ".json") {
+		data, err = json.MarshalIndent(interactions, "", "  ")
+	} else {
+		data, err = yaml.Marshal(interactions)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.filename), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.filename, data, 0644)
+}
+
+func (r *Recorder) record(req *http.Request) (*http.Response, error) {
+	req, reqBody, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	base := r.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+	i := Interaction{
+		Request: RecordedRequest{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Header: req.Header.Clone(),
+		},
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header.Clone(),
+		},
+	}
+	i.Request.Body, i.Request.BodyEncoding = encodeBody(reqBody)
+	i.Response.Body, i.Response.BodyEncoding = encodeBody(respBody)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interactions = append(r.interactions, i)
+
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Prefer interactions in the order they were recorded, but allow reuse
+	match := -1
+	for i := range r.interactions {
+		if r.matches(req, &r.interactions[i].Request) {
+			if !r.used[i] {
+				match = i
+				break
+			}
+			if match < 0 {
+				match = i
+			}
+		}
+	}
+	if match < 0 {
+		return nil, fmt.Errorf("apitest: no recorded interaction for %s %s", req.Method, req.URL.Path)
+	}
+	r.used[match] = true
+
+	rr := &r.interactions[match].Response
+	body, err := decodeBody(rr.Body, rr.BodyEncoding)
+	if err != nil {
+		return nil, fmt.Errorf("apitest: invalid recorded body for %s %s: %w", req.Method, req.URL.Path, err)
+	}
+	return &http.Response{
+		Status:        http.StatusText(rr.StatusCode),
+		StatusCode:    rr.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        rr.Header.Clone(),
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+func (r *Recorder) matches(req *http.Request, rr *RecordedRequest) bool {
+	if req.Method != rr.Method {
+		return false
+	}
+	path, query := urlPathQuery(rr.URL)
+	if req.URL.Path != path || req.URL.Query().Encode() != query {
+		return false
+	}
+	for _, h := range r.matchHeaders {
+		if req.Header.Get(h) != rr.Header.Get(h) {
+			return false
+		}
+	}
+	return true
+}
+
+// urlPathQuery returns the path and the normalized query string of a recorded URL.
+func urlPathQuery(u string) (string, string) {
+	pu, err := url.Parse(u)
+	if err != nil {
+		return u, ""
+	}
+	return pu.Path, pu.Query().Encode()
+}
+
+// encodeBody returns the recorded form of a body, bodies that are not valid UTF-8 are base64 encoded.
+func encodeBody(b []byte) (string, string) {
+	if utf8.Valid(b) {
+		return string(b), ""
+	}
+	return base64.StdEncoding.EncodeToString(b), BodyEncodingBase64
+}
+
+// decodeBody returns the body from its recorded form.
+func decodeBody(body, encoding string) ([]byte, error) {
+	switch encoding {
+	case "":
+		return []byte(body), nil
+	case BodyEncodingBase64:
+		return base64.StdEncoding.DecodeString(body)
+	default:
+		return nil, fmt.Errorf("unknown body encoding %q", encoding)
+	}
+}
+
+// readBody reads the request body, returning a copy of the request with a replacement body.
+func readBody(req *http.Request) (*http.Request, []byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, nil, nil
+	}
+	b, err := ioutil.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Body = ioutil.NopCloser(bytes.NewReader(b))
+	return req, b, nil
+}
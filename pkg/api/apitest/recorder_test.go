@@ -0,0 +1,121 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apitest
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte(r.Method + " " + r.URL.Path + " " + string(b)))
+	}))
+
+	filename := filepath.Join(t.TempDir(), "cassette.yaml")
+	do := func(rt http.RoundTripper, method, path string) string {
+		req, err := http.NewRequest(method, ts.URL+path, strings.NewReader("body"))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer secret")
+		resp, err := rt.RoundTrip(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		b, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(b)
+	}
+
+	// Record against the live server
+	rec, err := NewRecorder(filename, nil)
+	require.NoError(t, err)
+	assert.True(t, rec.Recording())
+	assert.Equal(t, "GET /foo body", do(rec, http.MethodGet, "/foo"))
+	assert.Equal(t, "POST /bar body", do(rec, http.MethodPost, "/bar"))
+	require.NoError(t, rec.Stop())
+	ts.Close()
+
+	cassette, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	assert.NotContains(t, string(cassette), "secret")
+
+	// Replay without the server
+	rep, err := NewRecorder(filename, nil, WithMode(ModeReplay))
+	require.NoError(t, err)
+	assert.False(t, rep.Recording())
+	assert.Equal(t, "POST /bar body", do(rep, http.MethodPost, "/bar"))
+	assert.Equal(t, "GET /foo body", do(rep, http.MethodGet, "/foo"))
+
+	req, err := http.NewRequest(http.MethodDelete, ts.URL+"/foo", nil)
+	require.NoError(t, err)
+	_, err = rep.RoundTrip(req)
+	assert.Error(t, err)
+
+	// Replay only fails for a missing cassette
+	_, err = NewRecorder(filepath.Join(t.TempDir(), "missing.yaml"), nil, WithMode(ModeReplay))
+	assert.Error(t, err)
+}
+
+func TestRecorder_BinaryAndQuery(t *testing.T) {
+	binary := []byte{0x1f, 0x8b, 0x08, 0x00, 0xff, 0xfe, 0x00}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/binary" {
+			_, _ = w.Write(binary)
+			return
+		}
+		_, _ = w.Write([]byte("page " + r.URL.Query().Get("offset")))
+	}))
+
+	filename := filepath.Join(t.TempDir(), "cassette.json")
+	do := func(rt http.RoundTripper, path string) []byte {
+		req, err := http.NewRequest(http.MethodGet, ts.URL+path, nil)
+		require.NoError(t, err)
+		resp, err := rt.RoundTrip(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		b, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return b
+	}
+
+	rec, err := NewRecorder(filename, nil)
+	require.NoError(t, err)
+	assert.Equal(t, binary, do(rec, "/binary"))
+	assert.Equal(t, "page 0", string(do(rec, "/list?offset=0&limit=10")))
+	assert.Equal(t, "page 10", string(do(rec, "/list?offset=10&limit=10")))
+	require.NoError(t, rec.Stop())
+	ts.Close()
+
+	rep, err := NewRecorder(filename, nil, WithMode(ModeReplay))
+	require.NoError(t, err)
+	assert.Equal(t, binary, do(rep, "/binary"))
+	assert.Equal(t, "page 10", string(do(rep, "/list?limit=10&offset=10")))
+	assert.Equal(t, "page 0", string(do(rep, "/list?offset=0&limit=10")))
+
+	req, err := http.NewRequest(http.MethodGet, ts.URL+"/list?offset=20&limit=10", nil)
+	require.NoError(t, err)
+	_, err = rep.RoundTrip(req)
+	assert.Error(t, err)
+}
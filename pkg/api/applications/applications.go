@@ -0,0 +1,222 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package applications provides a typed client for the applications API.
+package applications
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/thestormforge/optimize-go/pkg/api"
+)
+
+const (
+	endpointApplications = "/applications/"
+
+	relationSelf      = "self"
+	relationNext      = "next"
+	relationPrev      = "prev"
+	relationScenarios = "https://stormforge.io/rel/scenarios"
+)
+
+// API is a typed client for the applications API. All unsuccessful responses are returned as an `*api.Error`.
+type API interface {
+	// ListApplications returns a single page of applications.
+	ListApplications(ctx context.Context, q *ApplicationListQuery) (ApplicationList, error)
+	// GetApplication returns the named application.
+	GetApplication(ctx context.Context, name string) (Application, error)
+	// ListScenarios returns the scenarios of the named application.
+	ListScenarios(ctx context.Context, application string) (ScenarioList, error)
+	// RunScenario starts a run of the named scenario, kicking off trials of the experiment associated with it. The
+	// labels of the run (which may be nil) are applied to the resulting trials.
+	RunScenario(ctx context.Context, application, scenario string, run ScenarioRun) (ScenarioRun, error)
+}
+
+// NewAPI returns a new applications API using the supplied client.
+func NewAPI(c api.Client, opts ...Option) API {
+	h := &httpAPI{client: c}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Option is used to customize the behavior of the applications API.
+type Option func(*httpAPI)
+
+// WithContentTypes sets the media types of responses which are decoded as JSON, by default only "application/json"
+// is accepted.
+func WithContentTypes(mediaTypes ...string) Option {
+	return func(h *httpAPI) {
+		h.contentTypes = mediaTypes
+	}
+}
+
+type httpAPI struct {
+	client api.Client
+
+	contentTypes []string
+}
+
+func (h *httpAPI) ListApplications(ctx context.Context, q *ApplicationListQuery) (ApplicationList, error) {
+	lst := ApplicationList{}
+
+	query, err := url.ParseQuery(q.Encode())
+	if err != nil {
+		return lst, err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, api.URLWithQuery(h.client, endpointApplications, query).String(), nil)
+	if err != nil {
+		return lst, err
+	}
+
+	resp, err := h.do(ctx, req, &lst)
+	if err != nil {
+		return lst, err
+	}
+
+	links := parseLinks(resp.Header)
+	lst.Next, lst.Prev = links[relationNext], links[relationPrev]
+	for i := range lst.Applications {
+		if lst.Applications[i].SelfURL == "" && lst.Applications[i].Name != "" {
+			lst.Applications[i].SelfURL = h.applicationURL(lst.Applications[i].Name)
+		}
+	}
+	return lst, nil
+}
+
+func (h *httpAPI) GetApplication(ctx context.Context, name string) (Application, error) {
+	app := Application{}
+
+	req, err := http.NewRequest(http.MethodGet, h.applicationURL(name), nil)
+	if err != nil {
+		return app, err
+	}
+
+	resp, err := h.do(ctx, req, &app)
+	if err != nil {
+		return app, err
+	}
+
+	app.SelfURL = h.applicationURL(name)
+	if lastModified, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		app.LastModified = lastModified
+	}
+	links := parseLinks(resp.Header)
+	if self := links[relationSelf]; self != "" {
+		app.SelfURL = self
+	}
+	app.ScenariosURL = links[relationScenarios]
+	if app.ScenariosURL == "" {
+		app.ScenariosURL = app.SelfURL + "/scenarios/"
+	}
+	return app, nil
+}
+
+func (h *httpAPI) ListScenarios(ctx context.Context, application string) (ScenarioList, error) {
+	lst := ScenarioList{}
+
+	req, err := http.NewRequest(http.MethodGet, h.applicationURL(application)+"/scenarios/", nil)
+	if err != nil {
+		return lst, err
+	}
+
+	resp, err := h.do(ctx, req, &lst)
+	if err != nil {
+		return lst, err
+	}
+
+	lst.Next = parseLinks(resp.Header)[relationNext]
+	for i := range lst.Scenarios {
+		lst.Scenarios[i].SelfURL = h.scenarioURL(application, lst.Scenarios[i].Name)
+	}
+	return lst, nil
+}
+
+func (h *httpAPI) RunScenario(ctx context.Context, application, scenario string, run ScenarioRun) (ScenarioRun, error) {
+	result := ScenarioRun{}
+
+	b, err := api.ClientCodec(h.client).Marshal(run)
+	if err != nil {
+		return result, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, h.scenarioURL(application, scenario)+"/run", bytes.NewReader(b))
+	if err != nil {
+		return result, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := h.do(ctx, req, &result)
+	if err != nil {
+		return result, err
+	}
+
+	if loc := resp.Header.Get("Location"); loc != "" {
+		if u, err := req.URL.Parse(loc); err == nil {
+			result.SelfURL = u.String()
+		}
+	}
+	return result, nil
+}
+
+// applicationURL returns the location of the named application.
+func (h *httpAPI) applicationURL(name string) string {
+	return h.client.URL(endpointApplications + url.PathEscape(name)).String()
+}
+
+// scenarioURL returns the location of the named scenario.
+func (h *httpAPI) scenarioURL(application, scenario string) string {
+	return h.applicationURL(application) + "/scenarios/" + url.PathEscape(scenario)
+}
+
+// do performs the request, decoding a successful JSON response into the supplied value.
+func (h *httpAPI) do(ctx context.Context, req *http.Request, v interface{}) (*http.Response, error) {
+	req.Header.Set("Accept", "application/json")
+
+	resp, body, err := h.client.Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp, api.DecodeError(h.client, resp, body)
+	}
+
+	if resp.StatusCode != http.StatusNoContent && len(body) > 0 {
+		if err := api.CheckContentType(resp, body, h.contentTypes...); err != nil {
+			return resp, err
+		}
+		if err := api.ClientCodec(h.client).Unmarshal(body, v); err != nil {
+			return resp, err
+		}
+	}
+	return resp, nil
+}
+
+// parseLinks returns the links from the response headers, indexed by their lower case relation type.
+func parseLinks(header http.Header) map[string]string {
+	links := make(map[string]string)
+	for rel, u := range api.ParseLinkHeader(strings.Join(header.Values("Link"), ",")) {
+		links[strings.ToLower(rel)] = u.String()
+	}
+	return links
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package applications
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thestormforge/optimize-go/pkg/api"
+	"github.com/thestormforge/optimize-go/pkg/api/apitest"
+)
+
+func TestAPI_ListApplications(t *testing.T) {
+	resp := apitest.JSONResponse(http.StatusOK, `{"applications":[{"name":"web","displayName":"Web Store","scenarioCount":2}]}`)
+	resp.Header.Add("Link", `<`+apitest.MockBaseURL+`/applications/?offset=1>;rel="next"`)
+	m := apitest.NewMock().On(http.MethodGet, "/applications/", resp)
+
+	lst, err := NewAPI(m).ListApplications(context.Background(), &ApplicationListQuery{Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, apitest.MockBaseURL+"/applications/?offset=1", lst.Next)
+	if assert.Len(t, lst.Applications, 1) {
+		assert.Equal(t, "Web Store", lst.Applications[0].DisplayName)
+		assert.Equal(t, 2, lst.Applications[0].ScenarioCount)
+		assert.Equal(t, apitest.MockBaseURL+"/applications/web", lst.Applications[0].SelfURL)
+	}
+	if reqs := m.Requests(); assert.Len(t, reqs, 1) {
+		assert.Equal(t, "1", reqs[0].URL.Query().Get("limit"))
+	}
+}
+
+func TestAPI_GetApplication(t *testing.T) {
+	m := apitest.NewMock().
+		On(http.MethodGet, "/applications/web", apitest.JSONResponse(http.StatusOK, `{"name":"web","labels":{"team":"store"}}`)).
+		On(http.MethodGet, "/applications/missing", apitest.JSONResponse(http.StatusNotFound, `{"error":"application not found"}`))
+	a := NewAPI(m)
+
+	app, err := a.GetApplication(context.Background(), "web")
+	require.NoError(t, err)
+	assert.Equal(t, "web", app.Name)
+	assert.Equal(t, map[string]string{"team": "store"}, app.Labels)
+	assert.Equal(t, apitest.MockBaseURL+"/applications/web/scenarios/", app.ScenariosURL)
+
+	_, err = a.GetApplication(context.Background(), "missing")
+	assert.True(t, errors.Is(err, api.ErrNotFound))
+}
+
+func TestAPI_ListScenarios(t *testing.T) {
+	m := apitest.NewMock().On(http.MethodGet, "/applications/web/scenarios/", apitest.JSONResponse(http.StatusOK, `{"scenarios":[
+		{"name":"black-friday","objectives":[{"name":"cost","minimize":true},{"name":"throughput"}]}
+	]}`))
+
+	lst, err := NewAPI(m).ListScenarios(context.Background(), "web")
+	require.NoError(t, err)
+	if assert.Len(t, lst.Scenarios, 1) {
+		assert.Equal(t, []Objective{{Name: "cost", Minimize: true}, {Name: "throughput"}}, lst.Scenarios[0].Objectives)
+		assert.Equal(t, apitest.MockBaseURL+"/applications/web/scenarios/black-friday", lst.Scenarios[0].SelfURL)
+	}
+}
+
+func TestAPI_RunScenario(t *testing.T) {
+	resp := apitest.JSONResponse(http.StatusAccepted, `{"experiment":"web-black-friday","status":"pending"}`)
+	resp.Header.Set("Location", "/applications/web/scenarios/black-friday/runs/1")
+	m := apitest.NewMock().On(http.MethodPost, "/applications/web/scenarios/black-friday/run", resp)
+
+	run, err := NewAPI(m).RunScenario(context.Background(), "web", "black-friday", ScenarioRun{Labels: map[string]string{"source": "ci"}})
+	require.NoError(t, err)
+	assert.Equal(t, "web-black-friday", run.Experiment)
+	assert.Equal(t, "pending", run.Status)
+	assert.Equal(t, apitest.MockBaseURL+"/applications/web/scenarios/black-friday/runs/1", run.SelfURL)
+	if reqs := m.Requests(); assert.Len(t, reqs, 1) {
+		assert.JSONEq(t, `{"labels":{"source":"ci"}}`, string(reqs[0].Body))
+	}
+}
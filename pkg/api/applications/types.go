@@ -0,0 +1,121 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package applications
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Application is a deployed application whose scenarios are used to drive experiments.
+type Application struct {
+	// SelfURL is the location of the application.
+	SelfURL string `json:"-"`
+	// ScenariosURL is the location of the application's scenarios.
+	ScenariosURL string `json:"-"`
+	// LastModified is the time the application was last changed.
+	LastModified time.Time `json:"-"`
+
+	// The name of the application.
+	Name string `json:"name"`
+	// A human-readable name for the application.
+	DisplayName string `json:"displayName,omitempty"`
+	// The number of scenarios defined for the application.
+	ScenarioCount int `json:"scenarioCount,omitempty"`
+	// Labels for the application.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// ApplicationList is a single page of applications.
+type ApplicationList struct {
+	// Next is the location of the next page, empty on the last page.
+	Next string `json:"-"`
+	// Prev is the location of the previous page, empty on the first page.
+	Prev string `json:"-"`
+
+	// The list of applications.
+	Applications []Application `json:"applications"`
+}
+
+// ApplicationListQuery restricts the applications returned by a list request.
+type ApplicationListQuery struct {
+	// The number of applications to skip.
+	Offset int
+	// The maximum number of applications to return.
+	Limit int
+}
+
+// Encode returns the query string for the query.
+func (q *ApplicationListQuery) Encode() string {
+	if q == nil {
+		return ""
+	}
+	v := url.Values{}
+	if q.Offset != 0 {
+		v.Set("offset", strconv.Itoa(q.Offset))
+	}
+	if q.Limit != 0 {
+		v.Set("limit", strconv.Itoa(q.Limit))
+	}
+	return v.Encode()
+}
+
+// Objective is a goal of a scenario.
+type Objective struct {
+	// The name of the objective's metric.
+	Name string `json:"name"`
+	// The flag indicating the metric should be minimized.
+	Minimize bool `json:"minimize,omitempty"`
+}
+
+// Scenario describes how an application is exercised during an experiment.
+type Scenario struct {
+	// SelfURL is the location of the scenario.
+	SelfURL string `json:"-"`
+
+	// The name of the scenario.
+	Name string `json:"name"`
+	// A human-readable name for the scenario.
+	DisplayName string `json:"displayName,omitempty"`
+	// The objectives of experiments run using the scenario.
+	Objectives []Objective `json:"objectives,omitempty"`
+	// Labels for the scenario.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// ScenarioList is the list of scenarios for an application.
+type ScenarioList struct {
+	// Next is the location of the next page, empty on the last page.
+	Next string `json:"-"`
+
+	// The list of scenarios.
+	Scenarios []Scenario `json:"scenarios"`
+}
+
+// ScenarioRun is a request to run a scenario, producing trials for an experiment.
+type ScenarioRun struct {
+	// SelfURL is the location of the run.
+	SelfURL string `json:"-"`
+
+	// The name of the experiment the run produces trials for.
+	Experiment string `json:"experiment,omitempty"`
+	// The current status of the run (e.g. "pending" or "running").
+	Status string `json:"status,omitempty"`
+	// Labels applied to the trials of the run.
+	Labels map[string]string `json:"labels,omitempty"`
+}
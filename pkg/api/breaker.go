@@ -0,0 +1,145 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without sending the request while the circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// WithCircuitBreaker stops sending requests after `threshold` consecutive failures occur within `window`. While the
+// circuit is open requests fail immediately with `ErrCircuitOpen`; once `cooldown` has elapsed a single request is
+// allowed through to probe the server, closing the circuit if it succeeds. Only transport errors (including requests
+// that exceed a deadline) and "5xx" responses are considered failures; requests cancelled by the caller are not.
+func WithCircuitBreaker(threshold int, window, cooldown time.Duration) Option {
+	return func(c *httpClient) {
+		c.breaker = &circuitBreaker{threshold: threshold, window: window, cooldown: cooldown}
+	}
+}
+
+// circuitState is the state of a circuit breaker.
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// circuitBreaker tracks consecutive failures.
+type circuitBreaker struct {
+	threshold int
+	window    time.Duration
+	cooldown  time.Duration
+	clock     Clock
+
+	mu           sync.Mutex
+	state        circuitState
+	failures     int
+	firstFailure time.Time
+	openedAt     time.Time
+}
+
+// allow checks to see if a request can be sent.
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case circuitOpen:
+		if clockOf(b.clock).Now().Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = circuitHalfOpen
+		return true
+	case circuitHalfOpen:
+		// Only one probe is allowed at a time
+		return false
+	default:
+		return true
+	}
+}
+
+// record updates the state of the circuit using the outcome of a request.
+func (b *circuitBreaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !failed {
+		b.state = circuitClosed
+		b.failures = 0
+		return
+	}
+
+	now := clockOf(b.clock).Now()
+	if b.state == circuitHalfOpen {
+		b.state, b.openedAt = circuitOpen, now
+		return
+	}
+
+	if b.failures == 0 || now.Sub(b.firstFailure) > b.window {
+		b.failures, b.firstFailure = 0, now
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		b.state, b.openedAt = circuitOpen, now
+	}
+}
+
+// release returns the circuit to the open state (with the cooldown already elapsed) if a probe did not complete.
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == circuitHalfOpen {
+		b.state = circuitOpen
+	}
+}
+
+// circuitBreakerTransport fails requests while the circuit breaker is open.
+type circuitBreakerTransport struct {
+	breaker *circuitBreaker
+	base    http.RoundTripper
+}
+
+// RoundTrip delegates to the base transport if the circuit breaker allows it.
+func (t *circuitBreakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.breaker.allow() {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, ErrCircuitOpen
+	}
+
+	resp, err := transport(t.base).RoundTrip(req)
+	switch {
+	case err != nil && errors.Is(req.Context().Err(), context.Canceled):
+		// Cancellation by the caller says nothing about the health of the server, release a probe without judgement
+		t.breaker.release()
+	case err != nil:
+		t.breaker.record(true)
+	default:
+		t.breaker.record(resp.StatusCode >= 500)
+	}
+	return resp, err
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithCircuitBreaker(t *testing.T) {
+	var status, hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(int(atomic.LoadInt32(&status)))
+	}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts, WithCircuitBreaker(2, time.Minute, 50*time.Millisecond))
+	do := func() error {
+		req, err := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+		require.NoError(t, err)
+		_, _, err = client.Do(context.Background(), req)
+		return err
+	}
+
+	// Client errors do not trip the breaker
+	atomic.StoreInt32(&status, http.StatusNotFound)
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, do())
+	}
+	assert.Equal(t, int32(3), atomic.LoadInt32(&hits))
+
+	// Server errors do
+	atomic.StoreInt32(&status, http.StatusInternalServerError)
+	assert.NoError(t, do())
+	assert.NoError(t, do())
+	assert.True(t, errors.Is(do(), ErrCircuitOpen))
+	assert.Equal(t, int32(5), atomic.LoadInt32(&hits))
+
+	// A failed probe re-opens the circuit
+	time.Sleep(60 * time.Millisecond)
+	assert.NoError(t, do())
+	assert.True(t, errors.Is(do(), ErrCircuitOpen))
+	assert.Equal(t, int32(6), atomic.LoadInt32(&hits))
+
+	// A successful probe closes it
+	time.Sleep(60 * time.Millisecond)
+	atomic.StoreInt32(&status, http.StatusOK)
+	assert.NoError(t, do())
+	assert.NoError(t, do())
+	assert.Equal(t, int32(8), atomic.LoadInt32(&hits))
+}
+
+func TestWithCircuitBreaker_Cancellation(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts, WithCircuitBreaker(2, time.Minute, time.Minute))
+	do := func(ctx context.Context) error {
+		req, err := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+		require.NoError(t, err)
+		_, _, err = client.Do(ctx, req)
+		return err
+	}
+
+	// Cancellation by the caller does not trip the breaker
+	for i := 0; i < 3; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+		assert.True(t, errors.Is(do(ctx), context.Canceled))
+	}
+
+	// Requests that exceed their deadline do
+	for i := 0; i < 2; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		assert.True(t, errors.Is(do(ctx), context.DeadlineExceeded))
+		cancel()
+	}
+	assert.True(t, errors.Is(do(context.Background()), ErrCircuitOpen))
+}
@@ -0,0 +1,244 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// cacheKeyHeaders are the request headers that select the representation of a cached response.
+var cacheKeyHeaders = []string{"Accept", "Accept-Language", HeaderOrganizationID, "Authorization"}
+
+// CachedResponse is a response stored in a cache.
+type CachedResponse struct {
+	// ETag is the entity tag of the response.
+	ETag string
+	// StatusCode is the status code of the original response.
+	StatusCode int
+	// Status is the status line text of the original response (e.g. "200 OK").
+	Status string
+	// Header contains the headers of the original response.
+	Header http.Header
+	// Body is the body of the original response.
+	Body []byte
+	// Vary contains the values of the request headers named by the Vary header of the original response.
+	Vary http.Header
+}
+
+// ResponseCache stores responses for conditional requests.
+type ResponseCache interface {
+	// Get returns the cached response for the specified key. Keys are derived from the URL and the request headers
+	// that select a representation (e.g. Accept or the organization), they should be treated as opaque.
+	Get(key string) (CachedResponse, bool)
+	// Set stores the response for the specified key.
+	Set(key string, resp CachedResponse)
+}
+
+// NewMemoryCache returns a new unbounded in-memory response cache.
+func NewMemoryCache() ResponseCache {
+	return &memoryCache{entries: make(map[string]CachedResponse)}
+}
+
+type memoryCache struct {
+	mu      sync.RWMutex
+	entries map[string]CachedResponse
+}
+
+func (c *memoryCache) Get(key string) (CachedResponse, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	resp, ok := c.entries[key]
+	return resp, ok
+}
+
+func (c *memoryCache) Set(key string, resp CachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = resp
+}
+
+// WithCache enables caching of GET responses which include an ETag. Subsequent requests for the same URL (with the
+// same Accept, organization and Authorization headers, along with any headers named by the Vary header of the
+// response) are made conditionally using If-None-Match; if the server responds with "304 Not Modified", the cached
+// response is returned. Range requests, event streams, responses larger than the maximum response size and responses
+// with "Cache-Control: no-store" or "Vary: *" are never cached.
+func WithCache(cache ResponseCache) Option {
+	return func(c *httpClient) {
+		c.cache = cache
+	}
+}
+
+// cacheTransport makes conditional requests using a response cache.
+type cacheTransport struct {
+	cache    ResponseCache
+	maxBytes int64
+	base     http.RoundTripper
+}
+
+// RoundTrip makes the request conditional if a cached response exists.
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Header.Get("If-None-Match") != "" ||
+		req.Header.Get("Range") != "" || req.Header.Get("If-Range") != "" {
+		return transport(t.base).RoundTrip(req)
+	}
+
+	key := cacheKey(req)
+	cached, ok := t.cache.Get(key)
+	ok = ok && varyMatches(req.Header, cached.Vary)
+	if ok && cached.ETag != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+
+	resp, err := transport(t.base).RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && ok:
+		discard(resp)
+		resp.StatusCode = cached.StatusCode
+		resp.Status = cached.Status
+		if resp.Status == "" {
+			resp.Status = fmt.Sprintf("%d %s", cached.StatusCode, http.StatusText(cached.StatusCode))
+		}
+		resp.Header = cached.Header.Clone()
+		resp.Body = ioutil.NopCloser(bytes.NewReader(cached.Body))
+		resp.ContentLength = int64(len(cached.Body))
+
+	case resp.StatusCode == http.StatusOK && t.cacheable(resp):
+		r := io.Reader(resp.Body)
+		if t.maxBytes > 0 {
+			r = io.LimitReader(resp.Body, t.maxBytes+1)
+		}
+		body, err := ioutil.ReadAll(r)
+		if err != nil {
+			_ = resp.Body.Close()
+			return nil, err
+		}
+
+		// Too large to cache, let the caller read (or reject) the rest of the body
+		if t.maxBytes > 0 && int64(len(body)) > t.maxBytes {
+			resp.Body = &prefixBody{Reader: io.MultiReader(bytes.NewReader(body), resp.Body), rc: resp.Body}
+			return resp, nil
+		}
+		_ = resp.Body.Close()
+
+		t.cache.Set(key, CachedResponse{
+			ETag:       resp.Header.Get("ETag"),
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Header:     resp.Header.Clone(),
+			Body:       body,
+			Vary:       varyValues(req.Header, resp.Header),
+		})
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	return resp, nil
+}
+
+// cacheable checks to see if a successful response can be stored.
+func (t *cacheTransport) cacheable(resp *http.Response) bool {
+	switch {
+	case resp.Header.Get("ETag") == "" || noStore(resp.Header) || isMediaType(resp.Header, "text/event-stream"):
+		return false
+	case t.maxBytes > 0 && resp.ContentLength > t.maxBytes:
+		return false
+	}
+	for _, v := range resp.Header.Values("Vary") {
+		if strings.TrimSpace(v) == "*" {
+			return false
+		}
+	}
+	return true
+}
+
+// cacheKey returns the cache key for a request. Credentials are hashed so they are never stored in the cache.
+func cacheKey(req *http.Request) string {
+	var key strings.Builder
+	key.WriteString(req.URL.String())
+	for _, h := range cacheKeyHeaders {
+		v := strings.Join(req.Header.Values(h), ",")
+		if v == "" {
+			continue
+		}
+		if h == "Authorization" {
+			sum := sha256.Sum256([]byte(v))
+			v = hex.EncodeToString(sum[:])
+		}
+		key.WriteString("\n" + h + ": " + v)
+	}
+	return key.String()
+}
+
+// varyValues returns the values of the request headers named by the Vary header of a response.
+func varyValues(reqHeader, respHeader http.Header) http.Header {
+	var vary http.Header
+	for _, v := range respHeader.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			if name = http.CanonicalHeaderKey(strings.TrimSpace(name)); name != "" {
+				if vary == nil {
+					vary = make(http.Header)
+				}
+				vary[name] = append([]string{}, reqHeader.Values(name)...)
+			}
+		}
+	}
+	return vary
+}
+
+// varyMatches checks to see if a request has the same values for the headers a cached response varies by.
+func varyMatches(reqHeader, vary http.Header) bool {
+	for name, values := range vary {
+		if strings.Join(reqHeader.Values(name), ",") != strings.Join(values, ",") {
+			return false
+		}
+	}
+	return true
+}
+
+// prefixBody is a response body whose beginning has already been read.
+type prefixBody struct {
+	io.Reader
+	rc io.ReadCloser
+}
+
+func (b *prefixBody) Close() error {
+	return b.rc.Close()
+}
+
+// noStore checks the Cache-Control header for the "no-store" directive.
+func noStore(h http.Header) bool {
+	for _, v := range h.Values("Cache-Control") {
+		for _, d := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(d), "no-store") {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithCache(t *testing.T) {
+	var served int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/nostore" {
+			w.Header().Set("Cache-Control", "private, no-store")
+		}
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		served++
+		_, _ = w.Write([]byte("experiment"))
+	}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts, WithCache(NewMemoryCache()))
+	get := func(ep string) {
+		req, err := http.NewRequest(http.MethodGet, client.URL(ep).String(), nil)
+		require.NoError(t, err)
+		resp, body, err := client.Do(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "experiment", string(body))
+	}
+
+	get("/cached")
+	get("/cached")
+	assert.Equal(t, 1, served)
+
+	served = 0
+	get("/nostore")
+	get("/nostore")
+	assert.Equal(t, 2, served)
+}
+
+func TestWithCache_Representations(t *testing.T) {
+	var served int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Vary", "X-Locale")
+		if r.URL.Path == "/large" {
+			w.Header().Del("Vary")
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		served++
+		if r.URL.Path == "/large" {
+			_, _ = w.Write(bytes.Repeat([]byte("x"), 100))
+			return
+		}
+		_, _ = w.Write([]byte(r.Header.Get(HeaderOrganizationID) + r.Header.Get("X-Locale") + r.Header.Get("Range")))
+	}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts, WithCache(NewMemoryCache()), WithMaxResponseBytes(64))
+	get := func(c Client, ep string, header http.Header) (*http.Response, string, error) {
+		served = 0
+		req, err := http.NewRequest(http.MethodGet, c.URL(ep).String(), nil)
+		require.NoError(t, err)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		resp, body, err := c.Do(context.Background(), req)
+		return resp, string(body), err
+	}
+
+	// Each tenant gets their own entry
+	acme, globex := With(client, WithOrganization("acme")), With(client, WithOrganization("globex"))
+	_, body, err := get(acme, "/cached", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "acme", body)
+	_, body, err = get(globex, "/cached", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "globex", body)
+	assert.Equal(t, 1, served)
+	resp, body, err := get(acme, "/cached", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "acme", body)
+	assert.Equal(t, "200 OK", resp.Status)
+	assert.Equal(t, 0, served)
+
+	// Headers named by Vary must match
+	_, body, err = get(acme, "/cached", http.Header{"X-Locale": {"fr"}})
+	require.NoError(t, err)
+	assert.Equal(t, "acmefr", body)
+	assert.Equal(t, 1, served)
+
+	// Range requests bypass the cache
+	_, body, err = get(acme, "/cached", http.Header{"Range": {"bytes=0-"}})
+	require.NoError(t, err)
+	assert.Equal(t, "acmebytes=0-", body)
+	assert.Equal(t, 1, served)
+
+	// Responses exceeding the size limit are not cached
+	_, _, err = get(client, "/large", nil)
+	assert.True(t, errors.Is(err, ErrResponseTooLarge))
+	_, _, err = get(client, "/large", nil)
+	assert.True(t, errors.Is(err, ErrResponseTooLarge))
+	assert.Equal(t, 1, served)
+}
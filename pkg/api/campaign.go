@@ -0,0 +1,64 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrCampaignExhausted is the cause of contexts that are done because the campaign deadline has passed.
+	ErrCampaignExhausted = errors.New("campaign deadline exceeded")
+	// ErrCallTimeout is the cause of contexts that are done because an individual call took too long.
+	ErrCallTimeout = errors.New("call timed out")
+)
+
+// CallContextFunc derives a context for a single API call. The context is done when the timeout (if positive)
+// expires or when the campaign is over, whichever comes first. The cancel function must always be called.
+type CallContextFunc func(timeout time.Duration) (context.Context, context.CancelFunc)
+
+// WithCampaignDeadline returns a context that is done at the deadline of a campaign, along with a function for
+// deriving per-call contexts that cannot outlast the campaign. Use `CallError` to distinguish between the campaign
+// being exhausted and an individual call timing out. The returned cancel function releases the resources associated
+// with the campaign and must be called when the campaign ends.
+func WithCampaignDeadline(ctx context.Context, deadline time.Time) (context.Context, CallContextFunc, context.CancelFunc) {
+	campaignCtx, cancel := context.WithDeadlineCause(ctx, deadline, ErrCampaignExhausted)
+	call := func(timeout time.Duration) (context.Context, context.CancelFunc) {
+		if timeout <= 0 {
+			return context.WithCancel(campaignCtx)
+		}
+		return context.WithTimeoutCause(campaignCtx, timeout, ErrCallTimeout)
+	}
+	return campaignCtx, call, cancel
+}
+
+// CallError returns an error matching `ErrCampaignExhausted` or `ErrCallTimeout` (in addition to the supplied error)
+// if the call failed because the supplied call context reached one of those deadlines. Other errors are returned
+// unchanged.
+func CallError(ctx context.Context, err error) error {
+	if err == nil || ctx.Err() == nil {
+		return err
+	}
+	cause := context.Cause(ctx)
+	if cause == ctx.Err() || errors.Is(err, cause) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", cause, err)
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithCampaignDeadline(t *testing.T) {
+	ts := httptest.NewServer(slowHandler(time.Second))
+	defer ts.Close()
+	client := newTestClient(t, ts)
+
+	do := func(ctx context.Context) error {
+		req, err := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+		require.NoError(t, err)
+		_, _, err = client.Do(ctx, req)
+		return CallError(ctx, err)
+	}
+
+	campaignCtx, call, cancel := WithCampaignDeadline(context.Background(), time.Now().Add(200*time.Millisecond))
+	defer cancel()
+
+	// The call times out before the campaign is over
+	ctx, cancelCall := call(50 * time.Millisecond)
+	err := do(ctx)
+	cancelCall()
+	assert.True(t, errors.Is(err, ErrCallTimeout))
+	assert.False(t, errors.Is(err, ErrCampaignExhausted))
+	assert.NoError(t, campaignCtx.Err())
+
+	// The call would outlast the campaign
+	ctx, cancelCall = call(time.Minute)
+	err = do(ctx)
+	cancelCall()
+	assert.True(t, errors.Is(err, ErrCampaignExhausted))
+	assert.False(t, errors.Is(err, ErrCallTimeout))
+
+	// Once the campaign is over, new calls are done immediately
+	ctx, cancelCall = call(0)
+	defer cancelCall()
+	assert.True(t, errors.Is(context.Cause(ctx), ErrCampaignExhausted))
+}
+
+func TestCallError(t *testing.T) {
+	assert.NoError(t, CallError(context.Background(), nil))
+
+	err := errors.New("boom")
+	assert.Equal(t, err, CallError(context.Background(), err))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, err, CallError(ctx, err))
+}
@@ -18,10 +18,19 @@ package api
 
 import (
 	"context"
+	"errors"
+	"io"
 	"io/ioutil"
+	"log"
 	"net/http"
+	"net/http/httptrace"
 	"net/url"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/time/rate"
 )
 
 // Config exposes the information for configuring an API Client.
@@ -41,26 +50,146 @@ type Client interface {
 	URL(endpoint string) *url.URL
 	// Do performs the interaction specified by the HTTP request
 	Do(context.Context, *http.Request) (*http.Response, []byte, error)
+	// DoStream performs the interaction specified by the HTTP request, leaving the caller to consume the body
+	DoStream(context.Context, *http.Request) (*http.Response, io.ReadCloser, error)
 }
 
+// ErrClientClosed is returned for requests made using a client that has been closed.
+var ErrClientClosed = errors.New("client is closed")
+
+// DefaultTimeout is the time limit for requests made by a client unless otherwise configured.
+const DefaultTimeout = 10 * time.Second
+
 // NewClient returns a new client for accessing API server; the supplied context is used for authentication/authorization
-// requests and the supplied transport (which may be nil in the case of the default transport) is used for all requests made
-// to the API server.
-func NewClient(ctx context.Context, cfg Config, transport http.RoundTripper) (Client, error) {
+// requests. Unless a transport is supplied using `WithTransport`, a new transport is created using the default transport
+// settings and any transport options. See `WithMiddleware` for the order in which the client's features are applied.
+//
+// The returned client implements `io.Closer`, closing the client releases idle connections and causes subsequent
+// requests to fail with `ErrClientClosed`.
+func NewClient(ctx context.Context, cfg Config, opts ...Option) (Client, error) {
 	var err error
 
-	hc := &httpClient{}
-	hc.client.Timeout = 10 * time.Second
+	hc := &httpClient{
+		requestID:        NewUUID,
+		logBodyLimit:     DefaultBodyLoggingLimit,
+		maxResponseBytes: DefaultMaxResponseBytes,
+		tokenRefreshSkew: DefaultTokenRefreshSkew,
+		closed:           &atomic.Bool{},
+		clock:            realClock{},
+	}
+	hc.client.Timeout = DefaultTimeout
+	hc.retry.maxRetryAfter = DefaultMaxRetryAfter
+	for _, opt := range opts {
+		if opt != nil {
+			opt(hc)
+		}
+	}
+	for _, wrap := range hc.codecWrappers {
+		if wrap != nil {
+			hc.codec = wrap(ClientCodec(hc))
+		}
+	}
+	if hc.breaker != nil {
+		hc.breaker.clock = hc.clock
+	}
+	if hc.retryBudget != nil {
+		hc.retryBudget.clock = hc.clock
+	}
+
+	// Create a new transport if one was not supplied
+	transport := hc.transport
+	if transport == nil {
+		transport = hc.newTransport()
+	} else if hc.httpVersionOption {
+		log.Printf("optimize-go: HTTP version options are ignored when an explicit transport is used")
+	}
+	hc.base = transport
+
+	// Authorization may continue to use the context after the client is closed
+	ctx, hc.cancel = context.WithCancel(ctx)
 
-	// Configure the OAuth2 transport
-	hc.client.Transport, err = cfg.Authorize(ctx, transport)
-	if err != nil {
+	// Configure debug dumps as close to the wire as possible
+	if hc.debugDump != nil {
+		transport = &dumpTransport{w: hc.debugDump, bodies: hc.debugDumpBodies, base: transport}
+	}
+	if hc.har != nil {
+		transport = &harTransport{recorder: hc.har, base: transport}
+	}
+
+	// Configure the OAuth2 transport, falling back to the configuration if it does not use access tokens
+	var src oauth2.TokenSource
+	if tsc, ok := cfg.(TokenSourceConfig); ok {
+		if src, err = tsc.TokenSource(ctx); err != nil {
+			hc.cancel()
+			return nil, err
+		}
+	}
+	if src != nil {
+		hc.client.Transport = &tokenTransport{tokens: &tokenCache{src: src, skew: hc.tokenRefreshSkew, clock: hc.clock}, base: transport}
+	} else if hc.client.Transport, err = cfg.Authorize(ctx, transport); err != nil {
+		hc.cancel()
 		return nil, err
+	} else {
+		if ht, ok := hc.client.Transport.(*hmacTransport); ok {
+			ht.clock = hc.clock
+		}
+		hc.client.Transport = &redirectGuardTransport{authorized: hc.client.Transport, base: transport}
+	}
+
+	// Report upload progress on the bytes actually sent
+	if hc.uploadProgress != nil {
+		hc.client.Transport = &uploadProgressTransport{progress: hc.uploadProgress, base: hc.client.Transport}
+	}
+
+	// Configure content encoding
+	hc.client.Transport = &compressionTransport{compressRequests: hc.compressRequests, base: hc.client.Transport}
+
+	// Configure response caching
+	if hc.cache != nil {
+		hc.client.Transport = &cacheTransport{cache: hc.cache, maxBytes: hc.maxResponseBytes, base: hc.client.Transport}
+	}
+
+	// Configure the User-Agent
+	if hc.userAgent != "" {
+		hc.client.Transport = &userAgentTransport{userAgent: hc.userAgent, base: hc.client.Transport}
+	}
+
+	// Observe server side rate limits
+	if hc.rateLimitObserver != nil {
+		hc.client.Transport = &rateLimitObserverTransport{observer: hc.rateLimitObserver, clock: hc.clock, base: hc.client.Transport}
+	}
+
+	// Configure adaptive concurrency inside of rate limiting so only the latency of the server is measured
+	if hc.adaptive {
+		hc.client.Transport = &adaptiveConcurrencyTransport{limiter: newAdaptiveLimiter(hc.concurrencyLimitObserver), clock: hc.clock, base: hc.client.Transport}
+	}
+
+	// Configure client side rate limiting
+	if hc.limiter != nil {
+		hc.client.Transport = &rateLimitTransport{limiter: hc.limiter, clock: hc.clock, base: hc.client.Transport}
+	}
+
+	// Configure the circuit breaker
+	if hc.breaker != nil {
+		hc.client.Transport = &circuitBreakerTransport{breaker: hc.breaker, base: hc.client.Transport}
+	}
+
+	// Configure request hedging
+	if hc.hedgeMaxExtra > 0 {
+		hc.client.Transport = &hedgingTransport{delay: hc.hedgeDelay, maxExtra: hc.hedgeMaxExtra, clock: hc.clock, base: hc.client.Transport}
+	}
+
+	// Configure middleware so the first middleware is the outermost
+	for i := len(hc.middleware) - 1; i >= 0; i-- {
+		if hc.middleware[i] != nil {
+			hc.client.Transport = hc.middleware[i](hc.client.Transport)
+		}
 	}
 
 	// Configure the API endpoints
 	hc.endpoints, err = cfg.Endpoints()
 	if err != nil {
+		hc.cancel()
 		return nil, err
 	}
 
@@ -69,41 +198,304 @@ func NewClient(ctx context.Context, cfg Config, transport http.RoundTripper) (Cl
 
 type httpClient struct {
 	client    http.Client
+	base      http.RoundTripper
+	cancel    context.CancelFunc
+	closed    *atomic.Bool
 	endpoints func(string) *url.URL
+	transport http.RoundTripper
+	retry     retryPolicy
+	limiter   *rate.Limiter
+	breaker   *circuitBreaker
+	inflight  semaphore
+	userAgent string
+	requestID func() string
+	codec     Codec
+
+	codecWrappers []func(Codec) Codec
+
+	errorDecoder func(*http.Response, []byte) error
+
+	retryBudget *retryBudget
+	clock       Clock
+
+	apiVersion       string
+	defaultHeaders   http.Header
+	organization     string
+	organizationMode OrganizationMode
+	basePath         string
+	idempotencyKey   func() string
+	maxResponseBytes int64
+	tokenRefreshSkew time.Duration
+
+	compressRequests  bool
+	cache             ResponseCache
+	rateLimitObserver func(RateLimitStatus)
+	uploadProgress    func(bytesSent, total int64)
+
+	adaptive                 bool
+	concurrencyLimitObserver func(limit int)
+
+	hedgeDelay    time.Duration
+	hedgeMaxExtra int
+
+	clientTrace       func(context.Context) *httptrace.ClientTrace
+	connectionTimings bool
+
+	logger       func(context.Context, RequestInfo)
+	logBodies    bool
+	logBodyLimit int
+
+	debugDump       io.Writer
+	debugDumpBodies bool
+	har             *harRecorder
+
+	transportOptions  []func(*http.Transport)
+	httpVersionOption bool
+	middleware        []Middleware
+}
+
+// HTTPClient returns the HTTP client used to send requests made by a client created with `NewClient`, or nil if the
+// client does not use one (e.g. a mock client). This is an escape hatch for advanced tuning: the transport of the
+// returned client already includes the authorization and other features configured on the client, replacing it or
+// otherwise modifying the client bypasses those features and may leave the client in an inconsistent state.
+func HTTPClient(c Client) *http.Client {
+	if hc, ok := c.(interface{ HTTPClient() *http.Client }); ok {
+		return hc.HTTPClient()
+	}
+	return nil
+}
+
+// HTTPClient returns the underlying HTTP client.
+func (c *httpClient) HTTPClient() *http.Client {
+	return &c.client
+}
+
+// With returns a copy of a client created with `NewClient` that applies additional options, or the supplied client if
+// it does not support them. See `(*httpClient).With` for the options that can be overridden.
+func With(c Client, opts ...Option) Client {
+	if wc, ok := c.(interface{ With(...Option) Client }); ok {
+		return wc.With(opts...)
+	}
+	return c
+}
+
+// With returns a shallow copy of the client with the supplied options applied. The copy shares the authorized transport
+// (including any cached tokens, connections and layers such as rate limiting or circuit breaking) with the original, so
+// no additional authentication is required. Only options that apply to each request may be overridden: timeouts,
+// retries, redirects, cookie jars, response size limits, concurrency limits, logging, codecs, error decoders, API
+// versions, default headers, organizations, base paths, client traces, request ID and idempotency key generators.
+// Options that configure the transport (e.g. `WithTransport`, TLS, proxy or User-Agent settings, caching, compression,
+// hedging, rate limiting, adaptive concurrency, circuit breaking and middleware) have no effect on the copy and require
+// a new client. Closing either client closes both.
+func (c *httpClient) With(opts ...Option) Client {
+	hc := *c
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&hc)
+		}
+	}
+	return &hc
+}
+
+// Close releases any idle connections held by the client's transport and cancels the context used for authorization.
+// Any requests made after the client is closed fail with `ErrClientClosed`; requests already in flight are not
+// interrupted. Any interactions recorded using `WithHARRecorder` are written.
+func (c *httpClient) Close() error {
+	if c.closed.Swap(true) {
+		return nil
+	}
+	c.cancel()
+	if ci, ok := c.base.(interface{ CloseIdleConnections() }); ok {
+		ci.CloseIdleConnections()
+	}
+	return c.FlushHAR()
 }
 
 // URL resolves an endpoint to a fully qualified URL.
 func (c *httpClient) URL(ep string) *url.URL {
-	return c.endpoints(ep)
+	u := c.endpoints(ep)
+	if u != nil && c.organization != "" && c.organizationMode == OrganizationPath {
+		prefix, rawPrefix := organizationPath(c.organization)
+		u = insertPathPrefix(u, ep, prefix, rawPrefix)
+	}
+	if u != nil && c.basePath != "" {
+		u = addBasePath(u, c.basePath)
+	}
+	return u
 }
 
-// Do executes an HTTP request using this client and the supplied context.
+// Do executes an HTTP request using this client and the supplied context. A nil context is treated as
+// `context.Background()`. Options attached to the context using `WithRequestOption` override the client defaults for
+// this call.
 func (c *httpClient) Do(ctx context.Context, req *http.Request) (*http.Response, []byte, error) {
-	if ctx != nil {
-		req = req.WithContext(ctx)
+	if ctx == nil {
+		ctx = context.Background()
 	}
-	resp, err := c.client.Do(req)
+
+	resp, rc, err := c.DoStream(ctx, req)
 	if err != nil {
 		return nil, nil, err
 	}
-	defer resp.Body.Close()
+	defer rc.Close()
 
-	var body []byte
-	done := make(chan struct{})
-	go func() {
-		body, err = ioutil.ReadAll(resp.Body)
-		close(done)
-	}()
+	var r io.Reader = rc
+	if c.maxResponseBytes > 0 {
+		r = io.LimitReader(rc, c.maxResponseBytes+1)
+	}
 
-	select {
-	case <-ctx.Done():
-		<-done
-		err = resp.Body.Close()
-		if err == nil {
+	body, err := ioutil.ReadAll(r)
+	if err != nil {
+		if ctx.Err() != nil {
 			err = ctx.Err()
 		}
-	case <-done:
+		return resp, nil, err
+	}
+	if c.maxResponseBytes > 0 && int64(len(body)) > c.maxResponseBytes {
+		return resp, nil, ErrResponseTooLarge
+	}
+
+	return resp, body, nil
+}
+
+// DoStream executes an HTTP request using this client and the supplied context without reading the response body. The
+// caller must close the returned body (which is also available on the response); if the context is done before the
+// body is closed, the underlying connection is closed. Note that the client timeout still applies to reading the body.
+// Options attached to the context using `WithRequestOption` override the client defaults for this call.
+func (c *httpClient) DoStream(ctx context.Context, req *http.Request) (*http.Response, io.ReadCloser, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if c.closed.Load() {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, nil, ErrClientClosed
+	}
+	c, ro := c.withRequestOptions(ctx)
+	req = req.Clone(ctx)
+	if ro != nil {
+		if ro.header != nil {
+			setDefaultHeaders(req.Header, ro.header)
+		}
+		if ro.idempotencyKey != "" && req.Header.Get(HeaderIdempotencyKey) == "" {
+			req.Header.Set(HeaderIdempotencyKey, ro.idempotencyKey)
+		}
+	}
+	if c.defaultHeaders != nil {
+		setDefaultHeaders(req.Header, c.defaultHeaders)
+	}
+	if c.organization != "" && c.organizationMode == OrganizationHeader && req.Header.Get(HeaderOrganizationID) == "" {
+		req.Header.Set(HeaderOrganizationID, c.organization)
+	}
+	if c.apiVersion != "" {
+		setAcceptVersion(req.Header, c.apiVersion)
+	}
+	if c.requestID != nil && req.Header.Get(HeaderRequestID) == "" {
+		req.Header.Set(HeaderRequestID, c.requestID())
+	}
+	if c.idempotencyKey != nil && req.Method == http.MethodPost && req.Header.Get(HeaderIdempotencyKey) == "" {
+		req.Header.Set(HeaderIdempotencyKey, c.idempotencyKey())
+	}
+
+	if c.inflight != nil {
+		release, err := c.inflight.acquire(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Once the response is returned, the slot is released by the body
+		var resp *http.Response
+		defer func() {
+			if resp == nil {
+				release()
+			}
+		}()
+		resp, rc, err := c.doStream(ctx, req)
+		if err != nil {
+			return nil, nil, err
+		}
+		resp.Body = &releaseBody{ReadCloser: rc, release: release}
+		return resp, resp.Body, nil
+	}
+
+	return c.doStream(ctx, req)
+}
+
+// doStream sends the request, returning the response with a body that is closed when the context is done.
+func (c *httpClient) doStream(ctx context.Context, req *http.Request) (*http.Response, io.ReadCloser, error) {
+	var info *RequestInfo
+	if c.logger != nil {
+		info = c.newRequestInfo(req)
+	}
+
+	timings := func() ConnectionTimings { return ConnectionTimings{} }
+	if c.clientTrace != nil || (c.connectionTimings && info != nil) {
+		var tctx context.Context
+		tctx, timings = c.withTrace(req.Context())
+		req = req.WithContext(tctx)
+	}
+
+	start := time.Now()
+	resp, err := c.send(req)
+	if info != nil {
+		info.Connection = timings()
+	}
+	if err != nil {
+		err = newTransportError(req, err)
+		if info != nil {
+			info.Duration = time.Since(start)
+			info.Err = err
+			c.logger(ctx, *info)
+		}
+		return nil, nil, err
+	}
+
+	resp.Body = newContextBody(ctx, resp.Body)
+	if info != nil {
+		info.StatusCode = resp.StatusCode
+		lb := &loggingBody{ctx: ctx, rc: resp.Body, logger: c.logger, info: info, start: start}
+		if c.logBodies {
+			lb.limit = c.logBodyLimit
+		}
+		resp.Body = lb
 	}
+	return resp, resp.Body, nil
+}
+
+// contextBody is a response body that is closed when a context is done.
+type contextBody struct {
+	ctx  context.Context
+	rc   io.ReadCloser
+	once sync.Once
+	stop chan struct{}
+}
+
+func newContextBody(ctx context.Context, rc io.ReadCloser) *contextBody {
+	b := &contextBody{ctx: ctx, rc: rc, stop: make(chan struct{})}
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = rc.Close()
+		case <-b.stop:
+		}
+	}()
+	return b
+}
+
+// Read reads from the underlying body, failing with the context error once the context is done.
+func (b *contextBody) Read(p []byte) (int, error) {
+	if err := b.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := b.rc.Read(p)
+	if err != nil && b.ctx.Err() != nil {
+		err = b.ctx.Err()
+	}
+	return n, err
+}
 
-	return resp, body, err
+// Close closes the underlying body.
+func (b *contextBody) Close() error {
+	b.once.Do(func() { close(b.stop) })
+	return b.rc.Close()
 }
@@ -0,0 +1,387 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testConfig is a minimal configuration that resolves all endpoints against a single base URL.
+type testConfig struct {
+	base string
+
+	// transport is the transport supplied for authorization
+	transport http.RoundTripper
+}
+
+func (tc *testConfig) Endpoints() (func(string) *url.URL, error) {
+	return func(ep string) *url.URL {
+		u, _ := url.Parse(tc.base + ep)
+		return u
+	}, nil
+}
+
+func (tc *testConfig) Authorize(_ context.Context, transport http.RoundTripper) (http.RoundTripper, error) {
+	tc.transport = transport
+	return transport, nil
+}
+
+// newTestClient returns a client for the supplied test server.
+func newTestClient(t *testing.T, ts *httptest.Server, opts ...Option) Client {
+	c, err := NewClient(context.Background(), &testConfig{base: ts.URL}, opts...)
+	require.NoError(t, err)
+	return c
+}
+
+// slowHandler responds only after the specified delay.
+func slowHandler(delay time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	ts := httptest.NewServer(slowHandler(200 * time.Millisecond))
+	defer ts.Close()
+
+	cases := []struct {
+		desc            string
+		timeout         time.Duration
+		contextDeadline time.Duration
+		expectTimeout   bool
+	}{
+		{
+			desc:          "client timeout",
+			timeout:       50 * time.Millisecond,
+			expectTimeout: true,
+		},
+		{
+			desc:          "disabled",
+			timeout:       0,
+			expectTimeout: false,
+		},
+		{
+			desc:            "context deadline is shorter",
+			timeout:         time.Minute,
+			contextDeadline: 50 * time.Millisecond,
+			expectTimeout:   true,
+		},
+		{
+			desc:            "disabled with context deadline",
+			timeout:         -1,
+			contextDeadline: 50 * time.Millisecond,
+			expectTimeout:   true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			client := newTestClient(t, ts, WithTimeout(c.timeout))
+
+			ctx := context.Background()
+			if c.contextDeadline > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, c.contextDeadline)
+				defer cancel()
+			}
+
+			req, err := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+			require.NoError(t, err)
+
+			_, _, err = client.Do(ctx, req)
+			if c.expectTimeout {
+				var uerr *url.Error
+				if assert.True(t, errors.As(err, &uerr)) {
+					assert.True(t, uerr.Timeout())
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestDo_NilContext(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts)
+	req, err := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+	require.NoError(t, err)
+
+	assert.NotPanics(t, func() {
+		_, body, err := client.Do(nil, req)
+		assert.NoError(t, err)
+		assert.Equal(t, "ok", string(body))
+	})
+}
+
+func TestDo_CancelDuringRead(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		for {
+			if _, err := w.Write([]byte("slow")); err != nil {
+				return
+			}
+			w.(http.Flusher).Flush()
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts, WithTimeout(0))
+	req, err := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	_, body, err := client.Do(ctx, req)
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Nil(t, body)
+}
+
+func TestDoStream(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("streamed body"))
+	}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts)
+	req, err := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+	require.NoError(t, err)
+
+	resp, body, err := client.DoStream(context.Background(), req)
+	require.NoError(t, err)
+	defer body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	b, err := ioutil.ReadAll(body)
+	assert.NoError(t, err)
+	assert.Equal(t, "streamed body", string(b))
+}
+
+func TestWithMaxResponseBytes(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(make([]byte, 1024))
+	}))
+	defer ts.Close()
+
+	cases := []struct {
+		desc     string
+		limit    int64
+		expected error
+	}{
+		{desc: "exceeded", limit: 1023, expected: ErrResponseTooLarge},
+		{desc: "exact", limit: 1024},
+		{desc: "disabled", limit: 0},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			client := newTestClient(t, ts, WithMaxResponseBytes(c.limit))
+			req, err := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+			require.NoError(t, err)
+
+			_, body, err := client.Do(context.Background(), req)
+			if c.expected != nil {
+				assert.True(t, errors.Is(err, c.expected))
+				assert.Nil(t, body)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, body, 1024)
+			}
+		})
+	}
+}
+
+func TestWithCookieJar(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if c, err := r.Cookie("session"); err == nil {
+			_, _ = w.Write([]byte(c.Value))
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc123", Path: "/"})
+	}))
+	defer ts.Close()
+
+	u, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+
+	cfg := StaticTokenConfig("token", map[string]*url.URL{"/": u})
+	for _, c := range []struct {
+		desc     string
+		opts     []Option
+		expected string
+	}{
+		{desc: "default"},
+		{desc: "jar", opts: []Option{WithCookieJar(jar)}, expected: "abc123"},
+	} {
+		t.Run(c.desc, func(t *testing.T) {
+			client, err := NewClient(context.Background(), cfg, c.opts...)
+			require.NoError(t, err)
+
+			var body []byte
+			for i := 0; i < 2; i++ {
+				req, err := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+				require.NoError(t, err)
+				var resp *http.Response
+				resp, body, err = client.Do(context.Background(), req)
+				require.NoError(t, err)
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+			}
+			assert.Equal(t, c.expected, string(body))
+		})
+	}
+}
+
+func TestHTTPClient(t *testing.T) {
+	ts := httptest.NewServer(slowHandler(100 * time.Millisecond))
+	defer ts.Close()
+
+	client := newTestClient(t, ts)
+	hc := HTTPClient(client)
+	require.NotNil(t, hc)
+	assert.Equal(t, DefaultTimeout, hc.Timeout)
+
+	// Changes to the underlying client are visible to the client
+	hc.Timeout = 10 * time.Millisecond
+	req, err := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+	require.NoError(t, err)
+	_, _, err = client.Do(context.Background(), req)
+	var uerr *url.Error
+	if assert.True(t, errors.As(err, &uerr)) {
+		assert.True(t, uerr.Timeout())
+	}
+}
+
+func TestClose(t *testing.T) {
+	closed := make(chan struct{}, 1)
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	ts.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateClosed {
+			closed <- struct{}{}
+		}
+	}
+	ts.Start()
+	defer ts.Close()
+
+	client := newTestClient(t, ts)
+	req, err := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+	require.NoError(t, err)
+
+	_, _, err = client.Do(context.Background(), req)
+	require.NoError(t, err)
+
+	c, ok := client.(io.Closer)
+	require.True(t, ok)
+	assert.NoError(t, c.Close())
+	assert.NoError(t, c.Close())
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		assert.Fail(t, "idle connection was not closed")
+	}
+
+	_, _, err = client.Do(context.Background(), req)
+	assert.True(t, errors.Is(err, ErrClientClosed))
+}
+
+func TestClient_With(t *testing.T) {
+	var exchanges int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			atomic.AddInt32(&exchanges, 1)
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"access_token":"abc","token_type":"bearer","expires_in":3600}`))
+		case "/slow":
+			slowHandler(200*time.Millisecond)(w, r)
+		default:
+			_, _ = w.Write([]byte(r.Header.Get("Authorization")))
+		}
+	}))
+	defer ts.Close()
+
+	base, err := url.Parse(ts.URL + "/")
+	require.NoError(t, err)
+	client, err := NewClient(context.Background(), &ClientCredentialsConfig{
+		TokenURL:     ts.URL + "/token",
+		ClientID:     "client",
+		ClientSecret: "secret",
+		EndpointURLs: map[string]*url.URL{"/": base},
+	})
+	require.NoError(t, err)
+	derived := With(client, WithTimeout(20*time.Millisecond))
+
+	do := func(c Client, ep string) (string, error) {
+		req, err := http.NewRequest(http.MethodGet, c.URL(ep).String(), nil)
+		require.NoError(t, err)
+		_, body, err := c.Do(context.Background(), req)
+		return string(body), err
+	}
+
+	// The token cache is shared
+	for _, c := range []Client{client, derived} {
+		body, err := do(c, "/experiments/")
+		require.NoError(t, err)
+		assert.Equal(t, "Bearer abc", body)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&exchanges))
+
+	// Only the derived client has the shorter timeout
+	_, err = do(derived, "/slow")
+	var netErr net.Error
+	assert.True(t, errors.As(err, &netErr) && netErr.Timeout())
+	_, err = do(client, "/slow")
+	assert.NoError(t, err)
+
+	// Closing either client closes both
+	require.NoError(t, derived.(io.Closer).Close())
+	_, err = do(client, "/experiments/")
+	assert.True(t, errors.Is(err, ErrClientClosed))
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// ClientCredentialsConfig authorizes requests using access tokens obtained with the OAuth2 client credentials grant.
+// Tokens are not requested until they are needed.
+type ClientCredentialsConfig struct {
+	// TokenURL is the location of the authorization server's token endpoint.
+	TokenURL string
+	// ClientID is the application's identifier.
+	ClientID string
+	// ClientSecret is the application's secret.
+	ClientSecret string
+	// Scopes are the optional scopes to request.
+	Scopes []string
+	// EndpointParams are additional parameters for requests to the token endpoint.
+	EndpointParams url.Values
+	// EndpointURLs maps endpoint prefixes to their locations, see `StaticTokenConfig`.
+	EndpointURLs map[string]*url.URL
+}
+
+// Endpoints returns a resolver for the configured endpoint prefixes.
+func (c *ClientCredentialsConfig) Endpoints() (func(string) *url.URL, error) {
+	return prefixResolver(c.EndpointURLs), nil
+}
+
+// Authorize returns a transport that adds a cached access token to each request.
+func (c *ClientCredentialsConfig) Authorize(ctx context.Context, transport http.RoundTripper) (http.RoundTripper, error) {
+	src, err := c.TokenSource(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &tokenTransport{tokens: &tokenCache{src: src, skew: DefaultTokenRefreshSkew}, base: transport}, nil
+}
+
+// TokenSource returns a source that performs a new token exchange on each call. The supplied context is used for
+// requests to the token endpoint.
+func (c *ClientCredentialsConfig) TokenSource(ctx context.Context) (oauth2.TokenSource, error) {
+	cc := &clientcredentials.Config{
+		ClientID:       c.ClientID,
+		ClientSecret:   c.ClientSecret,
+		TokenURL:       c.TokenURL,
+		Scopes:         c.Scopes,
+		EndpointParams: c.EndpointParams,
+	}
+	return TokenSourceFunc(func() (*oauth2.Token, error) {
+		t, err := cc.Token(ctx)
+		if err != nil {
+			return nil, newOAuth2Error(err)
+		}
+		return t, nil
+	}), nil
+}
+
+// OAuth2Error is an error response from an OAuth2 authorization server.
+type OAuth2Error struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// Code is the error code, e.g. "invalid_client".
+	Code string `json:"error"`
+	// Description is the human-readable description of the error.
+	Description string `json:"error_description"`
+}
+
+// Error returns the error message.
+func (e *OAuth2Error) Error() string {
+	if e.Description != "" {
+		return "oauth2: " + e.Code + ": " + e.Description
+	}
+	return "oauth2: " + e.Code
+}
+
+// newOAuth2Error extracts the error details from a failed token retrieval.
+func newOAuth2Error(err error) error {
+	var rErr *oauth2.RetrieveError
+	if !errors.As(err, &rErr) {
+		return err
+	}
+
+	oErr := &OAuth2Error{}
+	if json.Unmarshal(rErr.Body, oErr) != nil || oErr.Code == "" {
+		return err
+	}
+	if rErr.Response != nil {
+		oErr.StatusCode = rErr.Response.StatusCode
+	}
+	return oErr
+}
+
+// TokenSourceFunc adapts an ordinary function to the token source interface.
+type TokenSourceFunc func() (*oauth2.Token, error)
+
+// Token returns the result of invoking the function.
+func (f TokenSourceFunc) Token() (*oauth2.Token, error) {
+	return f()
+}
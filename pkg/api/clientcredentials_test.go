@@ -0,0 +1,95 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientCredentialsConfig(t *testing.T) {
+	var exchanges int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			exchanges++
+			w.Header().Set("Content-Type", "application/json")
+			if _, secret, _ := r.BasicAuth(); secret != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte(`{"error":"invalid_client","error_description":"Client authentication failed"}`))
+				return
+			}
+			assert.Equal(t, "read write", r.FormValue("scope"))
+			_, _ = w.Write([]byte(`{"access_token":"abc","token_type":"bearer","expires_in":3600}`))
+		default:
+			_, _ = w.Write([]byte(r.Header.Get("Authorization")))
+		}
+	}))
+	defer ts.Close()
+
+	base, err := url.Parse(ts.URL + "/")
+	require.NoError(t, err)
+
+	newClient := func(secret string) Client {
+		client, err := NewClient(context.Background(), &ClientCredentialsConfig{
+			TokenURL:     ts.URL + "/token",
+			ClientID:     "client",
+			ClientSecret: secret,
+			Scopes:       []string{"read", "write"},
+			EndpointURLs: map[string]*url.URL{"/": base},
+		})
+		require.NoError(t, err)
+		return client
+	}
+
+	t.Run("authorized", func(t *testing.T) {
+		exchanges = 0
+		client := newClient("secret")
+		assert.Equal(t, 0, exchanges, "token was not requested lazily")
+
+		for i := 0; i < 2; i++ {
+			req, err := http.NewRequest(http.MethodGet, client.URL("/experiments/").String(), nil)
+			require.NoError(t, err)
+			_, body, err := client.Do(context.Background(), req)
+			require.NoError(t, err)
+			assert.Equal(t, "Bearer abc", string(body))
+		}
+		assert.Equal(t, 1, exchanges)
+	})
+
+	t.Run("invalid client", func(t *testing.T) {
+		client := newClient("wrong")
+		req, err := http.NewRequest(http.MethodGet, client.URL("/experiments/").String(), nil)
+		require.NoError(t, err)
+		_, _, err = client.Do(context.Background(), req)
+
+		var oErr *OAuth2Error
+		if assert.True(t, errors.As(err, &oErr)) {
+			assert.Equal(t, http.StatusUnauthorized, oErr.StatusCode)
+			assert.Equal(t, "invalid_client", oErr.Code)
+			assert.Equal(t, "Client authentication failed", oErr.Description)
+		}
+		assert.True(t, errors.Is(err, ErrUnauthorized))
+	})
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"time"
+)
+
+// Clock is the source of time used by the client for retries, rate limiting, circuit breaking, hedging, token refresh
+// and polling (see `ClientClock`). It exists so tests can control time deterministically (see `apitest.FakeClock`)
+// instead of sleeping; note that context deadlines and the client timeout always use the real clock.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// Sleep blocks for the specified amount of time, returning early with the context error if the context is done.
+	Sleep(ctx context.Context, d time.Duration) error
+	// NewTimer creates a timer that fires once after the specified amount of time.
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a single event created by a `Clock`, see `time.Timer`.
+type Timer interface {
+	// C returns the channel on which the time is delivered when the timer fires.
+	C() <-chan time.Time
+	// Stop prevents the timer from firing, returning false if the timer already fired or was stopped.
+	Stop() bool
+	// Reset changes the timer to fire after the specified amount of time, returning false if the timer had already
+	// fired or was stopped.
+	Reset(d time.Duration) bool
+}
+
+// WithClock sets the clock used by the client, a nil clock uses the real clock. This option is intended for tests and
+// must be supplied when the client is created, it has no effect on the transport of a copy made using `With`.
+func WithClock(clock Clock) Option {
+	return func(c *httpClient) {
+		c.clock = clockOf(clock)
+		c.retry.clock = c.clock
+	}
+}
+
+// ClientClock returns the clock used by the supplied client, or the real clock if the client does not have one (e.g. a
+// mock client).
+func ClientClock(c Client) Clock {
+	if cc, ok := c.(interface{ Clock() Clock }); ok {
+		return clockOf(cc.Clock())
+	}
+	return realClock{}
+}
+
+// Clock returns the clock used by the client.
+func (c *httpClient) Clock() Clock {
+	return clockOf(c.clock)
+}
+
+// clockOf returns the supplied clock, or the real clock if it is nil.
+func clockOf(c Clock) Clock {
+	if c == nil {
+		return realClock{}
+	}
+	return c
+}
+
+// realClock is a clock backed by the time package.
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (realClock) NewTimer(d time.Duration) Timer { return &realTimer{t: time.NewTimer(d)} }
+
+// realTimer is a timer backed by the time package.
+type realTimer struct {
+	t *time.Timer
+}
+
+func (t *realTimer) C() <-chan time.Time        { return t.t.C }
+func (t *realTimer) Stop() bool                 { return t.t.Stop() }
+func (t *realTimer) Reset(d time.Duration) bool { return t.t.Reset(d) }
@@ -0,0 +1,95 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// Codec serializes the bodies of requests and responses. Implementations must be safe for concurrent use.
+type Codec interface {
+	// Marshal returns the encoding of the supplied value.
+	Marshal(v any) ([]byte, error)
+	// Unmarshal decodes the data into the value pointed to by v.
+	Unmarshal(data []byte, v any) error
+}
+
+// JSONCodec is the default codec, it uses the "encoding/json" package.
+type JSONCodec struct {
+	// UseNumber decodes numbers into an `interface{}` as a `json.Number` instead of as a `float64` to preserve the
+	// precision of large integers.
+	UseNumber bool
+}
+
+// Marshal returns the JSON encoding of the supplied value.
+func (c JSONCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal decodes the JSON data into the value pointed to by v.
+func (c JSONCodec) Unmarshal(data []byte, v any) error {
+	if !c.UseNumber {
+		return json.Unmarshal(data, v)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return errors.New("invalid character after top-level value")
+	}
+	return nil
+}
+
+// WithCodec sets the codec used to serialize request and response bodies by `DoJSON` and the typed API clients. The
+// default is a `JSONCodec`.
+func WithCodec(codec Codec) Option {
+	return func(c *httpClient) {
+		c.codec = codec
+	}
+}
+
+// WithCodecWrapper wraps the codec used by the client, e.g. to validate or transform bodies before they are decoded.
+// Wrappers are applied once all other options have been applied, so the wrapped codec is the one configured using
+// `WithCodec` (or the default `JSONCodec`) regardless of the order of the options; when this option is supplied
+// multiple times, the first wrapper is the innermost.
+func WithCodecWrapper(wrap func(Codec) Codec) Option {
+	return func(c *httpClient) {
+		c.codecWrappers = append(c.codecWrappers, wrap)
+	}
+}
+
+// ClientCodec returns the codec configured on the supplied client, or the default `JSONCodec` if the client does not
+// have one (e.g. a mock client).
+func ClientCodec(c Client) Codec {
+	if cc, ok := c.(interface{ Codec() Codec }); ok {
+		if codec := cc.Codec(); codec != nil {
+			return codec
+		}
+	}
+	return JSONCodec{}
+}
+
+// Codec returns the codec configured on the client.
+func (c *httpClient) Codec() Codec {
+	return c.codec
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONCodec(t *testing.T) {
+	var v map[string]interface{}
+	require.NoError(t, JSONCodec{}.Unmarshal([]byte(`{"id":9007199254740993}`), &v))
+	assert.Equal(t, float64(9007199254740992), v["id"])
+
+	require.NoError(t, JSONCodec{UseNumber: true}.Unmarshal([]byte(`{"id":9007199254740993}`), &v))
+	assert.Equal(t, json.Number("9007199254740993"), v["id"])
+
+	assert.Error(t, JSONCodec{UseNumber: true}.Unmarshal([]byte(`{} {}`), &v))
+}
+
+// countingCodec records the use of the default codec.
+type countingCodec struct {
+	JSONCodec
+	unmarshals int
+}
+
+func (c *countingCodec) Unmarshal(data []byte, v any) error {
+	c.unmarshals++
+	return c.JSONCodec.Unmarshal(data, v)
+}
+
+func TestWithCodec(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":9007199254740993}`))
+	}))
+	defer ts.Close()
+
+	codec := &countingCodec{JSONCodec: JSONCodec{UseNumber: true}}
+	client := newTestClient(t, ts, WithCodec(codec))
+	assert.Equal(t, codec, ClientCodec(client))
+
+	req, err := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+	require.NoError(t, err)
+	v, err := DoJSON[map[string]interface{}](context.Background(), client, req)
+	require.NoError(t, err)
+	assert.Equal(t, json.Number("9007199254740993"), v["id"])
+	assert.Equal(t, 1, codec.unmarshals)
+}
+
+// wrappingCodec is a codec wrapper that is distinguishable from the codec it wraps.
+type wrappingCodec struct{ Codec }
+
+func TestWithCodecWrapper(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	codec := &countingCodec{}
+	wrap := WithCodecWrapper(func(c Codec) Codec { return &wrappingCodec{Codec: c} })
+
+	// The configured codec is wrapped regardless of the order of the options
+	assert.Equal(t, &wrappingCodec{Codec: codec}, ClientCodec(newTestClient(t, ts, wrap, WithCodec(codec))))
+	assert.Equal(t, &wrappingCodec{Codec: codec}, ClientCodec(newTestClient(t, ts, WithCodec(codec), wrap)))
+	assert.Equal(t, &wrappingCodec{Codec: JSONCodec{}}, ClientCodec(newTestClient(t, ts, wrap)))
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// DefaultCompressionThreshold is the minimum size of a request body that will be compressed.
+const DefaultCompressionThreshold = 1024
+
+// WithRequestCompression enables gzip compression of request bodies larger than the compression threshold.
+func WithRequestCompression() Option {
+	return func(c *httpClient) {
+		c.compressRequests = true
+	}
+}
+
+// compressionTransport requests compressed responses and optionally compresses request bodies.
+type compressionTransport struct {
+	compressRequests bool
+	base             http.RoundTripper
+}
+
+// RoundTrip negotiates gzip content encoding and decompresses the response.
+func (t *compressionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// If the caller is doing their own content negotiation, leave the request alone
+	if req.Header.Get("Accept-Encoding") != "" {
+		return transport(t.base).RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	if t.compressRequests {
+		if err := compressBody(req); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := transport(t.base).RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		resp.Body = &gzipBody{rc: resp.Body}
+		resp.Header.Del("Content-Encoding")
+		resp.Header.Del("Content-Length")
+		resp.ContentLength = -1
+		resp.Uncompressed = true
+	}
+
+	return resp, nil
+}
+
+// compressBody replaces a sufficiently large request body with a gzip compressed equivalent.
+func compressBody(req *http.Request) error {
+	if req.Body == nil || req.Body == http.NoBody || req.Header.Get("Content-Encoding") != "" {
+		return nil
+	}
+	if req.ContentLength >= 0 && req.ContentLength < DefaultCompressionThreshold {
+		return nil
+	}
+
+	b, err := ioutil.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return err
+	}
+
+	if len(b) >= DefaultCompressionThreshold {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(b); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		b = buf.Bytes()
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	req.ContentLength = int64(len(b))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(b)), nil
+	}
+	req.Body, _ = req.GetBody()
+	return nil
+}
+
+// gzipBody lazily decompresses a response body.
+type gzipBody struct {
+	rc io.ReadCloser
+	zr *gzip.Reader
+}
+
+func (b *gzipBody) Read(p []byte) (int, error) {
+	if b.zr == nil {
+		zr, err := gzip.NewReader(b.rc)
+		if err != nil {
+			return 0, err
+		}
+		b.zr = zr
+	}
+	return b.zr.Read(p)
+}
+
+func (b *gzipBody) Close() error {
+	return b.rc.Close()
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompression(t *testing.T) {
+	var attempts int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Fail the first attempt to exercise rewinding the compressed body
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		var body io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			zr, err := gzip.NewReader(r.Body)
+			require.NoError(t, err)
+			body = zr
+		}
+		b, err := ioutil.ReadAll(body)
+		require.NoError(t, err)
+
+		w.Header().Set("X-Content-Encoding", r.Header.Get("Content-Encoding"))
+		w.Header().Set("X-Content-Length", strconv.FormatInt(r.ContentLength, 10))
+		if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			w.Header().Set("Content-Encoding", "gzip")
+			zw := gzip.NewWriter(w)
+			_, _ = zw.Write(b)
+			_ = zw.Close()
+			return
+		}
+		_, _ = w.Write(b)
+	}))
+	defer ts.Close()
+
+	payload := strings.Repeat("compressible ", 1000)
+	cases := []struct {
+		desc             string
+		payload          string
+		expectedEncoding string
+	}{
+		{
+			desc:             "large",
+			payload:          payload,
+			expectedEncoding: "gzip",
+		},
+		{
+			desc:    "small",
+			payload: "tiny",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			atomic.StoreInt32(&attempts, 0)
+			client := newTestClient(t, ts, WithRequestCompression(), WithRetry(2, time.Millisecond))
+			req, err := http.NewRequest(http.MethodPut, client.URL("/").String(), strings.NewReader(c.payload))
+			require.NoError(t, err)
+
+			resp, body, err := client.Do(context.Background(), req)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, c.payload, string(body))
+			assert.Empty(t, resp.Header.Get("Content-Encoding"))
+			assert.Equal(t, c.expectedEncoding, resp.Header.Get("X-Content-Encoding"))
+			if c.expectedEncoding != "" {
+				n, err := strconv.Atoi(resp.Header.Get("X-Content-Length"))
+				require.NoError(t, err)
+				assert.Less(t, n, len(c.payload))
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// WithMaxConcurrentRequests limits the number of requests the client has in flight at once. Additional requests block
+// until another request completes or their context is done. A request made using `DoStream` remains in flight until
+// the response body is closed.
+func WithMaxConcurrentRequests(n int) Option {
+	return func(c *httpClient) {
+		if n > 0 {
+			c.inflight = make(semaphore, n)
+		} else {
+			c.inflight = nil
+		}
+	}
+}
+
+// semaphore bounds the number of concurrent holders.
+type semaphore chan struct{}
+
+// acquire blocks until the semaphore is acquired or the context is done. The returned function releases the
+// semaphore and is safe to call multiple times.
+func (s semaphore) acquire(ctx context.Context) (func(), error) {
+	select {
+	case s <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	var once sync.Once
+	return func() { once.Do(func() { <-s }) }, nil
+}
+
+// releaseBody is a response body that releases a semaphore when it is closed.
+type releaseBody struct {
+	io.ReadCloser
+	release func()
+}
+
+// Close closes the underlying body and releases the semaphore.
+func (b *releaseBody) Close() error {
+	defer b.release()
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithMaxConcurrentRequests(t *testing.T) {
+	var current, peak int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&current, 1)
+		defer atomic.AddInt32(&current, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts, WithMaxConcurrentRequests(2))
+	newRequest := func() *http.Request {
+		req, err := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+		require.NoError(t, err)
+		return req
+	}
+
+	t.Run("bounded", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 6; i++ {
+			wg.Add(1)
+			go func(req *http.Request) {
+				defer wg.Done()
+				_, _, err := client.Do(context.Background(), req)
+				assert.NoError(t, err)
+			}(newRequest())
+		}
+		wg.Wait()
+		assert.Equal(t, int32(2), atomic.LoadInt32(&peak))
+	})
+
+	t.Run("stream holds slot", func(t *testing.T) {
+		_, body1, err := client.DoStream(context.Background(), newRequest())
+		require.NoError(t, err)
+		_, body2, err := client.DoStream(context.Background(), newRequest())
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		_, _, err = client.Do(ctx, newRequest())
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+
+		_ = body1.Close()
+		_, _, err = client.Do(context.Background(), newRequest())
+		assert.NoError(t, err)
+		_ = body2.Close()
+	})
+}
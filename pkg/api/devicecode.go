@@ -0,0 +1,123 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+
+	"github.com/thestormforge/optimize-go/pkg/oauth2/devicecode"
+	"golang.org/x/oauth2"
+)
+
+// DeviceAuthorization performs the OAuth2 device authorization grant, blocking until the user approves the request,
+// the device code expires or the context is done. The returned configuration authorizes requests using the resulting
+// access token, using the refresh token (if any) to obtain new access tokens. If the prompt is nil, instructions are
+// printed to standard error. Endpoints are resolved as described by `StaticTokenConfig`.
+func DeviceAuthorization(ctx context.Context, cfg *devicecode.Config, prompt devicecode.UserInstruction, endpoints map[string]*url.URL) (Config, error) {
+	if prompt == nil {
+		prompt = func(userCode, verificationURI, _ string) {
+			_, _ = fmt.Fprintf(os.Stderr, "To authorize, visit %s and enter the code: %s\n", verificationURI, userCode)
+		}
+	}
+
+	t, err := cfg.Token(ctx, prompt)
+	if err != nil {
+		return nil, newOAuth2Error(err)
+	}
+
+	return &refreshTokenConfig{
+		config: oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			Endpoint:     oauth2.Endpoint{TokenURL: cfg.TokenURL, AuthStyle: cfg.AuthStyle},
+			Scopes:       cfg.Scopes,
+		},
+		token:     t,
+		endpoints: endpoints,
+	}, nil
+}
+
+// refreshTokenConfig authorizes requests using an existing token and its refresh token.
+type refreshTokenConfig struct {
+	config    oauth2.Config
+	token     *oauth2.Token
+	endpoints map[string]*url.URL
+}
+
+// Endpoints returns a resolver for the configured endpoint prefixes.
+func (c *refreshTokenConfig) Endpoints() (func(string) *url.URL, error) {
+	return prefixResolver(c.endpoints), nil
+}
+
+// Authorize returns a transport that adds a cached access token to each request.
+func (c *refreshTokenConfig) Authorize(ctx context.Context, transport http.RoundTripper) (http.RoundTripper, error) {
+	src, err := c.TokenSource(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &tokenTransport{tokens: &tokenCache{src: src, skew: DefaultTokenRefreshSkew}, base: transport}, nil
+}
+
+// TokenSource returns the existing token on the first call and a refreshed token on subsequent calls.
+func (c *refreshTokenConfig) TokenSource(ctx context.Context) (oauth2.TokenSource, error) {
+	return NewRefreshTokenSource(ctx, &c.config, c.token), nil
+}
+
+// NewRefreshTokenSource returns a token source that returns the supplied token on the first call (if it is still
+// valid) and uses the most recent refresh token to obtain a new token on each subsequent call. The supplied context
+// is used for requests to the token endpoint.
+func NewRefreshTokenSource(ctx context.Context, config *oauth2.Config, token *oauth2.Token) oauth2.TokenSource {
+	return &refreshTokenSource{ctx: ctx, config: config, token: token}
+}
+
+// refreshTokenSource uses the most recent refresh token to obtain a new token on each call.
+type refreshTokenSource struct {
+	ctx    context.Context
+	config *oauth2.Config
+
+	mu    sync.Mutex
+	token *oauth2.Token
+	used  bool
+}
+
+// Token returns a new token.
+func (s *refreshTokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Use the initial token once before forcing a refresh
+	if !s.used && s.token.Valid() {
+		s.used = true
+		return s.token, nil
+	}
+	if s.token.RefreshToken == "" {
+		return nil, fmt.Errorf("oauth2: token expired and refresh token is not set")
+	}
+
+	t, err := s.config.TokenSource(s.ctx, &oauth2.Token{RefreshToken: s.token.RefreshToken}).Token()
+	if err != nil {
+		return nil, newOAuth2Error(err)
+	}
+	s.used = true
+	s.token = t
+	return t, nil
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thestormforge/optimize-go/pkg/oauth2/devicecode"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+func TestDeviceAuthorization(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/device":
+			_, _ = w.Write([]byte(`{"device_code":"dc","user_code":"UC","verification_uri":"https://example.com/activate","expires_in":60}`))
+		case "/token":
+			if r.PostForm.Get("grant_type") == "refresh_token" {
+				assert.Equal(t, "rt", r.PostForm.Get("refresh_token"))
+				_, _ = w.Write([]byte(`{"access_token":"refreshed","token_type":"bearer","expires_in":3600}`))
+				return
+			}
+			// Expire immediately to force a refresh on the next request
+			_, _ = w.Write([]byte(`{"access_token":"initial","token_type":"bearer","refresh_token":"rt","expires_in":1}`))
+		default:
+			_, _ = w.Write([]byte(r.Header.Get("Authorization")))
+		}
+	}))
+	defer ts.Close()
+
+	base, err := url.Parse(ts.URL + "/")
+	require.NoError(t, err)
+
+	var verificationURI string
+	cfg, err := DeviceAuthorization(context.Background(), &devicecode.Config{
+		Config: clientcredentials.Config{
+			ClientID:  "client",
+			TokenURL:  ts.URL + "/token",
+			AuthStyle: oauth2.AuthStyleInParams,
+		},
+		DeviceAuthorizationURL: ts.URL + "/device",
+	}, func(_, uri, _ string) { verificationURI = uri }, map[string]*url.URL{"/": base})
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/activate", verificationURI)
+
+	client, err := NewClient(context.Background(), cfg)
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, client.URL("/experiments/").String(), nil)
+	require.NoError(t, err)
+	_, body, err := client.Do(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer refreshed", string(body))
+}
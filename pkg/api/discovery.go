@@ -0,0 +1,140 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/thestormforge/optimize-go/pkg/oauth2/discovery"
+)
+
+// DefaultDiscoveryTimeout is the time limit for fetching a discovery document.
+const DefaultDiscoveryTimeout = 5 * time.Second
+
+// DiscoveryDocument lists the locations of the API endpoints.
+type DiscoveryDocument struct {
+	// Endpoints maps endpoint prefixes (e.g. "/experiments/") to their locations, relative locations are resolved
+	// against the location of the document.
+	Endpoints map[string]string `json:"endpoints"`
+}
+
+// DiscoveryConfig resolves endpoints using the discovery document published by the API server at
+// "/.well-known/optimize-configuration". The document is not fetched until an endpoint is first resolved; the result
+// (including a failure to obtain it) is cached for the lifetime of the configuration. Endpoints that are not listed in
+// the document, or all endpoints if discovery fails, are resolved using the fallback configuration, which is also used
+// for authorization.
+type DiscoveryConfig struct {
+	// ServerIdentifier is the base URL of the API server, e.g. "https://api.stormforge.io/v1/".
+	ServerIdentifier string
+	// Fallback is the configuration used for authorization and for any endpoints that cannot be discovered.
+	Fallback Config
+	// HTTPClient is used to fetch the discovery document, the default client is used if it is nil. The document is
+	// fetched without authorization.
+	HTTPClient *http.Client
+
+	once     sync.Once
+	resolver func(string) *url.URL
+	err      error
+}
+
+// Endpoints returns a resolver that prefers the locations in the discovery document.
+func (c *DiscoveryConfig) Endpoints() (func(string) *url.URL, error) {
+	fallback, err := c.Fallback.Endpoints()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ep string) *url.URL {
+		if c.Err() == nil {
+			if u := c.resolver(ep); u != nil {
+				return u
+			}
+		}
+		return fallback(ep)
+	}, nil
+}
+
+// Authorize delegates to the fallback configuration.
+func (c *DiscoveryConfig) Authorize(ctx context.Context, transport http.RoundTripper) (http.RoundTripper, error) {
+	return c.Fallback.Authorize(ctx, transport)
+}
+
+// Err returns the reason the discovery document could not be used, if any, fetching it if necessary.
+func (c *DiscoveryConfig) Err() error {
+	c.once.Do(func() { c.resolver, c.err = c.discover() })
+	return c.err
+}
+
+// discover fetches the discovery document and returns a resolver for the endpoints it lists.
+func (c *DiscoveryConfig) discover() (func(string) *url.URL, error) {
+	issuer, err := discovery.IssuerURL(c.ServerIdentifier)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := url.Parse(discovery.WellKnownURI(issuer, "optimize-configuration"))
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultDiscoveryTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, doc.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, NewError(resp, body)
+	}
+
+	dd := DiscoveryDocument{}
+	if err := json.Unmarshal(body, &dd); err != nil {
+		return nil, err
+	}
+
+	endpoints := make(map[string]*url.URL, len(dd.Endpoints))
+	for ep, loc := range dd.Endpoints {
+		u, err := doc.Parse(loc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid location for %q: %w", ep, err)
+		}
+		endpoints[ep] = u
+	}
+	return prefixResolver(endpoints), nil
+}
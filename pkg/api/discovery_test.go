@@ -0,0 +1,72 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscoveryConfig(t *testing.T) {
+	var fetches int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/optimize-configuration/v1":
+			atomic.AddInt32(&fetches, 1)
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"endpoints":{"/experiments/":"/v2/experiments/","/accounts/":"https://accounts.example.com/"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	newConfig := func(server string) *DiscoveryConfig {
+		endpoints, err := Endpoints(ts.URL+"/v1/", nil)
+		require.NoError(t, err)
+		return &DiscoveryConfig{ServerIdentifier: server, Fallback: StaticTokenConfig("", endpoints)}
+	}
+
+	t.Run("discovered", func(t *testing.T) {
+		cfg := newConfig(ts.URL + "/v1/")
+		c, err := NewClient(context.Background(), cfg)
+		require.NoError(t, err)
+		assert.Equal(t, int32(0), atomic.LoadInt32(&fetches), "discovery was not lazy")
+
+		assert.Equal(t, ts.URL+"/v2/experiments/foo", c.URL("/experiments/foo").String())
+		assert.Equal(t, "https://accounts.example.com/me", c.URL("/accounts/me").String())
+		assert.Equal(t, ts.URL+"/v1/health", c.URL("/health").String(), "undiscovered endpoints should fall back")
+		assert.Equal(t, int32(1), atomic.LoadInt32(&fetches))
+		assert.NoError(t, cfg.Err())
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		cfg := newConfig(ts.URL + "/v3/")
+		c, err := NewClient(context.Background(), cfg)
+		require.NoError(t, err)
+
+		assert.Equal(t, ts.URL+"/v1/experiments/foo", c.URL("/experiments/foo").String())
+		assert.True(t, errors.Is(cfg.Err(), ErrNotFound))
+	})
+}
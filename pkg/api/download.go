@@ -0,0 +1,189 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultDownloadAttempts is the number of consecutive attempts made by `ResumableDownload` that transfer no data
+// before giving up.
+const DefaultDownloadAttempts = 5
+
+// downloadBackoff is the base delay between download attempts.
+const downloadBackoff = 100 * time.Millisecond
+
+// ErrNotResumable is returned when a download is interrupted and the server cannot continue it from where it left off.
+var ErrNotResumable = errors.New("download cannot be resumed")
+
+// ResumableDownload copies the body of a GET request for the supplied URL to the writer, returning the number of
+// bytes written. If the transfer is interrupted by a transient failure and the server advertises
+// "Accept-Ranges: bytes", the download is resumed using a Range request for the remaining bytes; the ETag (or
+// Last-Modified time) of the original response is sent using If-Range so a resource that changed in the meantime is
+// never spliced together. Downloads that cannot be resumed fail with an error matching `ErrNotResumable`. Compressed
+// responses are never requested, since the byte offsets of a compressed transfer do not match the content. The client
+// timeout does not apply to the transfer, use the context to limit the time spent on the download.
+func ResumableDownload(ctx context.Context, c Client, u string, w io.Writer) (int64, error) {
+	// The client timeout covers reading the body, large downloads would never finish
+	ctx = WithRequestOption(ctx, WithRequestTimeout(0))
+
+	d := &download{client: c, url: u, w: w}
+	p := retryPolicy{baseDelay: downloadBackoff}
+	for failures := 1; ; failures++ {
+		before := d.written
+		err := d.attempt(ctx)
+		if err == nil {
+			return d.written, nil
+		}
+		if d.written > before {
+			failures = 1
+		}
+
+		var te *transientError
+		if !errors.As(err, &te) || failures >= DefaultDownloadAttempts || ctx.Err() != nil {
+			if te != nil {
+				err = te.err
+			}
+			return d.written, err
+		}
+
+		if err := p.wait(ctx, p.backoff(failures)); err != nil {
+			return d.written, err
+		}
+	}
+}
+
+// download tracks the state of a resumable download.
+type download struct {
+	client    Client
+	url       string
+	w         io.Writer
+	written   int64
+	resumable bool
+	validator string
+}
+
+// transientError marks a failed download attempt as worth trying again.
+type transientError struct{ err error }
+
+func (e *transientError) Error() string { return e.err.Error() }
+func (e *transientError) Unwrap() error { return e.err }
+
+// attempt requests the remaining bytes of the download and copies them to the writer.
+func (d *download) attempt(ctx context.Context) error {
+	req, err := http.NewRequest(http.MethodGet, d.url, nil)
+	if err != nil {
+		return err
+	}
+
+	// Ranges (and the bytes written) refer to the unencoded content, so the response must not be compressed
+	req.Header.Set("Accept-Encoding", "identity")
+	if d.written > 0 {
+		req.Header.Set("Range", "bytes="+strconv.FormatInt(d.written, 10)+"-")
+		if d.validator != "" {
+			req.Header.Set("If-Range", d.validator)
+		}
+	}
+
+	resp, rc, err := d.client.DoStream(ctx, req)
+	if err != nil {
+		if isTransient(nil, err) {
+			return &transientError{err: err}
+		}
+		return err
+	}
+	defer rc.Close()
+
+	switch {
+	case d.written == 0 && resp.StatusCode == http.StatusOK:
+		d.resumable = strings.EqualFold(resp.Header.Get("Accept-Ranges"), "bytes")
+		d.validator = rangeValidator(resp.Header)
+	case d.written > 0 && resp.StatusCode == http.StatusPartialContent:
+		if start, ok := contentRangeStart(resp.Header.Get("Content-Range")); !ok || start != d.written {
+			return fmt.Errorf("%w: unexpected content range %q", ErrNotResumable, resp.Header.Get("Content-Range"))
+		}
+	case d.written > 0 && resp.StatusCode == http.StatusOK:
+		// The range was ignored (or the If-Range condition failed), the bytes already written cannot be taken back
+		return fmt.Errorf("%w: server returned the entire resource", ErrNotResumable)
+	default:
+		body, _ := io.ReadAll(io.LimitReader(rc, 4<<10))
+		err := DecodeError(d.client, resp, body)
+		if isTransient(resp, nil) {
+			return &transientError{err: err}
+		}
+		return err
+	}
+
+	cw := &countingWriter{w: d.w}
+	_, err = io.Copy(cw, rc)
+	d.written += cw.n
+	switch {
+	case err == nil:
+		return nil
+	case cw.err != nil || ctx.Err() != nil:
+		return err
+	case !d.resumable && d.written > 0:
+		return fmt.Errorf("%w: %w", ErrNotResumable, err)
+	default:
+		return &transientError{err: err}
+	}
+}
+
+// rangeValidator returns the value to use for If-Range, weak entity tags cannot be used.
+func rangeValidator(h http.Header) string {
+	if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return h.Get("Last-Modified")
+}
+
+// contentRangeStart returns the first byte position of a "Content-Range: bytes start-end/size" header.
+func contentRangeStart(v string) (int64, bool) {
+	v, ok := strings.CutPrefix(strings.TrimSpace(v), "bytes ")
+	if !ok {
+		return 0, false
+	}
+	start, _, ok := strings.Cut(v, "-")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(start, 10, 64)
+	return n, err == nil
+}
+
+// countingWriter tracks the number of bytes written and any error produced by the underlying writer.
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	if err != nil {
+		w.err = err
+	}
+	return n, err
+}
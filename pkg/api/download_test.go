@@ -0,0 +1,191 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// interruptingHandler serves the content using ranges, dropping the connection after `limit` bytes of the response
+// have been sent for each of the first `interruptions` requests.
+func interruptingHandler(content []byte, etag string, acceptRanges bool, interruptions, limit int, ranges *[]string) http.HandlerFunc {
+	var mu sync.Mutex
+	var count int
+	return func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		count++
+		n := count
+		*ranges = append(*ranges, r.Header.Get("Range"))
+		mu.Unlock()
+
+		if !acceptRanges {
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			if n <= interruptions {
+				_, _ = w.Write(content[:limit])
+				panic(http.ErrAbortHandler)
+			}
+			_, _ = w.Write(content)
+			return
+		}
+
+		w.Header().Set("ETag", etag)
+		if n <= interruptions {
+			rw := &limitedResponseWriter{ResponseWriter: w, remaining: limit}
+			http.ServeContent(rw, r, "", time.Time{}, bytes.NewReader(content))
+			panic(http.ErrAbortHandler)
+		}
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+	}
+}
+
+// limitedResponseWriter stops writing the body after a fixed number of bytes.
+type limitedResponseWriter struct {
+	http.ResponseWriter
+	remaining int
+}
+
+func (w *limitedResponseWriter) Write(p []byte) (int, error) {
+	if len(p) > w.remaining {
+		p = p[:w.remaining]
+	}
+	w.remaining -= len(p)
+	n, err := w.ResponseWriter.Write(p)
+	if err == nil && w.remaining == 0 {
+		w.ResponseWriter.(http.Flusher).Flush()
+		panic(http.ErrAbortHandler)
+	}
+	return n, err
+}
+
+func TestResumableDownload(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 10000)
+
+	t.Run("resumed", func(t *testing.T) {
+		var ranges []string
+		ts := httptest.NewServer(interruptingHandler(content, `"v1"`, true, 2, 30000, &ranges))
+		defer ts.Close()
+
+		client := newTestClient(t, ts)
+		var buf bytes.Buffer
+		n, err := ResumableDownload(context.Background(), client, client.URL("/export").String(), &buf)
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(content)), n)
+		assert.True(t, bytes.Equal(content, buf.Bytes()), "content was not reassembled")
+		assert.Equal(t, []string{"", "bytes=30000-", "bytes=60000-"}, ranges)
+	})
+
+	t.Run("compressible", func(t *testing.T) {
+		var ranges, encodings []string
+		h := interruptingHandler(content, `"v1"`, true, 1, 30000, &ranges)
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			encodings = append(encodings, r.Header.Get("Accept-Encoding"))
+			if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+				// Compressed offsets do not line up with the content, serve a truncated compressed response
+				w.Header().Set("Accept-Ranges", "bytes")
+				w.Header().Set("Content-Encoding", "gzip")
+				zw := gzip.NewWriter(w)
+				_, _ = zw.Write(content[:50000])
+				_ = zw.Flush()
+				panic(http.ErrAbortHandler)
+			}
+			h(w, r)
+		}))
+		defer ts.Close()
+
+		client := newTestClient(t, ts)
+		var buf bytes.Buffer
+		n, err := ResumableDownload(context.Background(), client, client.URL("/export").String(), &buf)
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(content)), n)
+		assert.True(t, bytes.Equal(content, buf.Bytes()), "content was not reassembled")
+		assert.Equal(t, []string{"", "bytes=30000-"}, ranges)
+		assert.Equal(t, []string{"identity", "identity"}, encodings)
+	})
+
+	t.Run("slow transfer", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			_, _ = w.Write(content[:30000])
+			w.(http.Flusher).Flush()
+
+			// Take longer than the client timeout to finish the transfer
+			time.Sleep(200 * time.Millisecond)
+			_, _ = w.Write(content[30000:])
+		}))
+		defer ts.Close()
+
+		client := newTestClient(t, ts, WithTimeout(50*time.Millisecond))
+		var buf bytes.Buffer
+		n, err := ResumableDownload(context.Background(), client, client.URL("/export").String(), &buf)
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(content)), n)
+		assert.True(t, bytes.Equal(content, buf.Bytes()), "content was not transferred")
+	})
+
+	t.Run("not resumable", func(t *testing.T) {
+		var ranges []string
+		ts := httptest.NewServer(interruptingHandler(content, "", false, 1, 30000, &ranges))
+		defer ts.Close()
+
+		client := newTestClient(t, ts)
+		var buf bytes.Buffer
+		n, err := ResumableDownload(context.Background(), client, client.URL("/export").String(), &buf)
+		assert.True(t, errors.Is(err, ErrNotResumable))
+		assert.Equal(t, int64(30000), n)
+		assert.Len(t, ranges, 1)
+	})
+
+	t.Run("changed", func(t *testing.T) {
+		var ranges []string
+		etag := `"v1"`
+		h := interruptingHandler(content, etag, true, 1, 30000, &ranges)
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("If-Range") != "" {
+				// Simulate the resource changing between attempts
+				r.Header.Set("If-Range", `"v0"`)
+			}
+			h(w, r)
+		}))
+		defer ts.Close()
+
+		client := newTestClient(t, ts)
+		_, err := ResumableDownload(context.Background(), client, client.URL("/export").String(), &bytes.Buffer{})
+		assert.True(t, errors.Is(err, ErrNotResumable))
+	})
+
+	t.Run("client error", func(t *testing.T) {
+		ts := httptest.NewServer(http.NotFoundHandler())
+		defer ts.Close()
+
+		client := newTestClient(t, ts)
+		_, err := ResumableDownload(context.Background(), client, client.URL("/export").String(), &bytes.Buffer{})
+		assert.True(t, errors.Is(err, ErrNotFound))
+	})
+}
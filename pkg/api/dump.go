@@ -0,0 +1,126 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+	"sync"
+)
+
+// WithDebugDump writes the wire representation of every request and response to the supplied writer, suitable for
+// inclusion in bug reports. Credentials, including authorization headers and cookies, are redacted. If bodies are
+// included they are buffered in memory, with the exception of event streams whose bodies are never dumped.
+func WithDebugDump(w io.Writer, includeBodies bool) Option {
+	return func(c *httpClient) {
+		c.debugDump = w
+		c.debugDumpBodies = includeBodies
+	}
+}
+
+// dumpTransport writes requests and responses to a writer.
+type dumpTransport struct {
+	w      io.Writer
+	bodies bool
+	mu     sync.Mutex
+	base   http.RoundTripper
+}
+
+// RoundTrip dumps the request, performs the round trip and then dumps the response.
+func (t *dumpTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	dreq := req.Clone(req.Context())
+	if t.bodies && req.Body != nil && req.Body != http.NoBody {
+		b, err := ioutil.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		// Replace the body on a copy of the request so the original is not consumed
+		req = req.Clone(req.Context())
+		req.Body = ioutil.NopCloser(bytes.NewReader(b))
+		dreq.Body = ioutil.NopCloser(bytes.NewReader(b))
+	}
+	dreq.Header = redactHeaders(dreq.Header)
+
+	dump, err := httputil.DumpRequestOut(dreq, t.bodies)
+	if err != nil {
+		return nil, err
+	}
+	t.write(dump)
+
+	resp, err := transport(t.base).RoundTrip(req)
+	if err != nil {
+		t.write([]byte(fmt.Sprintf("* %v\n", err)))
+		return nil, err
+	}
+
+	// Dump a copy of the response with redacted headers, the dumped body replaces the original
+	dresp := *resp
+	dresp.Header = redactHeaders(resp.Header)
+	dump, err = httputil.DumpResponse(&dresp, t.bodies && !isMediaType(resp.Header, "text/event-stream"))
+	resp.Body = dresp.Body
+	if err != nil {
+		_ = resp.Body.Close()
+		return nil, err
+	}
+	t.write(dump)
+	return resp, nil
+}
+
+// write writes a single dump, ensuring concurrent dumps are not interleaved.
+func (t *dumpTransport) write(dump []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, _ = t.w.Write(dump)
+	if !bytes.HasSuffix(dump, []byte("\n")) {
+		_, _ = t.w.Write([]byte("\n"))
+	}
+}
+
+// redactedHeaders are the names of headers which carry credentials. HMAC request signatures are also sent using the
+// Authorization header.
+var redactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// redactHeaders returns a copy of the supplied headers with the values of the redacted headers hidden. The scheme of
+// authorization headers is preserved, cookies are hidden entirely.
+func redactHeaders(header http.Header) http.Header {
+	h := header.Clone()
+	for _, k := range redactedHeaders {
+		for i, v := range h[k] {
+			if strings.HasSuffix(k, "Authorization") {
+				h[k][i] = redact(v)
+			} else {
+				h[k][i] = "[REDACTED]"
+			}
+		}
+	}
+	return h
+}
+
+// redact hides the credentials of an authorization header value, preserving the scheme.
+func redact(auth string) string {
+	if i := strings.IndexByte(auth, ' '); i > 0 {
+		return auth[:i] + " [REDACTED]"
+	}
+	return "[REDACTED]"
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithDebugDump(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "secret-session"})
+		_, _ = w.Write(append([]byte("echo: "), b...))
+	}))
+	defer ts.Close()
+
+	cases := []struct {
+		desc          string
+		includeBodies bool
+	}{
+		{desc: "headers only"},
+		{desc: "bodies", includeBodies: true},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			var dump bytes.Buffer
+			client, err := NewClient(context.Background(), StaticTokenConfig("secret-token", map[string]*url.URL{"/": mustParseURL(t, ts.URL)}),
+				WithDebugDump(&dump, c.includeBodies))
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPost, client.URL("/test").String(), strings.NewReader("request body"))
+			require.NoError(t, err)
+			req.AddCookie(&http.Cookie{Name: "session", Value: "secret-cookie"})
+
+			_, body, err := client.Do(context.Background(), req)
+			require.NoError(t, err)
+			assert.Equal(t, "echo: request body", string(body))
+
+			out := dump.String()
+			assert.Contains(t, out, "POST /test HTTP/1.1")
+			assert.Contains(t, out, "Authorization: Bearer [REDACTED]")
+			assert.NotContains(t, out, "secret-token")
+			assert.Contains(t, out, "Cookie: [REDACTED]")
+			assert.NotContains(t, out, "secret-cookie")
+			assert.Contains(t, out, "Set-Cookie: [REDACTED]")
+			assert.NotContains(t, out, "secret-session")
+			assert.Contains(t, out, "HTTP/1.1 200 OK")
+			if c.includeBodies {
+				assert.Contains(t, out, "request body")
+				assert.Contains(t, out, "echo: request body")
+			} else {
+				assert.NotContains(t, out, "request body")
+			}
+		})
+	}
+}
+
+func TestRedactHeaders(t *testing.T) {
+	header := http.Header{}
+	header.Set("Authorization", `HMAC-SHA256 keyId="key",headers="date",signature="c2lnbmF0dXJl"`)
+	header.Set("Proxy-Authorization", "Basic dXNlcjpwYXNz")
+	header.Set("Cookie", "session=secret")
+	header.Add("Set-Cookie", "a=1")
+	header.Add("Set-Cookie", "b=2")
+	header.Set("Content-Type", "application/json")
+
+	assert.Equal(t, http.Header{
+		"Authorization":       {"HMAC-SHA256 [REDACTED]"},
+		"Proxy-Authorization": {"Basic [REDACTED]"},
+		"Cookie":              {"[REDACTED]"},
+		"Set-Cookie":          {"[REDACTED]", "[REDACTED]"},
+		"Content-Type":        {"application/json"},
+	}, redactHeaders(header))
+	assert.Equal(t, "session=secret", header.Get("Cookie"), "original header must not be modified")
+}
+
+func mustParseURL(t *testing.T, rawURL string) *url.URL {
+	u, err := url.Parse(rawURL)
+	require.NoError(t, err)
+	return u
+}
@@ -0,0 +1,217 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// pathParameters maps collection names to the placeholder used for the resource identifier that follows them.
+var pathParameters = map[string]string{
+	"experiments":  "{name}",
+	"trials":       "{number}",
+	"applications": "{name}",
+	"scenarios":    "{name}",
+}
+
+// EndpointPattern returns a templated version of the supplied URL path with resource identifiers replaced by
+// placeholders, e.g. "/experiments/foo/trials/1" becomes "/experiments/{name}/trials/{number}". The result has bounded
+// cardinality and is suitable for use in span names or metric labels.
+func EndpointPattern(path string) string {
+	segments := strings.Split(path, "/")
+	for i := 1; i < len(segments); i++ {
+		if p, ok := pathParameters[segments[i-1]]; ok && segments[i] != "" {
+			segments[i] = p
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// WithBasePath prefixes the path of every resolved endpoint, for example when the API server is exposed under
+// "/optimize/v1" by an ingress. Leading and trailing slashes on the prefix are ignored and the prefix is added exactly
+// once: resolved paths that already start with it are not modified. Unlike changing the base URL of the configuration,
+// the prefix also applies to endpoints mapped to absolute URLs.
+func WithBasePath(prefix string) Option {
+	return func(c *httpClient) {
+		c.basePath = ""
+		if p := strings.Trim(prefix, "/"); p != "" {
+			c.basePath = "/" + p
+		}
+	}
+}
+
+// knownEndpoints are the endpoint prefixes served by the API server, relative to the API base URL.
+var knownEndpoints = []string{"/experiments/", "/applications/", "/accounts/", "/health"}
+
+// Endpoints returns the locations of all the known API endpoints relative to the supplied base URL (e.g.
+// "https://api.stormforge.io/v1/"), suitable for use with configurations such as `StaticTokenConfig`. The overrides
+// map endpoint prefixes (e.g. "/experiments/") to absolute URLs used in place of the derived location.
+func Endpoints(base string, overrides map[string]string) (map[string]*url.URL, error) {
+	b, err := parseBaseURL(base)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints := make(map[string]*url.URL, len(knownEndpoints)+len(overrides))
+	for _, ep := range knownEndpoints {
+		endpoints[ep] = JoinURL(b, ep)
+	}
+
+	for ep, o := range overrides {
+		u, err := parseBaseURL(o)
+		if err != nil {
+			return nil, fmt.Errorf("invalid override for %q: %w", ep, err)
+		}
+		endpoints[ep] = u
+	}
+
+	return endpoints, nil
+}
+
+// parseBaseURL parses an absolute HTTP URL.
+func parseBaseURL(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid base URL %q: scheme must be http or https", rawURL)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q: missing host", rawURL)
+	}
+	return u, nil
+}
+
+// prefixResolver returns an endpoint resolver that appends the remainder of the endpoint to the URL of the longest
+// matching prefix in the supplied map. Endpoints that do not match any prefix resolve to nil, prefixes that do not end
+// with a slash (e.g. "/health") resolve to exactly the mapped URL.
+func prefixResolver(endpoints map[string]*url.URL) func(string) *url.URL {
+	return func(ep string) *url.URL {
+		var prefix string
+		var base *url.URL
+		for k, v := range endpoints {
+			if strings.HasPrefix(ep, k) && len(k) >= len(prefix) {
+				prefix, base = k, v
+			}
+		}
+		if base == nil {
+			return nil
+		}
+
+		// Prefixes without a trailing slash identify a single resource rather than a directory
+		if ep == prefix && !strings.HasSuffix(prefix, "/") {
+			u := *base
+			return &u
+		}
+
+		return JoinURL(base, strings.TrimPrefix(ep, prefix))
+	}
+}
+
+// JoinURL returns the location of the endpoint relative to the base URL. The base URL is always treated as a
+// directory, regardless of a trailing slash, and any leading slash on the endpoint is ignored, for example
+// "https://x/api" and "/experiments" produce "https://x/api/experiments". A query string on the endpoint is merged
+// with the query string of the base URL.
+func JoinURL(base *url.URL, endpoint string) *url.URL {
+	// Avoid url.Parse so endpoints are never mistaken for an absolute URL (e.g. "foo:bar")
+	p, q := endpoint, ""
+	if i := strings.IndexByte(endpoint, '?'); i >= 0 {
+		p, q = endpoint[:i], endpoint[i+1:]
+	}
+
+	b := *base
+	if !strings.HasSuffix(b.Path, "/") {
+		b.Path += "/"
+		if b.RawPath != "" {
+			b.RawPath += "/"
+		}
+	}
+
+	u := &b
+	if p = strings.TrimLeft(p, "/"); p != "" {
+		u = b.ResolveReference(&url.URL{Path: p})
+	}
+
+	switch {
+	case q == "":
+		u.RawQuery = base.RawQuery
+	case base.RawQuery == "":
+		u.RawQuery = q
+	default:
+		u.RawQuery = base.RawQuery + "&" + q
+	}
+	return u
+}
+
+// URLWithQuery returns the location of the endpoint with the supplied query parameters. Parameters already present on
+// the resolved endpoint are preserved unless they are also specified in the supplied query.
+func URLWithQuery(c Client, endpoint string, q url.Values) *url.URL {
+	u := c.URL(endpoint)
+	if u == nil || len(q) == 0 {
+		return u
+	}
+
+	u2 := *u
+	v := u2.Query()
+	for k, vs := range q {
+		v[k] = append([]string(nil), vs...)
+	}
+	u2.RawQuery = v.Encode()
+	return &u2
+}
+
+// insertPathPrefix returns a copy of the URL resolved for an endpoint with the prefix inserted before the endpoint
+// path. If the resolved path does not end with the endpoint path (e.g. the endpoint is mapped to an arbitrary URL),
+// the prefix is added to the beginning of the path. The raw prefix is used in the escaped form of the path.
+func insertPathPrefix(u *url.URL, endpoint, prefix, rawPrefix string) *url.URL {
+	if i := strings.IndexByte(endpoint, '?'); i >= 0 {
+		endpoint = endpoint[:i]
+	}
+	endpoint = "/" + strings.TrimLeft(endpoint, "/")
+
+	insert := func(p, prefix string) string {
+		i := 0
+		if strings.HasSuffix(p, endpoint) {
+			i = len(p) - len(endpoint)
+		}
+		return p[:i] + prefix + "/" + strings.TrimLeft(p[i:], "/")
+	}
+
+	u2 := *u
+	u2.Path = insert(u2.Path, prefix)
+	if u2.RawPath != "" {
+		u2.RawPath = insert(u2.RawPath, rawPrefix)
+	}
+	return &u2
+}
+
+// addBasePath returns a copy of the URL with the path prefix added, unless the path already starts with it.
+func addBasePath(u *url.URL, prefix string) *url.URL {
+	if u.Path == prefix || strings.HasPrefix(u.Path, prefix+"/") {
+		return u
+	}
+
+	u2 := *u
+	u2.Path = prefix + "/" + strings.TrimLeft(u.Path, "/")
+	if u2.RawPath != "" {
+		u2.RawPath = (&url.URL{Path: prefix}).EscapedPath() + "/" + strings.TrimLeft(u.RawPath, "/")
+	}
+	return &u2
+}
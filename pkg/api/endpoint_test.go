@@ -0,0 +1,122 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEndpoints(t *testing.T) {
+	t.Run("derived", func(t *testing.T) {
+		endpoints, err := Endpoints("https://api.example.com/v1", map[string]string{
+			"/experiments/": "http://localhost:8080/experiments/",
+		})
+		require.NoError(t, err)
+
+		resolve := prefixResolver(endpoints)
+		assert.Equal(t, "http://localhost:8080/experiments/foo", resolve("/experiments/foo").String())
+		assert.Equal(t, "https://api.example.com/v1/applications/bar", resolve("/applications/bar").String())
+		assert.Equal(t, "https://api.example.com/v1/accounts/", resolve("/accounts/").String())
+		assert.Nil(t, resolve("/unknown/"))
+	})
+
+	t.Run("invalid base", func(t *testing.T) {
+		for _, base := range []string{"", "api.example.com", "ftp://api.example.com/", "https://", "https://api example.com/"} {
+			_, err := Endpoints(base, nil)
+			assert.Error(t, err, base)
+		}
+	})
+
+	t.Run("invalid override", func(t *testing.T) {
+		_, err := Endpoints("https://api.example.com/v1/", map[string]string{"/experiments/": "/experiments/"})
+		assert.Error(t, err)
+	})
+}
+
+func TestJoinURL(t *testing.T) {
+	cases := []struct {
+		base     string
+		endpoint string
+		expected string
+	}{
+		{base: "https://x/api", endpoint: "experiments", expected: "https://x/api/experiments"},
+		{base: "https://x/api/", endpoint: "experiments", expected: "https://x/api/experiments"},
+		{base: "https://x/api", endpoint: "/experiments", expected: "https://x/api/experiments"},
+		{base: "https://x/api/", endpoint: "/experiments/", expected: "https://x/api/experiments/"},
+		{base: "https://x", endpoint: "experiments", expected: "https://x/experiments"},
+		{base: "https://x/api", endpoint: "", expected: "https://x/api/"},
+		{base: "https://x/api/v1", endpoint: "experiments/foo/trials", expected: "https://x/api/v1/experiments/foo/trials"},
+		{base: "https://x/api?tenant=a", endpoint: "experiments", expected: "https://x/api/experiments?tenant=a"},
+		{base: "https://x/api?tenant=a", endpoint: "experiments?limit=10", expected: "https://x/api/experiments?tenant=a&limit=10"},
+		{base: "https://x/api", endpoint: "experiments?limit=10", expected: "https://x/api/experiments?limit=10"},
+		{base: "https://x/api", endpoint: "foo:bar", expected: "https://x/api/foo:bar"},
+	}
+	for _, c := range cases {
+		t.Run(c.base+" "+c.endpoint, func(t *testing.T) {
+			base, err := url.Parse(c.base)
+			require.NoError(t, err)
+
+			assert.Equal(t, c.expected, JoinURL(base, c.endpoint).String())
+			assert.Equal(t, c.base, base.String(), "base URL was modified")
+		})
+	}
+}
+
+func TestURLWithQuery(t *testing.T) {
+	c := &httpClient{endpoints: func(ep string) *url.URL {
+		u, _ := url.Parse("https://x/api" + ep + "?tenant=a&limit=5")
+		return u
+	}}
+
+	u := URLWithQuery(c, "/experiments/", url.Values{"limit": {"10"}, "name": {"a b&c"}})
+	assert.Equal(t, "https://x/api/experiments/?limit=10&name=a+b%26c&tenant=a", u.String())
+	assert.Equal(t, "https://x/api/experiments/?tenant=a&limit=5", URLWithQuery(c, "/experiments/", nil).String())
+}
+
+func TestWithBasePath(t *testing.T) {
+	endpoints, err := Endpoints("https://api.example.com/", map[string]string{"/accounts/": "https://accounts.example.com/"})
+	require.NoError(t, err)
+	endpoints["/"] = endpoints["/experiments/"].ResolveReference(&url.URL{Path: "/"})
+
+	for _, prefix := range []string{"/optimize/v1", "optimize/v1/", "//optimize/v1//"} {
+		t.Run(prefix, func(t *testing.T) {
+			c, err := NewClient(context.Background(), StaticTokenConfig("", endpoints), WithBasePath(prefix))
+			require.NoError(t, err)
+
+			assert.Equal(t, "https://api.example.com/optimize/v1/experiments/foo", c.URL("/experiments/foo").String())
+			assert.Equal(t, "https://api.example.com/optimize/v1/health", c.URL("/health").String())
+			assert.Equal(t, "https://accounts.example.com/optimize/v1/me", c.URL("/accounts/me").String())
+			assert.Equal(t, "https://api.example.com/optimize/v1/experiments/?limit=1", URLWithQuery(c, "/experiments/", url.Values{"limit": {"1"}}).String())
+
+			// Endpoints that already include the prefix are not prefixed again
+			assert.Equal(t, "https://api.example.com/optimize/v1/experiments/", c.URL("/optimize/v1/experiments/").String())
+			assert.Equal(t, "https://api.example.com/optimize/v1/optimize/v1x/", c.URL("/optimize/v1x/").String())
+		})
+	}
+
+	t.Run("organization", func(t *testing.T) {
+		c, err := NewClient(context.Background(), StaticTokenConfig("", endpoints), WithBasePath("/optimize/v1"),
+			WithOrganization("acme"), WithOrganizationMode(OrganizationPath))
+		require.NoError(t, err)
+		assert.Equal(t, "https://api.example.com/optimize/v1/orgs/acme/experiments/foo", c.URL("/experiments/foo").String())
+	})
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// DefaultEnvPrefix is the prefix of the environment variables read by `ConfigFromEnv` unless otherwise configured.
+const DefaultEnvPrefix = "REDSKY_"
+
+// DefaultAudience is the logical identifier of the API requested for access tokens unless otherwise configured.
+const DefaultAudience = "https://api.carbonrelay.io/v1/"
+
+// The names of the environment variables read by `ConfigFromEnv`, following the prefix.
+const (
+	// EnvServerIdentifier is the base URL of the API server, e.g. "https://api.stormforge.io/v1/".
+	EnvServerIdentifier = "SERVER_IDENTIFIER"
+	// EnvServerIssuer is the base URL of the authorization server, required for client credentials.
+	EnvServerIssuer = "SERVER_ISSUER"
+	// EnvAuthorizationToken is a long-lived bearer token.
+	EnvAuthorizationToken = "AUTHORIZATION_TOKEN"
+	// EnvAuthorizationClientID is the OAuth2 client identifier used with the client credentials grant.
+	EnvAuthorizationClientID = "AUTHORIZATION_CLIENT_ID"
+	// EnvAuthorizationClientSecret is the OAuth2 client secret used with the client credentials grant.
+	EnvAuthorizationClientSecret = "AUTHORIZATION_CLIENT_SECRET"
+	// EnvAuthorizationAudience is the audience of tokens obtained using the client credentials grant, it defaults to
+	// `DefaultAudience`.
+	EnvAuthorizationAudience = "AUTHORIZATION_AUDIENCE"
+)
+
+// MissingEnvError is returned when the environment does not contain enough information to build a configuration.
+type MissingEnvError struct {
+	// Variables are the names of the required environment variables that are not set.
+	Variables []string
+	// Alternative is the name of a variable that can be set instead of the missing authorization variables, if any.
+	Alternative string
+}
+
+func (e *MissingEnvError) Error() string {
+	msg := "missing environment variables: " + strings.Join(e.Variables, ", ")
+	if e.Alternative != "" {
+		msg += " (or set " + e.Alternative + ")"
+	}
+	return msg
+}
+
+// EnvOption is used to control how the configuration is read from the environment.
+type EnvOption func(*envOptions)
+
+type envOptions struct {
+	prefix string
+}
+
+// WithEnvPrefix changes the prefix of the environment variable names, allowing the configurations of multiple
+// deployments to coexist in the same environment.
+func WithEnvPrefix(prefix string) EnvOption {
+	return func(o *envOptions) {
+		o.prefix = prefix
+	}
+}
+
+// ConfigFromEnv returns a configuration read from environment variables. The API server is located using
+// `EnvServerIdentifier`; requests are authorized using the static token in `EnvAuthorizationToken` if it is set,
+// otherwise using the client credentials in `EnvAuthorizationClientID` and `EnvAuthorizationClientSecret` obtained from
+// the authorization server at `EnvServerIssuer` for the `EnvAuthorizationAudience`. All names are prefixed with
+// `DefaultEnvPrefix` unless a different prefix is supplied. If any required variables are not set, a `*MissingEnvError`
+// listing them is returned.
+func ConfigFromEnv(opts ...EnvOption) (Config, error) {
+	o := envOptions{prefix: DefaultEnvPrefix}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var missing []string
+	get := func(name string, required bool) string {
+		v := strings.TrimSpace(os.Getenv(o.prefix + name))
+		if v == "" && required {
+			missing = append(missing, o.prefix+name)
+		}
+		return v
+	}
+
+	server := get(EnvServerIdentifier, true)
+	token := get(EnvAuthorizationToken, false)
+	n := len(missing)
+	cc := &ClientCredentialsConfig{
+		ClientID:     get(EnvAuthorizationClientID, token == ""),
+		ClientSecret: get(EnvAuthorizationClientSecret, token == ""),
+	}
+	issuer := get(EnvServerIssuer, token == "")
+	if len(missing) > 0 {
+		err := &MissingEnvError{Variables: missing}
+		if len(missing) > n {
+			// The client credentials are incomplete, but a token could be used instead
+			err.Alternative = o.prefix + EnvAuthorizationToken
+		}
+		return nil, err
+	}
+
+	endpoints, err := Endpoints(server, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s%s: %w", o.prefix, EnvServerIdentifier, err)
+	}
+
+	if token != "" {
+		return StaticTokenConfig(token, endpoints), nil
+	}
+
+	audience := get(EnvAuthorizationAudience, false)
+	if audience == "" {
+		audience = DefaultAudience
+	}
+
+	cc.TokenURL = strings.TrimRight(issuer, "/") + "/oauth/token"
+	cc.EndpointParams = url.Values{"audience": []string{audience}}
+	cc.EndpointURLs = endpoints
+	return cc, nil
+}
+
+var defaultClient struct {
+	once   sync.Once
+	client Client
+	err    error
+}
+
+// Default returns a client configured using `ConfigFromEnv`, building it the first time it is called. If the
+// environment is incomplete the error is returned from every call. The default client is safe for concurrent use and
+// is never closed.
+func Default() (Client, error) {
+	defaultClient.once.Do(func() {
+		cfg, err := ConfigFromEnv()
+		if err != nil {
+			defaultClient.err = fmt.Errorf("default client: %w", err)
+			return
+		}
+		defaultClient.client, defaultClient.err = NewClient(context.Background(), cfg)
+	})
+	return defaultClient.client, defaultClient.err
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	cases := []struct {
+		desc        string
+		prefix      string
+		env         map[string]string
+		expectedErr string
+		expected    Config
+	}{
+		{
+			desc:        "empty",
+			expectedErr: "missing environment variables: REDSKY_SERVER_IDENTIFIER, REDSKY_AUTHORIZATION_CLIENT_ID, REDSKY_AUTHORIZATION_CLIENT_SECRET, REDSKY_SERVER_ISSUER (or set REDSKY_AUTHORIZATION_TOKEN)",
+		},
+		{
+			desc: "static token",
+			env: map[string]string{
+				"REDSKY_SERVER_IDENTIFIER":       "https://api.example.com/v1/",
+				"REDSKY_AUTHORIZATION_TOKEN":     "secret",
+				"REDSKY_AUTHORIZATION_CLIENT_ID": "ignored",
+			},
+			expected: &staticTokenConfig{},
+		},
+		{
+			desc: "client credentials",
+			env: map[string]string{
+				"REDSKY_SERVER_IDENTIFIER":           "https://api.example.com/v1/",
+				"REDSKY_SERVER_ISSUER":               "https://auth.example.com/",
+				"REDSKY_AUTHORIZATION_CLIENT_ID":     "client",
+				"REDSKY_AUTHORIZATION_CLIENT_SECRET": "secret",
+			},
+			expected: &ClientCredentialsConfig{},
+		},
+		{
+			desc: "incomplete client credentials",
+			env: map[string]string{
+				"REDSKY_SERVER_IDENTIFIER":       "https://api.example.com/v1/",
+				"REDSKY_AUTHORIZATION_CLIENT_ID": "client",
+			},
+			expectedErr: "missing environment variables: REDSKY_AUTHORIZATION_CLIENT_SECRET, REDSKY_SERVER_ISSUER (or set REDSKY_AUTHORIZATION_TOKEN)",
+		},
+		{
+			desc: "missing server",
+			env: map[string]string{
+				"REDSKY_AUTHORIZATION_TOKEN": "secret",
+			},
+			expectedErr: "missing environment variables: REDSKY_SERVER_IDENTIFIER",
+		},
+		{
+			desc:   "prefix",
+			prefix: "STAGING_",
+			env: map[string]string{
+				"REDSKY_SERVER_IDENTIFIER":    "https://api.example.org/v1/",
+				"STAGING_SERVER_IDENTIFIER":   "https://api.example.com/v1/",
+				"STAGING_AUTHORIZATION_TOKEN": "secret",
+			},
+			expected: &staticTokenConfig{},
+		},
+		{
+			desc: "invalid server",
+			env: map[string]string{
+				"REDSKY_SERVER_IDENTIFIER":   "api.example.com",
+				"REDSKY_AUTHORIZATION_TOKEN": "secret",
+			},
+			expectedErr: `invalid REDSKY_SERVER_IDENTIFIER: invalid base URL "api.example.com": scheme must be http or https`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			for _, name := range []string{EnvServerIdentifier, EnvServerIssuer, EnvAuthorizationToken, EnvAuthorizationClientID, EnvAuthorizationClientSecret, EnvAuthorizationAudience} {
+				t.Setenv(DefaultEnvPrefix+name, "")
+			}
+			for k, v := range c.env {
+				t.Setenv(k, v)
+			}
+
+			var opts []EnvOption
+			if c.prefix != "" {
+				opts = append(opts, WithEnvPrefix(c.prefix))
+			}
+			cfg, err := ConfigFromEnv(opts...)
+			if c.expectedErr != "" {
+				assert.EqualError(t, err, c.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.IsType(t, c.expected, cfg)
+
+			ep, err := cfg.Endpoints()
+			require.NoError(t, err)
+			assert.Equal(t, "https://api.example.com/v1/experiments/foo", ep("/experiments/foo").String())
+			if cc, ok := cfg.(*ClientCredentialsConfig); ok {
+				assert.Equal(t, "https://auth.example.com/oauth/token", cc.TokenURL)
+			}
+		})
+	}
+
+	t.Run("audience", func(t *testing.T) {
+		var audiences []string
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/oauth/token", r.URL.Path)
+			require.NoError(t, r.ParseForm())
+			audiences = append(audiences, r.PostForm.Get("audience"))
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"access_token":"token","token_type":"bearer","expires_in":3600}`))
+		}))
+		defer ts.Close()
+
+		t.Setenv(DefaultEnvPrefix+EnvServerIdentifier, "https://api.example.com/v1/")
+		t.Setenv(DefaultEnvPrefix+EnvServerIssuer, ts.URL+"/")
+		t.Setenv(DefaultEnvPrefix+EnvAuthorizationToken, "")
+		t.Setenv(DefaultEnvPrefix+EnvAuthorizationClientID, "client")
+		t.Setenv(DefaultEnvPrefix+EnvAuthorizationClientSecret, "secret")
+		for _, audience := range []string{"", "https://api.example.com/"} {
+			t.Setenv(DefaultEnvPrefix+EnvAuthorizationAudience, audience)
+			cfg, err := ConfigFromEnv()
+			require.NoError(t, err)
+			src, err := cfg.(*ClientCredentialsConfig).TokenSource(context.Background())
+			require.NoError(t, err)
+			_, err = src.Token()
+			require.NoError(t, err)
+		}
+		assert.Equal(t, []string{DefaultAudience, "https://api.example.com/"}, audiences)
+	})
+
+	t.Run("error type", func(t *testing.T) {
+		t.Setenv(DefaultEnvPrefix+EnvServerIdentifier, "")
+		_, err := ConfigFromEnv()
+		var merr *MissingEnvError
+		if assert.True(t, errors.As(err, &merr)) {
+			assert.Contains(t, merr.Variables, "REDSKY_SERVER_IDENTIFIER")
+		}
+	})
+}
+
+func TestDefault(t *testing.T) {
+	var authorization string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+	}))
+	defer ts.Close()
+
+	t.Setenv(DefaultEnvPrefix+EnvServerIdentifier, ts.URL+"/v1/")
+	t.Setenv(DefaultEnvPrefix+EnvAuthorizationToken, "secret")
+
+	c, err := Default()
+	require.NoError(t, err)
+	c2, err := Default()
+	require.NoError(t, err)
+	assert.Same(t, c, c2)
+
+	req, err := http.NewRequest(http.MethodGet, c.URL("/experiments/").String(), nil)
+	require.NoError(t, err)
+	_, _, err = c.Do(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer secret", authorization)
+}
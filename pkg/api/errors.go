@@ -0,0 +1,271 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	// ErrUnauthorized matches errors for requests which lack valid authentication credentials.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrNotFound matches errors for requests against resources that do not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict matches errors for requests that conflict with the current state of a resource.
+	ErrConflict = errors.New("conflict")
+	// ErrTooManyRequests matches errors for requests rejected by server side rate limiting.
+	ErrTooManyRequests = errors.New("too many requests")
+	// ErrPreconditionFailed matches errors for conditional requests (e.g. using If-Match) whose condition was not met.
+	ErrPreconditionFailed = errors.New("precondition failed")
+	// ErrUnsupportedAPIVersion matches errors for requests asking for a representation the server cannot produce.
+	ErrUnsupportedAPIVersion = errors.New("unsupported API version")
+	// ErrUnexpectedContentType matches errors for responses whose content type cannot be decoded.
+	ErrUnexpectedContentType = errors.New("unexpected content type")
+)
+
+// Error represents an unsuccessful response from the API server.
+type Error struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// Type is a URI reference identifying the problem type, if reported by the server.
+	Type string
+	// Message is the server supplied error message, or the status text if the server did not supply one.
+	Message string
+	// Detail is a human-readable explanation specific to this occurrence of the problem.
+	Detail string
+	// Body is the raw response body.
+	Body []byte
+	// RetryAfter is the amount of time the server asked us to wait before trying again.
+	RetryAfter time.Duration
+	// RequestID is the identifier used to correlate the request with the server logs.
+	RequestID string
+}
+
+// WithErrorDecoder customizes how unsuccessful responses are converted into errors by the typed API clients, for
+// example to support servers that wrap errors using their own envelope. If the decoder returns nil, the response is
+// decoded using `NewError`. Decoders should return errors that match the errors of the status code (e.g.
+// `ErrNotFound`), the easiest way to do so is to adjust the `*Error` returned by `NewError`.
+func WithErrorDecoder(decoder func(resp *http.Response, body []byte) error) Option {
+	return func(c *httpClient) {
+		c.errorDecoder = decoder
+	}
+}
+
+// DecodeError returns an error describing the supplied response using the error decoder configured on the supplied
+// client, or `NewError` if the client does not have one (e.g. a mock client).
+func DecodeError(c Client, resp *http.Response, body []byte) error {
+	if ec, ok := c.(interface {
+		ErrorDecoder() func(*http.Response, []byte) error
+	}); ok {
+		if decoder := ec.ErrorDecoder(); decoder != nil {
+			if err := decoder(resp, body); err != nil {
+				return err
+			}
+		}
+	}
+	return newError(resp, body, ClientClock(c).Now())
+}
+
+// ErrorDecoder returns the error decoder configured on the client.
+func (c *httpClient) ErrorDecoder() func(*http.Response, []byte) error {
+	return c.errorDecoder
+}
+
+// NewError returns an error describing the supplied response, understanding both "application/problem+json" and the
+// `{"error":"..."}` JSON responses of the API server.
+func NewError(resp *http.Response, body []byte) error {
+	return newError(resp, body, time.Now())
+}
+
+// newError returns an error describing the supplied response, Retry-After dates are relative to the supplied time.
+func newError(resp *http.Response, body []byte, now time.Time) error {
+	err := &Error{
+		StatusCode: resp.StatusCode,
+		Body:       body,
+		RequestID:  RequestID(resp),
+	}
+
+	// Try to get the server supplied error message
+	switch {
+	case isMediaType(resp.Header, "application/problem+json"):
+		// https://tools.ietf.org/html/rfc7807
+		p := struct {
+			Type   string `json:"type"`
+			Title  string `json:"title"`
+			Detail string `json:"detail"`
+		}{}
+		if json.Unmarshal(body, &p) == nil {
+			err.Type = p.Type
+			err.Message = p.Title
+			err.Detail = p.Detail
+		}
+	case isMediaType(resp.Header, "application/json"):
+		msg := struct {
+			Error string `json:"error"`
+		}{}
+		if json.Unmarshal(body, &msg) == nil {
+			err.Message = msg.Error
+		}
+		// Keep the content of JSON bodies that are not in the expected format
+		if msg.Error == "" {
+			err.Detail = snippet(body)
+		}
+	default:
+		err.Detail = snippet(body)
+	}
+
+	if err.Message == "" {
+		err.Message = http.StatusText(resp.StatusCode)
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+		err.RetryAfter, _ = retryAfter(resp.Header, now)
+	}
+
+	return err
+}
+
+// Error returns the status code and message.
+func (e *Error) Error() string {
+	if e.Detail != "" {
+		return fmt.Sprintf("%d: %s (%s)", e.StatusCode, e.Message, e.Detail)
+	}
+	return fmt.Sprintf("%d: %s", e.StatusCode, e.Message)
+}
+
+// Is allows the error to match the sentinel errors for the corresponding status code.
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrTooManyRequests:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrPreconditionFailed:
+		return e.StatusCode == http.StatusPreconditionFailed
+	case ErrUnsupportedAPIVersion:
+		return e.StatusCode == http.StatusNotAcceptable
+	default:
+		return false
+	}
+}
+
+// TransportError is returned when a request fails without a response from the server (e.g. the host could not be
+// resolved or the connection was refused). It identifies the request but never includes its headers or body.
+type TransportError struct {
+	// Method is the HTTP method of the failed request.
+	Method string
+	// URL is the location of the failed request, with any password redacted.
+	URL string
+	// Err is the reason the request failed.
+	Err error
+}
+
+// newTransportError wraps an error returned by the HTTP client.
+func newTransportError(req *http.Request, err error) error {
+	return &TransportError{Method: req.Method, URL: req.URL.Redacted(), Err: err}
+}
+
+// Error returns the method and URL of the request along with the reason it failed.
+func (e *TransportError) Error() string {
+	// The HTTP client already reports the URL, only include it once
+	msg := e.Err.Error()
+	var uerr *url.Error
+	if errors.As(e.Err, &uerr) && uerr.Err != nil {
+		msg = uerr.Err.Error()
+	}
+	return fmt.Sprintf("%s %s: %s", e.Method, e.URL, msg)
+}
+
+// Unwrap returns the reason the request failed.
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+// ContentTypeError is returned when a response cannot be decoded because of its content type, for example when an
+// intermediary proxy responds with an HTML page.
+type ContentTypeError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// ContentType is the actual content type of the response.
+	ContentType string
+	// Snippet is the beginning of the response body.
+	Snippet string
+}
+
+// Error returns a description of the unexpected content.
+func (e *ContentTypeError) Error() string {
+	return fmt.Sprintf("%s %q (%d): %s", ErrUnexpectedContentType, e.ContentType, e.StatusCode, e.Snippet)
+}
+
+// Is allows the error to match `ErrUnexpectedContentType`.
+func (e *ContentTypeError) Is(target error) bool {
+	return target == ErrUnexpectedContentType
+}
+
+// CheckContentType returns a `*ContentTypeError` if a response with a body does not have one of the supplied media
+// types (parameters such as the charset are ignored). If no media types are supplied, "application/json" is expected.
+func CheckContentType(resp *http.Response, body []byte, mediaTypes ...string) error {
+	if len(body) == 0 {
+		return nil
+	}
+	if len(mediaTypes) == 0 {
+		mediaTypes = []string{"application/json"}
+	}
+	for _, mt := range mediaTypes {
+		if isMediaType(resp.Header, mt) {
+			return nil
+		}
+	}
+	return &ContentTypeError{
+		StatusCode:  resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Snippet:     snippet(body),
+	}
+}
+
+// isMediaType checks the content type header against the specified media type, ignoring any parameters.
+func isMediaType(header http.Header, mediaType string) bool {
+	mt, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	return err == nil && mt == mediaType
+}
+
+// snippet returns a bounded, single line representation of a (presumably textual) response body.
+func snippet(body []byte) string {
+	const maxLen = 256
+	s := strings.Join(strings.Fields(string(body)), " ")
+	if len(s) > maxLen {
+		// Back up to the start of a rune so the result remains valid UTF-8
+		n := maxLen
+		for n > 0 && !utf8.RuneStart(s[n]) {
+			n--
+		}
+		s = s[:n] + "..."
+	}
+	return s
+}
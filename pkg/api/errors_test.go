@@ -0,0 +1,242 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewError(t *testing.T) {
+	cases := []struct {
+		desc            string
+		statusCode      int
+		contentType     string
+		body            string
+		expectedMessage string
+		expectedIs      error
+	}{
+		{
+			desc:            "not found",
+			statusCode:      http.StatusNotFound,
+			expectedMessage: "404: Not Found",
+			expectedIs:      ErrNotFound,
+		},
+		{
+			desc:            "server message",
+			statusCode:      http.StatusConflict,
+			contentType:     "application/json; charset=utf-8",
+			body:            `{"error":"experiment exists"}`,
+			expectedMessage: "409: experiment exists",
+			expectedIs:      ErrConflict,
+		},
+		{
+			desc:            "other json",
+			statusCode:      http.StatusBadRequest,
+			contentType:     "application/json",
+			body:            `{"message":"budget must be positive"}`,
+			expectedMessage: `400: Bad Request ({"message":"budget must be positive"})`,
+		},
+		{
+			desc:            "invalid json",
+			statusCode:      http.StatusBadGateway,
+			contentType:     "application/json",
+			body:            "upstream\n failed",
+			expectedMessage: "502: Bad Gateway (upstream failed)",
+		},
+		{
+			desc:            "unauthorized",
+			statusCode:      http.StatusUnauthorized,
+			contentType:     "text/plain",
+			body:            "missing\n token",
+			expectedMessage: "401: Unauthorized (missing token)",
+			expectedIs:      ErrUnauthorized,
+		},
+		{
+			desc:            "problem",
+			statusCode:      http.StatusForbidden,
+			contentType:     "application/problem+json",
+			body:            `{"type":"https://example.com/probs/scope","title":"insufficient scope","status":403,"detail":"request lacks experiments:write"}`,
+			expectedMessage: "403: insufficient scope (request lacks experiments:write)",
+		},
+		{
+			desc:            "too many requests",
+			statusCode:      http.StatusTooManyRequests,
+			expectedMessage: "429: Too Many Requests",
+			expectedIs:      ErrTooManyRequests,
+		},
+		{
+			desc:            "precondition failed",
+			statusCode:      http.StatusPreconditionFailed,
+			expectedMessage: "412: Precondition Failed",
+			expectedIs:      ErrPreconditionFailed,
+		},
+		{
+			desc:            "not acceptable",
+			statusCode:      http.StatusNotAcceptable,
+			expectedMessage: "406: Not Acceptable",
+			expectedIs:      ErrUnsupportedAPIVersion,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			resp := &http.Response{StatusCode: c.statusCode, Header: http.Header{}}
+			if c.contentType != "" {
+				resp.Header.Set("Content-Type", c.contentType)
+			}
+
+			err := NewError(resp, []byte(c.body))
+			assert.EqualError(t, err, c.expectedMessage)
+			if c.expectedIs != nil {
+				assert.True(t, errors.Is(err, c.expectedIs))
+				assert.False(t, errors.Is(err, errors.New(c.expectedIs.Error())))
+			}
+
+			var apiErr *Error
+			if assert.True(t, errors.As(err, &apiErr)) {
+				assert.Equal(t, c.statusCode, apiErr.StatusCode)
+				assert.Equal(t, c.body, string(apiErr.Body))
+			}
+		})
+	}
+}
+
+func TestWithErrorDecoder(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		if r.URL.Path == "/envelope" {
+			_, _ = w.Write([]byte(`{"error":{"code":"E42","message":"no such thing"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"error":"plain"}`))
+	}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts, WithErrorDecoder(func(resp *http.Response, body []byte) error {
+		var envelope struct {
+			Error struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(body, &envelope) != nil || envelope.Error.Code == "" {
+			return nil
+		}
+		err := NewError(resp, body).(*Error)
+		err.Type, err.Message = envelope.Error.Code, envelope.Error.Message
+		return err
+	}))
+	get := func(c Client, path string) error {
+		req, err := http.NewRequest(http.MethodGet, c.URL(path).String(), nil)
+		require.NoError(t, err)
+		_, err = DoJSON[map[string]string](context.Background(), c, req)
+		return err
+	}
+
+	var apiErr *Error
+	err := get(client, "/envelope")
+	assert.True(t, errors.Is(err, ErrNotFound))
+	if assert.True(t, errors.As(err, &apiErr)) {
+		assert.Equal(t, "E42", apiErr.Type)
+		assert.Equal(t, "no such thing", apiErr.Message)
+	}
+
+	// A nil error falls back to the default decoder
+	err = get(client, "/plain")
+	if assert.True(t, errors.As(err, &apiErr)) {
+		assert.Equal(t, "plain", apiErr.Message)
+	}
+
+	// Copies can replace the decoder
+	err = get(With(client, WithErrorDecoder(nil)), "/envelope")
+	if assert.True(t, errors.As(err, &apiErr)) {
+		assert.Empty(t, apiErr.Type)
+	}
+}
+
+func TestDecodeError_Clock(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	resp := &http.Response{
+		StatusCode: http.StatusServiceUnavailable,
+		Header:     http.Header{"Retry-After": {now.Add(time.Minute).Format(http.TimeFormat)}},
+	}
+
+	// Retry-After dates are relative to the clock of the client
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	client := newTestClient(t, ts, WithClock(&sleepRecorder{now: now}))
+
+	var apiErr *Error
+	if assert.True(t, errors.As(DecodeError(client, resp, nil), &apiErr)) {
+		assert.Equal(t, time.Minute, apiErr.RetryAfter)
+	}
+}
+
+func TestTransportError(t *testing.T) {
+	ts := httptest.NewServer(slowHandler(time.Second))
+	client := newTestClient(t, ts)
+	u := client.URL("/experiments/x/trials").String()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer secret")
+	_, _, err = client.Do(ctx, req)
+
+	var terr *TransportError
+	if assert.True(t, errors.As(err, &terr)) {
+		assert.Equal(t, http.MethodGet, terr.Method)
+		assert.Equal(t, u, terr.URL)
+	}
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.NotContains(t, err.Error(), "secret")
+
+	ts.Close()
+	req, err = http.NewRequest(http.MethodPost, u, strings.NewReader(`{"secret":true}`))
+	require.NoError(t, err)
+	_, _, err = client.Do(context.Background(), req)
+
+	var opErr *net.OpError
+	assert.True(t, errors.As(err, &opErr))
+	assert.True(t, strings.HasPrefix(err.Error(), "POST "+u+": dial tcp"), err.Error())
+	assert.NotContains(t, err.Error(), "secret")
+}
+
+func TestSnippet(t *testing.T) {
+	assert.Equal(t, "a b c", snippet([]byte(" a\n b\tc ")))
+
+	long := snippet([]byte(strings.Repeat("x", 300)))
+	assert.Equal(t, strings.Repeat("x", 256)+"...", long)
+
+	// A multi-byte rune straddling the limit is dropped instead of being split
+	s := snippet([]byte(strings.Repeat("x", 255) + strings.Repeat("é", 10)))
+	assert.True(t, utf8.ValidString(s))
+	assert.Equal(t, strings.Repeat("x", 255)+"...", s)
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiments
+
+import (
+	"context"
+	"sync"
+
+	"github.com/thestormforge/optimize-go/pkg/api"
+	"github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+)
+
+// DefaultBatchConcurrency is the number of concurrent requests used for batch operations unless otherwise configured.
+const DefaultBatchConcurrency = 4
+
+// WithBatchConcurrency limits the number of concurrent requests made by batch operations such as `CreateTrials`.
+func WithBatchConcurrency(n int) Option {
+	return func(h *httpAPI) {
+		h.batchConcurrency = n
+	}
+}
+
+func (h *httpAPI) CreateTrials(ctx context.Context, experiment string, asms []v1alpha1.TrialAssignments) ([]v1alpha1.TrialAssignments, error) {
+	ctx, cancel := h.withTimeout(ctx, "CreateTrials")
+	defer cancel()
+
+	results := make([]v1alpha1.TrialAssignments, len(asms))
+	errs := make([]error, len(asms))
+
+	limit := h.batchConcurrency
+	if limit <= 0 {
+		limit = DefaultBatchConcurrency
+	}
+
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i := range asms {
+		select {
+		case <-ctx.Done():
+			errs[i] = ctx.Err()
+			continue
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer func() { <-sem; wg.Done() }()
+			results[i], errs[i] = h.CreateTrial(ctx, experiment, asms[i])
+		}(i)
+	}
+	wg.Wait()
+
+	merr := &api.MultiError{Total: len(asms)}
+	for i, err := range errs {
+		merr.Add(i, "", err)
+	}
+	return results, merr.ErrorOrNil()
+}
@@ -0,0 +1,359 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package experiments provides a typed client for the experiments API.
+package experiments
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/thestormforge/optimize-go/pkg/api"
+	"github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+)
+
+const endpointExperiments = "/experiments/"
+
+// API is a typed client for the experiments API. All unsuccessful responses are returned as an `*api.Error`.
+type API interface {
+	// Client returns the underlying client for making requests not covered by this API.
+	Client() api.Client
+	// CreateExperiment creates or replaces the named experiment.
+	CreateExperiment(ctx context.Context, name string, exp v1alpha1.Experiment) (v1alpha1.Experiment, error)
+	// UpdateExperiment replaces the named experiment. If the ETag is not empty, the update only succeeds if the
+	// experiment has not been modified since it was retrieved, otherwise an error matching `api.ErrPreconditionFailed`
+	// is returned. The ETag of the updated experiment is returned on the result.
+	UpdateExperiment(ctx context.Context, name string, exp v1alpha1.Experiment, etag string) (v1alpha1.Experiment, error)
+	// PatchExperiment applies a JSON merge patch (RFC 7386) to the named experiment, returning the updated experiment.
+	// The patch is typically a map, use nil values to clear fields (e.g. to remove a label).
+	PatchExperiment(ctx context.Context, name string, patch any) (v1alpha1.Experiment, error)
+	// GetExperiment returns the named experiment. Use `TypedParameters` and `Objectives` on the result to inspect the
+	// parameter space and `v1alpha1.ValidateAssignments` to check assignments before creating a trial.
+	GetExperiment(ctx context.Context, name string) (v1alpha1.Experiment, error)
+	// DeleteExperiment deletes the named experiment.
+	DeleteExperiment(ctx context.Context, name string) error
+	// ListExperiments returns a single page of experiments matching the query.
+	ListExperiments(ctx context.Context, q *v1alpha1.ExperimentListQuery) (v1alpha1.ExperimentList, error)
+
+	// NextTrial returns the suggested assignments for the next trial of the named experiment. If the experiment will
+	// not produce any more trials, `ErrExperimentFinished` is returned; if a trial is not ready yet (indicated by a
+	// "503 Service Unavailable" or a "204 No Content" with a Retry-After header), `ErrTrialUnavailable` is returned.
+	NextTrial(ctx context.Context, experiment string) (v1alpha1.TrialAssignments, error)
+	// WaitForTrial polls for the next trial of the named experiment until one is available, the experiment finishes
+	// or the context is done. The server's Retry-After is used in place of the poll interval when present.
+	WaitForTrial(ctx context.Context, experiment string, pollInterval time.Duration) (v1alpha1.TrialAssignments, error)
+	// CreateTrial creates a new trial of the named experiment using explicit assignments.
+	CreateTrial(ctx context.Context, experiment string, asm v1alpha1.TrialAssignments) (v1alpha1.TrialAssignments, error)
+	// CreateTrials creates multiple trials of the named experiment, returning the created trials in the same order as
+	// the supplied assignments. Trials are created concurrently (see `WithBatchConcurrency`); if any trial cannot be
+	// created an `*api.MultiError` identifying the failures by index is returned along with the trials that were created.
+	CreateTrials(ctx context.Context, experiment string, asms []v1alpha1.TrialAssignments) ([]v1alpha1.TrialAssignments, error)
+	// ListTrials returns a single page of trials of the named experiment matching the query. Use the query's label
+	// selector to only return trials which have all of the specified labels.
+	ListTrials(ctx context.Context, experiment string, q *v1alpha1.TrialListQuery) (v1alpha1.TrialList, error)
+	// WatchTrials subscribes to changes in the trials of the named experiment using server-sent events. Events are
+	// delivered until the context is done or the server ends the stream, at which point the channel is closed; if
+	// the connection is lost, the watch is resumed using the identifier of the last event received. The channel is
+	// buffered, if the consumer falls behind the stream stops being read (no events are dropped).
+	WatchTrials(ctx context.Context, experiment string) (<-chan TrialEvent, error)
+	// ReportTrialValues reports the observed values (or failure) of the trial at the specified location. Use
+	// `FailedTrialValues` to report a trial which ran but could not produce values.
+	ReportTrialValues(ctx context.Context, trialURL string, vls v1alpha1.TrialValues) error
+	// GetTrialMetrics returns the raw measurements of each metric taken during the trial at the specified location,
+	// following the pagination links of long series. If the server does not expose the measurements, an error
+	// matching `api.ErrNotFound` is returned.
+	GetTrialMetrics(ctx context.Context, trialURL string) ([]MetricSeries, error)
+	// AbandonTrial tells the server the trial at the specified location will never complete, using an optional
+	// human-readable reason. The returned trial reflects the server's view of the trial after the transition. If the
+	// trial already finished, an error matching both `ErrTrialAlreadyCompleted` and `api.ErrConflict` is returned.
+	AbandonTrial(ctx context.Context, trialURL string, reason string) (v1alpha1.TrialItem, error)
+
+	// Export writes every experiment, each followed by its trials, to the supplied writer as newline-delimited JSON
+	// (see `ExportRecord`) using the client codec. Experiments are written one at a time ordered by name, each
+	// followed by its trials ordered by number, so exports of the same data are identical; the output is flushed
+	// after each experiment.
+	Export(ctx context.Context, w io.Writer) error
+	// Import recreates the experiments and trials read from the output of `Export`. Values are reported for trials
+	// which had completed or failed. A record which cannot be imported does not stop the import, the failures are
+	// returned as an `*api.MultiError` indexed by the position of the record in the input.
+	Import(ctx context.Context, r io.Reader) error
+}
+
+// NewAPI returns a new experiments API using the supplied client.
+func NewAPI(c api.Client, opts ...Option) API {
+	h := &httpAPI{client: c}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Option is used to customize the behavior of the experiments API.
+type Option func(*httpAPI)
+
+// WithAwaitOperations makes operations which respond with "202 Accepted" wait for the asynchronous operation at the
+// returned Location to complete, polling at the specified interval (see `api.PollOperation`).
+func WithAwaitOperations(pollInterval time.Duration) Option {
+	return func(h *httpAPI) {
+		h.awaitOperations = true
+		h.pollInterval = pollInterval
+	}
+}
+
+// WithContentTypes sets the media types of responses which are decoded as JSON, by default only "application/json"
+// is accepted. Responses with any other content type fail with an error matching `api.ErrUnexpectedContentType`.
+func WithContentTypes(mediaTypes ...string) Option {
+	return func(h *httpAPI) {
+		h.contentTypes = mediaTypes
+	}
+}
+
+// WithOperationTimeout sets a default timeout for the named method of the API (e.g. "NextTrial"). The timeout is
+// not applied if the caller's context already has an earlier deadline. Each method is bounded only by its own timeout,
+// except that the individual polls of "WaitForTrial" are also bounded by the timeout of "NextTrial". Timeouts do not
+// apply to `WatchTrials`.
+func WithOperationTimeout(method string, timeout time.Duration) Option {
+	return func(h *httpAPI) {
+		if h.operationTimeouts == nil {
+			h.operationTimeouts = make(map[string]time.Duration)
+		}
+		h.operationTimeouts[method] = timeout
+	}
+}
+
+type httpAPI struct {
+	client api.Client
+
+	awaitOperations   bool
+	pollInterval      time.Duration
+	batchConcurrency  int
+	operationTimeouts map[string]time.Duration
+	contentTypes      []string
+}
+
+// withTimeout returns a context bounded by the timeout configured for the named method. The returned cancel function
+// must always be called.
+func (h *httpAPI) withTimeout(ctx context.Context, method string) (context.Context, context.CancelFunc) {
+	timeout := h.operationTimeouts[method]
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= timeout {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+func (h *httpAPI) Client() api.Client {
+	return h.client
+}
+
+func (h *httpAPI) CreateExperiment(ctx context.Context, name string, exp v1alpha1.Experiment) (v1alpha1.Experiment, error) {
+	ctx, cancel := h.withTimeout(ctx, "CreateExperiment")
+	defer cancel()
+
+	return h.updateExperiment(ctx, name, exp, "")
+}
+
+func (h *httpAPI) UpdateExperiment(ctx context.Context, name string, exp v1alpha1.Experiment, etag string) (v1alpha1.Experiment, error) {
+	ctx, cancel := h.withTimeout(ctx, "UpdateExperiment")
+	defer cancel()
+
+	return h.updateExperiment(ctx, name, exp, etag)
+}
+
+// updateExperiment creates or replaces an experiment, it is shared by the create and update methods so each is
+// bounded only by its own timeout.
+func (h *httpAPI) updateExperiment(ctx context.Context, name string, exp v1alpha1.Experiment, etag string) (v1alpha1.Experiment, error) {
+	e := v1alpha1.Experiment{}
+
+	req, err := h.newJSONRequest(http.MethodPut, h.experimentURL(name), exp)
+	if err != nil {
+		return e, err
+	}
+	if etag != "" {
+		req.Header.Set("If-Match", etag)
+	}
+
+	resp, err := h.do(ctx, req, &e)
+	if err != nil {
+		return e, err
+	}
+
+	unmarshalExperimentMeta(resp.Header, &e.ExperimentMeta)
+	return e, nil
+}
+
+func (h *httpAPI) PatchExperiment(ctx context.Context, name string, patch any) (v1alpha1.Experiment, error) {
+	ctx, cancel := h.withTimeout(ctx, "PatchExperiment")
+	defer cancel()
+
+	e := v1alpha1.Experiment{}
+
+	req, err := h.newJSONRequest(http.MethodPatch, h.experimentURL(name), patch)
+	if err != nil {
+		return e, err
+	}
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+
+	resp, err := h.do(ctx, req, &e)
+	if err != nil {
+		return e, err
+	}
+
+	unmarshalExperimentMeta(resp.Header, &e.ExperimentMeta)
+	return e, nil
+}
+
+func (h *httpAPI) GetExperiment(ctx context.Context, name string) (v1alpha1.Experiment, error) {
+	ctx, cancel := h.withTimeout(ctx, "GetExperiment")
+	defer cancel()
+
+	e := v1alpha1.Experiment{}
+
+	req, err := http.NewRequest(http.MethodGet, h.experimentURL(name), nil)
+	if err != nil {
+		return e, err
+	}
+
+	resp, err := h.do(ctx, req, &e)
+	if err != nil {
+		return e, err
+	}
+
+	unmarshalExperimentMeta(resp.Header, &e.ExperimentMeta)
+	return e, nil
+}
+
+func (h *httpAPI) DeleteExperiment(ctx context.Context, name string) error {
+	ctx, cancel := h.withTimeout(ctx, "DeleteExperiment")
+	defer cancel()
+
+	req, err := http.NewRequest(http.MethodDelete, h.experimentURL(name), nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = h.do(ctx, req, nil)
+	return err
+}
+
+func (h *httpAPI) ListExperiments(ctx context.Context, q *v1alpha1.ExperimentListQuery) (v1alpha1.ExperimentList, error) {
+	ctx, cancel := h.withTimeout(ctx, "ListExperiments")
+	defer cancel()
+
+	lst := v1alpha1.ExperimentList{}
+
+	if q != nil {
+		if err := q.ListOptions.Validate(); err != nil {
+			return lst, err
+		}
+	}
+
+	query, err := url.ParseQuery(q.Encode())
+	if err != nil {
+		return lst, err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, api.URLWithQuery(h.client, endpointExperiments, query).String(), nil)
+	if err != nil {
+		return lst, err
+	}
+
+	resp, err := h.do(ctx, req, &lst)
+	if err != nil {
+		return lst, err
+	}
+
+	v1alpha1.UnmarshalMeta(resp.Header, &lst.ExperimentListMeta)
+	if lst.Next == "" && lst.NextPageToken != "" {
+		lst.Next = v1alpha1.PageURL(req.URL, lst.NextPageToken)
+	}
+	for i := range lst.Experiments {
+		unmarshalExperimentMeta(http.Header(lst.Experiments[i].Metadata), &lst.Experiments[i].ExperimentMeta)
+	}
+	return lst, nil
+}
+
+// unmarshalExperimentMeta extracts the experiment metadata from the response headers.
+func unmarshalExperimentMeta(header http.Header, meta *v1alpha1.ExperimentMeta) {
+	v1alpha1.UnmarshalMeta(header, meta)
+	meta.ETag = header.Get("ETag")
+	meta.APIVersion = api.APIVersion(header)
+}
+
+// unmarshalTrialMeta extracts the trial metadata from the response headers.
+func unmarshalTrialMeta(header http.Header, meta *v1alpha1.TrialMeta) {
+	v1alpha1.UnmarshalMeta(header, meta)
+	meta.APIVersion = api.APIVersion(header)
+}
+
+// experimentURL returns the location of the named experiment.
+func (h *httpAPI) experimentURL(name string) string {
+	return h.client.URL(endpointExperiments + url.PathEscape(name)).String()
+}
+
+// do performs the request, decoding a successful JSON response into the supplied value (if it is not nil).
+func (h *httpAPI) do(ctx context.Context, req *http.Request, v interface{}) (*http.Response, error) {
+	req.Header.Set("Accept", "application/json")
+
+	resp, body, err := h.client.Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp, api.DecodeError(h.client, resp, body)
+	}
+
+	if resp.StatusCode == http.StatusAccepted && h.awaitOperations {
+		if loc, err := req.URL.Parse(resp.Header.Get("Location")); err == nil && loc.String() != req.URL.String() {
+			resp, body, err = api.PollOperation(ctx, h.client, loc.String(), h.pollInterval)
+			if err != nil {
+				return resp, err
+			}
+		}
+	}
+
+	if v != nil && resp.StatusCode != http.StatusNoContent && len(body) > 0 {
+		if err := api.CheckContentType(resp, body, h.contentTypes...); err != nil {
+			return resp, err
+		}
+		if err := api.ClientCodec(h.client).Unmarshal(body, v); err != nil {
+			return resp, err
+		}
+	}
+	return resp, nil
+}
+
+// newJSONRequest returns a new HTTP request with a JSON payload.
+func (h *httpAPI) newJSONRequest(method, u string, body interface{}) (*http.Request, error) {
+	b, err := api.ClientCodec(h.client).Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(method, u, bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
@@ -0,0 +1,288 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiments
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thestormforge/optimize-go/pkg/api"
+	"github.com/thestormforge/optimize-go/pkg/api/apitest"
+	"github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+)
+
+func TestAPI_CreateExperiment(t *testing.T) {
+	resp := apitest.JSONResponse(http.StatusCreated, `{"displayName":"my exp","budget":10}`)
+	resp.Header.Add("Link", `<`+apitest.MockBaseURL+`/experiments/my-exp/trials/>; rel="https://carbonrelay.com/rel/trials"`)
+	m := apitest.NewMock().On(http.MethodPut, "/experiments/my-exp", resp)
+
+	exp, err := NewAPI(m).CreateExperiment(context.Background(), "my-exp", v1alpha1.Experiment{DisplayName: "my exp", Budget: 10})
+	require.NoError(t, err)
+	assert.Equal(t, "my exp", exp.DisplayName)
+	assert.Equal(t, apitest.MockBaseURL+"/experiments/my-exp/trials/", exp.TrialsURL)
+
+	if reqs := m.Requests(); assert.Len(t, reqs, 1) {
+		assert.Equal(t, "application/json", reqs[0].Header.Get("Content-Type"))
+		assert.Equal(t, "application/json", reqs[0].Header.Get("Accept"))
+		assert.JSONEq(t, `{"displayName":"my exp","budget":10,"metrics":null,"parameters":null}`, string(reqs[0].Body))
+	}
+}
+
+func TestAPI_GetExperiment(t *testing.T) {
+	m := apitest.NewMock().
+		On(http.MethodGet, "/experiments/found", apitest.JSONResponse(http.StatusOK, `{"displayName":"found"}`)).
+		On(http.MethodGet, "/experiments/missing", apitest.JSONResponse(http.StatusNotFound, `{"error":"experiment not found"}`))
+	a := NewAPI(m)
+
+	exp, err := a.GetExperiment(context.Background(), "found")
+	require.NoError(t, err)
+	assert.Equal(t, "found", exp.DisplayName)
+
+	_, err = a.GetExperiment(context.Background(), "missing")
+	var apiErr *api.Error
+	if assert.True(t, errors.As(err, &apiErr)) {
+		assert.Equal(t, "experiment not found", apiErr.Message)
+	}
+	assert.True(t, errors.Is(err, api.ErrNotFound))
+}
+
+func TestAPI_DeleteExperiment(t *testing.T) {
+	m := apitest.NewMock().On(http.MethodDelete, "/experiments/my-exp", apitest.Response{StatusCode: http.StatusNoContent})
+
+	err := NewAPI(m).DeleteExperiment(context.Background(), "my-exp")
+	assert.NoError(t, err)
+}
+
+func TestAPI_ListExperiments(t *testing.T) {
+	resp := apitest.JSONResponse(http.StatusOK, `{"experiments":[{"displayName":"a","_metadata":{"Link":"<`+apitest.MockBaseURL+`/experiments/a>; rel=\"self\""}}]}`)
+	resp.Header.Add("Link", `<`+apitest.MockBaseURL+`/experiments/?offset=1>; rel="next"`)
+	m := apitest.NewMock().On(http.MethodGet, "/experiments/", resp)
+
+	lst, err := NewAPI(m).ListExperiments(context.Background(), &v1alpha1.ExperimentListQuery{Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, apitest.MockBaseURL+"/experiments/?offset=1", lst.Next)
+	if assert.Len(t, lst.Experiments, 1) {
+		assert.Equal(t, "a", lst.Experiments[0].Name())
+	}
+
+	if reqs := m.Requests(); assert.Len(t, reqs, 1) {
+		assert.Equal(t, "1", reqs[0].URL.Query().Get("limit"))
+	}
+}
+
+func TestAPI_ListExperiments_OrderBy(t *testing.T) {
+	m := apitest.NewMock().On(http.MethodGet, "/experiments/", apitest.JSONResponse(http.StatusOK, `{"experiments":[{"displayName":"a"}]}`))
+	a := NewAPI(m)
+
+	lst, err := a.ListExperiments(context.Background(), &v1alpha1.ExperimentListQuery{ListOptions: v1alpha1.ListOptions{
+		OrderBy: []v1alpha1.OrderBy{{Field: "createdAt", Descending: true}, {Field: "name"}},
+		Fields:  []string{"displayName"},
+	}})
+	require.NoError(t, err)
+	if assert.Len(t, lst.Experiments, 1) {
+		assert.Equal(t, "a", lst.Experiments[0].DisplayName)
+		assert.Nil(t, lst.Experiments[0].Parameters)
+	}
+	if reqs := m.Requests(); assert.Len(t, reqs, 1) {
+		assert.Equal(t, "-createdAt,name", reqs[0].URL.Query().Get("sort"))
+		assert.Equal(t, "displayName", reqs[0].URL.Query().Get("fields"))
+	}
+
+	_, err = a.ListExperiments(context.Background(), &v1alpha1.ExperimentListQuery{ListOptions: v1alpha1.ListOptions{
+		OrderBy: []v1alpha1.OrderBy{{Field: "color"}},
+	}})
+	assert.True(t, errors.Is(err, v1alpha1.ErrInvalidOrderBy))
+	assert.Len(t, m.Requests(), 1)
+}
+
+func TestAPI_UpdateExperiment(t *testing.T) {
+	updated := apitest.JSONResponse(http.StatusOK, `{"displayName":"mine"}`)
+	updated.Header.Set("ETag", `"v2"`)
+	m := apitest.NewMock().On(http.MethodPut, "/experiments/my-exp",
+		updated,
+		apitest.JSONResponse(http.StatusPreconditionFailed, `{"error":"experiment was modified"}`),
+	)
+	a := NewAPI(m)
+
+	// The first writer wins and gets the new ETag
+	exp, err := a.UpdateExperiment(context.Background(), "my-exp", v1alpha1.Experiment{DisplayName: "mine"}, `"v1"`)
+	require.NoError(t, err)
+	assert.Equal(t, `"v2"`, exp.ETag)
+
+	// The second writer used a stale ETag
+	_, err = a.UpdateExperiment(context.Background(), "my-exp", v1alpha1.Experiment{DisplayName: "theirs"}, `"v1"`)
+	assert.True(t, errors.Is(err, api.ErrPreconditionFailed))
+
+	if reqs := m.Requests(); assert.Len(t, reqs, 2) {
+		assert.Equal(t, `"v1"`, reqs[0].Header.Get("If-Match"))
+		assert.Equal(t, `"v1"`, reqs[1].Header.Get("If-Match"))
+	}
+}
+
+func TestWithAwaitOperations(t *testing.T) {
+	m := apitest.NewMock().
+		On(http.MethodDelete, "/experiments/my-exp", apitest.Response{StatusCode: http.StatusAccepted, Header: http.Header{"Location": {"/operations/1"}}}).
+		On(http.MethodGet, "/operations/1",
+			apitest.JSONResponse(http.StatusOK, `{"status":"running"}`),
+			apitest.JSONResponse(http.StatusOK, `{"status":"failed","error":"experiment is locked"}`),
+		)
+
+	err := NewAPI(m, WithAwaitOperations(time.Millisecond)).DeleteExperiment(context.Background(), "my-exp")
+	var opErr *api.OperationError
+	if assert.True(t, errors.As(err, &opErr)) {
+		assert.Equal(t, apitest.MockBaseURL+"/operations/1", opErr.Location)
+		assert.Equal(t, "experiment is locked", opErr.Message)
+	}
+	assert.Len(t, m.Requests(), 3)
+}
+
+func TestAPI_PatchExperiment(t *testing.T) {
+	m := apitest.NewMock().On(http.MethodPatch, "/experiments/my-exp",
+		apitest.JSONResponse(http.StatusOK, `{"displayName":"my exp","budget":20,"labels":{"team":"perf"}}`))
+
+	exp, err := NewAPI(m).PatchExperiment(context.Background(), "my-exp", map[string]any{
+		"budget": 20,
+		"labels": map[string]any{"owner": nil},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(20), exp.Budget)
+	assert.Equal(t, "my exp", exp.DisplayName)
+
+	if reqs := m.Requests(); assert.Len(t, reqs, 1) {
+		assert.Equal(t, "application/merge-patch+json", reqs[0].Header.Get("Content-Type"))
+		assert.JSONEq(t, `{"budget":20,"labels":{"owner":null}}`, string(reqs[0].Body))
+	}
+}
+
+func TestWithOperationTimeout(t *testing.T) {
+	m := apitest.NewMock().On(http.MethodPost, "/experiments/my-exp/nextTrial",
+		apitest.Response{StatusCode: http.StatusOK, Delay: time.Second})
+
+	cases := []struct {
+		desc     string
+		method   string
+		timeout  time.Duration
+		deadline time.Duration
+		expected time.Duration
+	}{
+		{desc: "operation timeout", method: "NextTrial", timeout: 20 * time.Millisecond, deadline: time.Minute, expected: 20 * time.Millisecond},
+		{desc: "shorter caller deadline", method: "NextTrial", timeout: time.Minute, deadline: 20 * time.Millisecond, expected: 20 * time.Millisecond},
+		{desc: "other operation", method: "GetExperiment", timeout: 20 * time.Millisecond, deadline: 100 * time.Millisecond, expected: 100 * time.Millisecond},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), c.deadline)
+			defer cancel()
+
+			start := time.Now()
+			_, err := NewAPI(m, WithOperationTimeout(c.method, c.timeout)).NextTrial(ctx, "my-exp")
+			elapsed := time.Since(start)
+
+			assert.True(t, errors.Is(err, context.DeadlineExceeded))
+			assert.GreaterOrEqual(t, elapsed, c.expected)
+			assert.Less(t, elapsed, c.expected+500*time.Millisecond)
+		})
+	}
+}
+
+func TestWithOperationTimeout_Methods(t *testing.T) {
+	t.Run("wait for trial polls", func(t *testing.T) {
+		m := apitest.NewMock().On(http.MethodPost, "/experiments/my-exp/nextTrial",
+			apitest.Response{StatusCode: http.StatusOK, Delay: time.Second})
+
+		start := time.Now()
+		_, err := NewAPI(m, WithOperationTimeout("NextTrial", 20*time.Millisecond)).WaitForTrial(context.Background(), "my-exp", time.Hour)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		assert.Less(t, int64(time.Since(start)), int64(500*time.Millisecond))
+	})
+
+	t.Run("create experiment", func(t *testing.T) {
+		slow := apitest.JSONResponse(http.StatusOK, `{}`)
+		slow.Delay = 100 * time.Millisecond
+		m := apitest.NewMock().On(http.MethodPut, "/experiments/my-exp", slow)
+
+		// The update timeout does not apply to a create
+		_, err := NewAPI(m, WithOperationTimeout("UpdateExperiment", 20*time.Millisecond)).CreateExperiment(context.Background(), "my-exp", v1alpha1.Experiment{})
+		assert.NoError(t, err)
+	})
+}
+
+func TestWithContentTypes(t *testing.T) {
+	html := apitest.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"text/html"}},
+		Body:       []byte("<html>Sign in</html>"),
+	}
+	vendor := apitest.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/vnd.stormforge+json"}},
+		Body:       []byte(`{"displayName":"vendor"}`),
+	}
+	m := apitest.NewMock().
+		On(http.MethodGet, "/experiments/html", html).
+		On(http.MethodGet, "/experiments/vendor", vendor)
+
+	_, err := NewAPI(m).GetExperiment(context.Background(), "html")
+	assert.True(t, errors.Is(err, api.ErrUnexpectedContentType))
+	assert.Contains(t, err.Error(), "<html>Sign in</html>")
+
+	_, err = NewAPI(m).GetExperiment(context.Background(), "vendor")
+	assert.True(t, errors.Is(err, api.ErrUnexpectedContentType))
+
+	exp, err := NewAPI(m, WithContentTypes("application/json", "application/vnd.stormforge+json")).GetExperiment(context.Background(), "vendor")
+	require.NoError(t, err)
+	assert.Equal(t, "vendor", exp.DisplayName)
+}
+
+func TestAPI_APIVersion(t *testing.T) {
+	resp := apitest.JSONResponse(http.StatusOK, `{"displayName":"my exp"}`)
+	resp.Header.Set("Content-Type", "application/json; version=2")
+	m := apitest.NewMock().On(http.MethodGet, "/experiments/my-exp", resp)
+
+	exp, err := NewAPI(m).GetExperiment(context.Background(), "my-exp")
+	require.NoError(t, err)
+	assert.Equal(t, "2", exp.APIVersion)
+}
+
+func TestAPI_Organization(t *testing.T) {
+	var paths, orgs []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths, orgs = append(paths, r.URL.Path), append(orgs, r.Header.Get(api.HeaderOrganizationID))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"displayName":"my exp"}`))
+	}))
+	defer ts.Close()
+
+	endpoints, err := api.Endpoints(ts.URL+"/v1/", nil)
+	require.NoError(t, err)
+	for _, mode := range []api.OrganizationMode{api.OrganizationHeader, api.OrganizationPath} {
+		client, err := api.NewClient(context.Background(), api.StaticTokenConfig("", endpoints),
+			api.WithOrganization("acme"), api.WithOrganizationMode(mode))
+		require.NoError(t, err)
+
+		_, err = NewAPI(client).GetExperiment(context.Background(), "my-exp")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []string{"/v1/experiments/my-exp", "/v1/orgs/acme/experiments/my-exp"}, paths)
+	assert.Equal(t, []string{"acme", ""}, orgs)
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiments
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/thestormforge/optimize-go/pkg/api"
+	"github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+)
+
+// ExportRecord is a single line of the newline-delimited JSON produced by `Export`.
+type ExportRecord struct {
+	// Experiment is the name of the experiment the record belongs to.
+	Experiment string `json:"experiment"`
+	// Spec is the definition of the experiment; it is only present on the first record for each experiment.
+	Spec *v1alpha1.Experiment `json:"spec,omitempty"`
+	// Trial is a trial of the experiment.
+	Trial *v1alpha1.TrialItem `json:"trial,omitempty"`
+}
+
+func (h *httpAPI) Export(ctx context.Context, w io.Writer) error {
+	ctx, cancel := h.withTimeout(ctx, "Export")
+	defer cancel()
+
+	bw := bufio.NewWriter(w)
+	encode := func(rec *ExportRecord) error {
+		b, err := api.ClientCodec(h.client).Marshal(rec)
+		if err != nil {
+			return err
+		}
+		_, err = bw.Write(append(b, '\n'))
+		return err
+	}
+
+	// The server order is not stable, sort so exports of the same data are identical
+	v1 := v1alpha1.NewAPI(h.client)
+	var exps []v1alpha1.Experiment
+	for item, err := range v1alpha1.ListAllExperiments(ctx, v1, nil, 0) {
+		if err != nil {
+			return err
+		}
+		exps = append(exps, item.Experiment)
+	}
+	sort.SliceStable(exps, func(i, j int) bool { return exps[i].Name() < exps[j].Name() })
+
+	for i := range exps {
+		exp := exps[i]
+		name := exp.Name()
+		if err := encode(&ExportRecord{Experiment: name, Spec: &exp}); err != nil {
+			return err
+		}
+
+		trialsURL := exp.TrialsURL
+		if trialsURL == "" {
+			trialsURL = h.experimentURL(name) + "/trials/"
+		}
+		var trials []v1alpha1.TrialItem
+		for t, err := range v1alpha1.ListAllTrials(ctx, v1, trialsURL, nil, 0) {
+			if err != nil {
+				return err
+			}
+			t.Metadata = nil
+			trials = append(trials, t)
+		}
+		sort.SliceStable(trials, func(i, j int) bool { return trials[i].Number < trials[j].Number })
+
+		for j := range trials {
+			if err := encode(&ExportRecord{Experiment: name, Trial: &trials[j]}); err != nil {
+				return err
+			}
+		}
+
+		// Flush after each experiment so partial exports always end on a complete experiment
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+func (h *httpAPI) Import(ctx context.Context, r io.Reader) error {
+	ctx, cancel := h.withTimeout(ctx, "Import")
+	defer cancel()
+
+	merr := &api.MultiError{}
+	codec := api.ClientCodec(h.client)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), 16<<20)
+	for scanner.Scan() {
+		// Records are newline-delimited, blank lines are ignored
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		rec := ExportRecord{}
+		if err := codec.Unmarshal(line, &rec); err != nil {
+			return fmt.Errorf("invalid export record %d: %w", merr.Total+1, err)
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		switch {
+		case rec.Spec != nil:
+			_, err := h.CreateExperiment(ctx, rec.Experiment, *rec.Spec)
+			merr.Add(merr.Total, rec.Experiment, err)
+
+		case rec.Trial != nil:
+			key := fmt.Sprintf("%s/%d", rec.Experiment, rec.Trial.Number)
+			merr.Add(merr.Total, key, h.importTrial(ctx, rec.Experiment, rec.Trial))
+		}
+		merr.Total++
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return merr.ErrorOrNil()
+}
+
+// importTrial recreates a single exported trial.
+func (h *httpAPI) importTrial(ctx context.Context, experiment string, t *v1alpha1.TrialItem) error {
+	asm := v1alpha1.TrialAssignments{Assignments: t.Assignments, Labels: t.Labels}
+	ta, err := h.CreateTrial(ctx, experiment, asm)
+	if err != nil {
+		return err
+	}
+
+	switch t.Status {
+	case v1alpha1.TrialCompleted, v1alpha1.TrialFailed:
+		if ta.SelfURL == "" {
+			return fmt.Errorf("missing location for trial %d of experiment %q", t.Number, experiment)
+		}
+		return h.ReportTrialValues(ctx, ta.SelfURL, t.TrialValues)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiments
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thestormforge/optimize-go/pkg/api"
+	"github.com/thestormforge/optimize-go/pkg/api/apitest"
+)
+
+func TestAPI_ExportImport(t *testing.T) {
+	src := apitest.NewMock().
+		On(http.MethodGet, "/experiments/", apitest.JSONResponse(http.StatusOK, `{"experiments":[
+			{"displayName":"a","_metadata":{"Link":["<`+apitest.MockBaseURL+`/experiments/a>;rel=\"self\""]}},
+			{"displayName":"b","_metadata":{"Link":["<`+apitest.MockBaseURL+`/experiments/b>;rel=\"self\""]}}
+		]}`)).
+		On(http.MethodGet, "/experiments/a/trials/", apitest.JSONResponse(http.StatusOK, `{"trials":[
+			{"number":1,"status":"completed","assignments":[{"parameterName":"cpu","value":100}],"values":[{"metricName":"cost","value":5}]},
+			{"number":2,"status":"active","assignments":[{"parameterName":"cpu","value":200}]}
+		]}`)).
+		On(http.MethodGet, "/experiments/b/trials/", apitest.JSONResponse(http.StatusOK, `{"trials":[]}`))
+
+	var buf bytes.Buffer
+	require.NoError(t, NewAPI(src).Export(context.Background(), &buf))
+
+	var records []ExportRecord
+	for s := bufio.NewScanner(bytes.NewReader(buf.Bytes())); s.Scan(); {
+		rec := ExportRecord{}
+		require.NoError(t, json.Unmarshal(s.Bytes(), &rec))
+		records = append(records, rec)
+	}
+	if assert.Len(t, records, 4) {
+		assert.Equal(t, "a", records[0].Experiment)
+		assert.NotNil(t, records[0].Spec)
+		assert.Equal(t, int64(1), records[1].Trial.Number)
+		assert.Equal(t, int64(2), records[2].Trial.Number)
+		assert.Equal(t, "b", records[3].Experiment)
+		assert.NotNil(t, records[3].Spec)
+	}
+
+	trialLocation := apitest.Response{
+		StatusCode: http.StatusCreated,
+		Header:     http.Header{"Location": []string{apitest.MockBaseURL + "/experiments/a/trials/1"}},
+	}
+	dst := apitest.NewMock().
+		On(http.MethodPut, "/experiments/a", apitest.JSONResponse(http.StatusCreated, `{}`)).
+		On(http.MethodPut, "/experiments/b", apitest.JSONResponse(http.StatusCreated, `{}`)).
+		On(http.MethodPost, "/experiments/a/trials/", trialLocation).
+		On(http.MethodPost, "/experiments/a/trials/1", apitest.Response{StatusCode: http.StatusNoContent})
+
+	require.NoError(t, NewAPI(dst).Import(context.Background(), &buf))
+
+	var actual []string
+	for _, r := range dst.Requests() {
+		actual = append(actual, r.Method+" "+r.URL.Path)
+	}
+	assert.Equal(t, []string{
+		"PUT /experiments/a",
+		"POST /experiments/a/trials/",
+		"POST /experiments/a/trials/1",
+		"POST /experiments/a/trials/",
+		"PUT /experiments/b",
+	}, actual)
+}
+
+func TestAPI_Export_Order(t *testing.T) {
+	src := apitest.NewMock().
+		On(http.MethodGet, "/experiments/", apitest.JSONResponse(http.StatusOK, `{"experiments":[
+			{"displayName":"b","_metadata":{"Link":["<`+apitest.MockBaseURL+`/experiments/b>;rel=\"self\""]}},
+			{"displayName":"a","_metadata":{"Link":["<`+apitest.MockBaseURL+`/experiments/a>;rel=\"self\""]}}
+		]}`)).
+		On(http.MethodGet, "/experiments/a/trials/", apitest.JSONResponse(http.StatusOK, `{"trials":[
+			{"number":2,"status":"active"},
+			{"number":1,"status":"active"}
+		]}`)).
+		On(http.MethodGet, "/experiments/b/trials/", apitest.JSONResponse(http.StatusOK, `{"trials":[]}`))
+
+	var buf bytes.Buffer
+	require.NoError(t, NewAPI(src).Export(context.Background(), &buf))
+	assert.Equal(t, `{"experiment":"a","spec":{"displayName":"a","metrics":null,"parameters":null}}
+{"experiment":"a","trial":{"assignments":null,"status":"active","number":1}}
+{"experiment":"a","trial":{"assignments":null,"status":"active","number":2}}
+{"experiment":"b","spec":{"displayName":"b","metrics":null,"parameters":null}}
+`, buf.String())
+}
+
+func TestAPI_Import_PartialFailure(t *testing.T) {
+	in := `{"experiment":"a","spec":{}}
+{"experiment":"a","trial":{"number":1,"status":"active"}}
+{"experiment":"b","spec":{}}
+{"experiment":"b","trial":{"number":1,"status":"active"}}
+`
+	dst := apitest.NewMock().
+		On(http.MethodPut, "/experiments/a", apitest.JSONResponse(http.StatusCreated, `{}`)).
+		On(http.MethodPost, "/experiments/a/trials/", apitest.Response{StatusCode: http.StatusCreated}).
+		On(http.MethodPut, "/experiments/b", apitest.JSONResponse(http.StatusUnprocessableEntity, `{"error":"invalid experiment"}`)).
+		On(http.MethodPost, "/experiments/b/trials/", apitest.JSONResponse(http.StatusNotFound, `{"error":"experiment not found"}`))
+
+	err := NewAPI(dst).Import(context.Background(), strings.NewReader(in))
+
+	var merr *api.MultiError
+	require.True(t, errors.As(err, &merr))
+	assert.Equal(t, 4, merr.Total)
+	if assert.Len(t, merr.Errors, 2) {
+		assert.Equal(t, 2, merr.Errors[0].Index)
+		assert.Equal(t, "b", merr.Errors[0].Key)
+		assert.Equal(t, "b/1", merr.Errors[1].Key)
+		assert.True(t, errors.Is(merr.Errors[1], api.ErrNotFound))
+	}
+	assert.Len(t, dst.Requests(), 4)
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiments
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/thestormforge/optimize-go/pkg/api"
+)
+
+// MetricSample is a single raw measurement of a metric.
+type MetricSample struct {
+	// Time is when the measurement was taken.
+	Time time.Time `json:"time"`
+	// Value is the measured value.
+	Value float64 `json:"value"`
+}
+
+// MetricSeries is the sequence of raw measurements of a metric taken during a trial.
+type MetricSeries struct {
+	// MetricName is the name of the metric in the experiment.
+	MetricName string `json:"metricName"`
+	// Samples are the measurements, in the order they were taken.
+	Samples []MetricSample `json:"samples"`
+}
+
+// trialMetricsPage is a single page of the trial metrics response.
+type trialMetricsPage struct {
+	Metrics []MetricSeries `json:"metrics"`
+}
+
+func (h *httpAPI) GetTrialMetrics(ctx context.Context, trialURL string) ([]MetricSeries, error) {
+	ctx, cancel := h.withTimeout(ctx, "GetTrialMetrics")
+	defer cancel()
+
+	var series []MetricSeries
+	index := make(map[string]int)
+
+	u := strings.TrimSuffix(trialURL, "/") + "/metrics"
+	for u != "" {
+		req, err := http.NewRequest(http.MethodGet, u, nil)
+		if err != nil {
+			return series, err
+		}
+
+		page := trialMetricsPage{}
+		resp, err := h.do(ctx, req, &page)
+		if err != nil {
+			return series, err
+		}
+
+		// Long series are split across pages, samples are appended to the series started on earlier pages
+		for _, s := range page.Metrics {
+			if i, ok := index[s.MetricName]; ok {
+				series[i].Samples = append(series[i].Samples, s.Samples...)
+				continue
+			}
+			index[s.MetricName] = len(series)
+			series = append(series, s)
+		}
+
+		u = ""
+		if next, ok := api.ParseLinkHeader(strings.Join(resp.Header.Values("Link"), ","))["next"]; ok {
+			u = req.URL.ResolveReference(next).String()
+		}
+	}
+
+	return series, nil
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiments
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thestormforge/optimize-go/pkg/api/apitest"
+)
+
+func TestAPI_GetTrialMetrics(t *testing.T) {
+	first := apitest.JSONResponse(http.StatusOK, `{"metrics":[
+		{"metricName":"cost","samples":[{"time":"2020-01-01T00:00:00Z","value":1},{"time":"2020-01-01T00:01:00Z","value":2}]}
+	]}`)
+	first.Header.Add("Link", `</experiments/my-exp/trials/1/metrics?page=2>;rel="next"`)
+	second := apitest.JSONResponse(http.StatusOK, `{"metrics":[
+		{"metricName":"cost","samples":[{"time":"2020-01-01T00:02:00Z","value":3}]},
+		{"metricName":"latency","samples":[{"time":"2020-01-01T00:00:00Z","value":0.25}]}
+	]}`)
+	m := apitest.NewMock().On(http.MethodGet, "/experiments/my-exp/trials/1/metrics", first, second)
+
+	series, err := NewAPI(m).GetTrialMetrics(context.Background(), apitest.MockBaseURL+"/experiments/my-exp/trials/1")
+	require.NoError(t, err)
+	if assert.Len(t, series, 2) {
+		assert.Equal(t, "cost", series[0].MetricName)
+		assert.Len(t, series[0].Samples, 3)
+		assert.Equal(t, time.Date(2020, 1, 1, 0, 2, 0, 0, time.UTC), series[0].Samples[2].Time)
+		assert.Equal(t, "latency", series[1].MetricName)
+		assert.Equal(t, 0.25, series[1].Samples[0].Value)
+	}
+	if reqs := m.Requests(); assert.Len(t, reqs, 2) {
+		assert.Equal(t, "2", reqs[1].URL.Query().Get("page"))
+	}
+}
@@ -0,0 +1,237 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/thestormforge/optimize-go/pkg/api"
+	"github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+)
+
+// DefaultPollInterval is the amount of time to wait between requests for a trial unless otherwise specified.
+const DefaultPollInterval = 5 * time.Second
+
+var (
+	// ErrExperimentFinished is returned when an experiment will not produce any more trials.
+	ErrExperimentFinished = errors.New("experiment finished")
+	// ErrTrialUnavailable is returned when an experiment does not have a trial ready yet.
+	ErrTrialUnavailable = errors.New("trial unavailable")
+	// ErrTrialAlreadyCompleted is returned when a trial cannot be abandoned because it has already finished.
+	ErrTrialAlreadyCompleted = errors.New("trial already completed")
+)
+
+// FailedTrialValues returns trial values which mark a trial as failed. The reason is a machine-readable code while
+// the message is a human-readable explanation of the failure.
+func FailedTrialValues(reason, message string) v1alpha1.TrialValues {
+	return v1alpha1.TrialValues{Failed: true, FailureReason: reason, FailureMessage: message}
+}
+
+func (h *httpAPI) NextTrial(ctx context.Context, experiment string) (v1alpha1.TrialAssignments, error) {
+	ctx, cancel := h.withTimeout(ctx, "NextTrial")
+	defer cancel()
+
+	asm, _, err := h.nextTrial(ctx, experiment)
+	return asm, err
+}
+
+func (h *httpAPI) WaitForTrial(ctx context.Context, experiment string, pollInterval time.Duration) (v1alpha1.TrialAssignments, error) {
+	ctx, cancel := h.withTimeout(ctx, "WaitForTrial")
+	defer cancel()
+
+	if pollInterval <= 0 {
+		pollInterval = DefaultPollInterval
+	}
+
+	// Each poll is bounded by the timeout of "NextTrial", the entire wait by the timeout of "WaitForTrial"
+	poll := func() (v1alpha1.TrialAssignments, time.Duration, error) {
+		ctx, cancel := h.withTimeout(ctx, "NextTrial")
+		defer cancel()
+		return h.nextTrial(ctx, experiment)
+	}
+
+	for {
+		asm, retryAfter, err := poll()
+		if !errors.Is(err, ErrTrialUnavailable) {
+			return asm, err
+		}
+
+		delay := pollInterval
+		if retryAfter > 0 {
+			delay = retryAfter
+		}
+
+		if err := api.ClientClock(h.client).Sleep(ctx, delay); err != nil {
+			return asm, err
+		}
+	}
+}
+
+// nextTrial requests the next trial, also returning the amount of time the server asked us to wait if no trial is
+// available yet.
+func (h *httpAPI) nextTrial(ctx context.Context, experiment string) (v1alpha1.TrialAssignments, time.Duration, error) {
+	asm := v1alpha1.TrialAssignments{}
+
+	req, err := http.NewRequest(http.MethodPost, h.experimentURL(experiment)+"/nextTrial", nil)
+	if err != nil {
+		return asm, 0, err
+	}
+
+	resp, err := h.do(ctx, req, &asm)
+	if resp == nil {
+		return asm, 0, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+		// A "204 No Content" is only temporary if the server tells us when to try again
+		if retryAfter, ok := api.RetryAfter(resp.Header); ok {
+			return asm, retryAfter, ErrTrialUnavailable
+		}
+		return asm, 0, ErrExperimentFinished
+	case http.StatusGone:
+		return asm, 0, ErrExperimentFinished
+	case http.StatusServiceUnavailable:
+		retryAfter, _ := api.RetryAfter(resp.Header)
+		return asm, retryAfter, fmt.Errorf("%w: %w", ErrTrialUnavailable, err)
+	}
+	if err != nil {
+		return asm, 0, err
+	}
+
+	unmarshalTrialMeta(resp.Header, &asm.TrialMeta)
+	return asm, 0, nil
+}
+
+func (h *httpAPI) CreateTrial(ctx context.Context, experiment string, asm v1alpha1.TrialAssignments) (v1alpha1.TrialAssignments, error) {
+	ctx, cancel := h.withTimeout(ctx, "CreateTrial")
+	defer cancel()
+
+	ta := v1alpha1.TrialAssignments{}
+
+	req, err := h.newJSONRequest(http.MethodPost, h.experimentURL(experiment)+"/trials/", asm)
+	if err != nil {
+		return ta, err
+	}
+
+	resp, err := h.do(ctx, req, &ta)
+	if err != nil {
+		return ta, err
+	}
+
+	unmarshalTrialMeta(resp.Header, &ta.TrialMeta)
+	return ta, nil
+}
+
+func (h *httpAPI) ListTrials(ctx context.Context, experiment string, q *v1alpha1.TrialListQuery) (v1alpha1.TrialList, error) {
+	ctx, cancel := h.withTimeout(ctx, "ListTrials")
+	defer cancel()
+
+	lst := v1alpha1.TrialList{}
+
+	if q != nil {
+		if err := q.ListOptions.Validate(); err != nil {
+			return lst, err
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodGet, h.experimentURL(experiment)+"/trials/", nil)
+	if err != nil {
+		return lst, err
+	}
+	if query := q.Encode(); query != "" {
+		req.URL.RawQuery = query
+	}
+
+	resp, err := h.do(ctx, req, &lst)
+	if err != nil {
+		return lst, err
+	}
+
+	v1alpha1.UnmarshalMeta(resp.Header, &lst.TrialListMeta)
+	if lst.Next == "" && lst.NextPageToken != "" {
+		lst.Next = v1alpha1.PageURL(req.URL, lst.NextPageToken)
+	}
+	for i := range lst.Trials {
+		unmarshalTrialMeta(http.Header(lst.Trials[i].Metadata), &lst.Trials[i].TrialMeta)
+	}
+	return lst, nil
+}
+
+func (h *httpAPI) ReportTrialValues(ctx context.Context, trialURL string, vls v1alpha1.TrialValues) error {
+	ctx, cancel := h.withTimeout(ctx, "ReportTrialValues")
+	defer cancel()
+
+	if vls.Failed {
+		vls.Values = nil
+	} else {
+		vls.FailureReason = ""
+		vls.FailureMessage = ""
+		vls.FailureDetails = nil
+	}
+
+	if vls.StartTime != nil && vls.CompletionTime != nil {
+		start, completion := vls.StartTime.Round(time.Millisecond).UTC(), vls.CompletionTime.Round(time.Millisecond).UTC()
+		vls.StartTime, vls.CompletionTime = &start, &completion
+	} else {
+		vls.StartTime, vls.CompletionTime = nil, nil
+	}
+
+	req, err := h.newJSONRequest(http.MethodPost, trialURL, vls)
+	if err != nil {
+		return err
+	}
+
+	_, err = h.do(ctx, req, nil)
+	return err
+}
+
+func (h *httpAPI) AbandonTrial(ctx context.Context, trialURL string, reason string) (v1alpha1.TrialItem, error) {
+	ctx, cancel := h.withTimeout(ctx, "AbandonTrial")
+	defer cancel()
+
+	t := v1alpha1.TrialItem{}
+
+	req, err := http.NewRequest(http.MethodDelete, trialURL, nil)
+	if reason != "" {
+		req, err = h.newJSONRequest(http.MethodDelete, trialURL, struct {
+			Reason string `json:"reason"`
+		}{Reason: reason})
+	}
+	if err != nil {
+		return t, err
+	}
+
+	resp, err := h.do(ctx, req, &t)
+	if resp != nil && resp.StatusCode == http.StatusConflict {
+		return t, fmt.Errorf("%w: %w", ErrTrialAlreadyCompleted, err)
+	}
+	if err != nil {
+		return t, err
+	}
+
+	// The server may not describe the trial, in which case it is simply gone
+	if t.Status == "" {
+		t.Status = v1alpha1.TrialAbandoned
+	}
+	unmarshalTrialMeta(resp.Header, &t.TrialMeta)
+	return t, nil
+}
@@ -0,0 +1,264 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiments
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thestormforge/optimize-go/pkg/api"
+	"github.com/thestormforge/optimize-go/pkg/api/apitest"
+	"github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+	"github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1/numstr"
+)
+
+func TestAPI_NextTrial(t *testing.T) {
+	cases := []struct {
+		desc         string
+		response     apitest.Response
+		expectedErr  error
+		expectedName string
+	}{
+		{
+			desc:         "assigned",
+			response:     apitest.JSONResponse(http.StatusOK, `{"assignments":[{"parameterName":"cpu","value":100}]}`),
+			expectedName: "cpu",
+		},
+		{
+			desc:        "no content",
+			response:    apitest.Response{StatusCode: http.StatusNoContent},
+			expectedErr: ErrExperimentFinished,
+		},
+		{
+			desc:        "gone",
+			response:    apitest.JSONResponse(http.StatusGone, `{"error":"experiment stopped"}`),
+			expectedErr: ErrExperimentFinished,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			m := apitest.NewMock().On(http.MethodPost, "/experiments/my-exp/nextTrial", c.response)
+
+			asm, err := NewAPI(m).NextTrial(context.Background(), "my-exp")
+			if c.expectedErr != nil {
+				assert.True(t, errors.Is(err, c.expectedErr))
+				return
+			}
+			require.NoError(t, err)
+			if assert.Len(t, asm.Assignments, 1) {
+				assert.Equal(t, c.expectedName, asm.Assignments[0].ParameterName)
+			}
+		})
+	}
+}
+
+func TestAPI_CreateTrial(t *testing.T) {
+	resp := apitest.Response{StatusCode: http.StatusCreated, Header: http.Header{"Location": {apitest.MockBaseURL + "/experiments/my-exp/trials/1"}}}
+	m := apitest.NewMock().On(http.MethodPost, "/experiments/my-exp/trials/", resp)
+
+	asm, err := NewAPI(m).CreateTrial(context.Background(), "my-exp", v1alpha1.TrialAssignments{
+		Assignments: []v1alpha1.Assignment{{ParameterName: "cpu"}},
+		Labels:      map[string]string{"source": "baseline"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, apitest.MockBaseURL+"/experiments/my-exp/trials/1", asm.SelfURL)
+	if reqs := m.Requests(); assert.Len(t, reqs, 1) {
+		assert.JSONEq(t, `{"assignments":[{"parameterName":"cpu","value":0}],"labels":{"source":"baseline"}}`, string(reqs[0].Body))
+	}
+}
+
+func TestAPI_ListTrials(t *testing.T) {
+	m := apitest.NewMock().On(http.MethodGet, "/experiments/my-exp/trials/", apitest.JSONResponse(http.StatusOK, `{"trials":[
+		{"number":1,"status":"completed","labels":{"source":"baseline","phase":"exploration"}}
+	]}`))
+
+	lst, err := NewAPI(m).ListTrials(context.Background(), "my-exp", &v1alpha1.TrialListQuery{
+		Status:        []v1alpha1.TrialStatus{v1alpha1.TrialCompleted},
+		LabelSelector: map[string]string{"source": "baseline", "phase": "exploration"},
+	})
+	require.NoError(t, err)
+	if assert.Len(t, lst.Trials, 1) {
+		assert.Equal(t, map[string]string{"source": "baseline", "phase": "exploration"}, lst.Trials[0].Labels)
+	}
+	if reqs := m.Requests(); assert.Len(t, reqs, 1) {
+		// Every selector must match, so they are combined into a single parameter
+		assert.Equal(t, []string{"phase=exploration,source=baseline"}, reqs[0].URL.Query()["labelSelector"])
+		assert.Equal(t, "completed", reqs[0].URL.Query().Get("status"))
+	}
+}
+
+func TestAPI_ReportTrialValues(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 123456789, time.UTC)
+	completion := start.Add(time.Minute)
+
+	cases := []struct {
+		desc     string
+		values   v1alpha1.TrialValues
+		expected string
+	}{
+		{
+			desc: "observed",
+			values: v1alpha1.TrialValues{
+				Values:         []v1alpha1.Value{{MetricName: "cost", Value: numstr.FromFloat64(1.5)}},
+				FailureReason:  "ignored",
+				StartTime:      &start,
+				CompletionTime: &completion,
+			},
+			expected: `{"values":[{"metricName":"cost","value":1.5}],"startTime":"2020-01-01T00:00:00.123Z","completionTime":"2020-01-01T00:01:00.123Z"}`,
+		},
+		{
+			desc:     "failed",
+			values:   FailedTrialValues("OOMKilled", "the application ran out of memory"),
+			expected: `{"failed":true,"failureReason":"OOMKilled","failureMessage":"the application ran out of memory"}`,
+		},
+		{
+			desc: "failed with details",
+			values: v1alpha1.TrialValues{
+				Values:         []v1alpha1.Value{{MetricName: "cost", Value: numstr.FromFloat64(1.5)}},
+				Failed:         true,
+				FailureReason:  "MetricUnavailable",
+				FailureMessage: "the cost metric could not be collected",
+				FailureDetails: map[string]string{"metric": "cost"},
+			},
+			expected: `{"failed":true,"failureReason":"MetricUnavailable","failureMessage":"the cost metric could not be collected","failureDetails":{"metric":"cost"}}`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			m := apitest.NewMock().On(http.MethodPost, "/experiments/my-exp/trials/1", apitest.Response{StatusCode: http.StatusCreated})
+
+			err := NewAPI(m).ReportTrialValues(context.Background(), apitest.MockBaseURL+"/experiments/my-exp/trials/1", c.values)
+			require.NoError(t, err)
+			if reqs := m.Requests(); assert.Len(t, reqs, 1) {
+				assert.JSONEq(t, c.expected, string(reqs[0].Body))
+			}
+		})
+	}
+	assert.Equal(t, 123456789, start.Nanosecond(), "caller values were modified")
+}
+
+func TestAPI_WaitForTrial(t *testing.T) {
+	notReady := apitest.Response{StatusCode: http.StatusNoContent, Header: http.Header{"Retry-After": {"0"}}}
+	unavailable := apitest.JSONResponse(http.StatusServiceUnavailable, `{"error":"trial unavailable"}`)
+
+	t.Run("available", func(t *testing.T) {
+		m := apitest.NewMock().On(http.MethodPost, "/experiments/my-exp/nextTrial",
+			notReady,
+			unavailable,
+			apitest.JSONResponse(http.StatusOK, `{"assignments":[{"parameterName":"cpu","value":100}]}`),
+		)
+
+		asm, err := NewAPI(m).WaitForTrial(context.Background(), "my-exp", time.Millisecond)
+		require.NoError(t, err)
+		assert.Len(t, asm.Assignments, 1)
+		assert.Len(t, m.Requests(), 3)
+	})
+
+	t.Run("finished", func(t *testing.T) {
+		m := apitest.NewMock().On(http.MethodPost, "/experiments/my-exp/nextTrial",
+			notReady,
+			apitest.Response{StatusCode: http.StatusGone},
+		)
+
+		_, err := NewAPI(m).WaitForTrial(context.Background(), "my-exp", time.Millisecond)
+		assert.True(t, errors.Is(err, ErrExperimentFinished))
+		assert.Len(t, m.Requests(), 2)
+	})
+
+	t.Run("clock", func(t *testing.T) {
+		clock := apitest.NewFakeClock(time.Now())
+		m := apitest.NewMock().WithClock(clock).On(http.MethodPost, "/experiments/my-exp/nextTrial",
+			unavailable,
+			apitest.JSONResponse(http.StatusOK, `{"assignments":[{"parameterName":"cpu","value":100}]}`),
+		)
+
+		done := make(chan error)
+		go func() {
+			_, err := NewAPI(m).WaitForTrial(context.Background(), "my-exp", time.Hour)
+			done <- err
+		}()
+
+		// The poll interval elapses on the clock of the client, no real time passes
+		clock.BlockUntil(1)
+		clock.Advance(time.Hour)
+		assert.NoError(t, <-done)
+		assert.Len(t, m.Requests(), 2)
+	})
+
+	t.Run("deadline", func(t *testing.T) {
+		m := apitest.NewMock().On(http.MethodPost, "/experiments/my-exp/nextTrial", unavailable)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		_, err := NewAPI(m).WaitForTrial(ctx, "my-exp", time.Hour)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		assert.Less(t, int64(time.Since(start)), int64(time.Second))
+	})
+}
+
+func TestAPI_CreateTrials(t *testing.T) {
+	m := apitest.NewMock().On(http.MethodPost, "/experiments/my-exp/trials/",
+		apitest.JSONResponse(http.StatusCreated, `{"assignments":[{"parameterName":"cpu","value":100}]}`),
+		apitest.JSONResponse(http.StatusConflict, `{"error":"duplicate trial"}`),
+		apitest.JSONResponse(http.StatusCreated, `{"assignments":[{"parameterName":"cpu","value":300}]}`),
+	)
+
+	asms := make([]v1alpha1.TrialAssignments, 3)
+	trials, err := NewAPI(m, WithBatchConcurrency(1)).CreateTrials(context.Background(), "my-exp", asms)
+
+	var merr *api.MultiError
+	require.True(t, errors.As(err, &merr))
+	assert.True(t, errors.Is(err, api.ErrConflict))
+	assert.Equal(t, 3, merr.Total)
+	if assert.Len(t, merr.Errors, 1) {
+		assert.Equal(t, 1, merr.Errors[0].Index)
+		assert.True(t, errors.Is(merr.Errors[0], api.ErrConflict))
+	}
+	if assert.Len(t, trials, 3) {
+		assert.Equal(t, "100", trials[0].Assignments[0].Value.String())
+		assert.Empty(t, trials[1].Assignments)
+		assert.Equal(t, "300", trials[2].Assignments[0].Value.String())
+	}
+	assert.Len(t, m.Requests(), 3)
+}
+
+func TestAPI_AbandonTrial(t *testing.T) {
+	trialURL := apitest.MockBaseURL + "/experiments/my-exp/trials/1"
+
+	m := apitest.NewMock().On(http.MethodDelete, "/experiments/my-exp/trials/1",
+		apitest.Response{StatusCode: http.StatusNoContent},
+		apitest.JSONResponse(http.StatusConflict, `{"error":"trial already reported"}`),
+	)
+	a := NewAPI(m)
+
+	trial, err := a.AbandonTrial(context.Background(), trialURL, "pod crashed")
+	require.NoError(t, err)
+	assert.Equal(t, v1alpha1.TrialAbandoned, trial.Status)
+	if reqs := m.Requests(); assert.Len(t, reqs, 1) {
+		assert.JSONEq(t, `{"reason":"pod crashed"}`, string(reqs[0].Body))
+	}
+
+	_, err = a.AbandonTrial(context.Background(), trialURL, "")
+	assert.True(t, errors.Is(err, ErrTrialAlreadyCompleted))
+	assert.True(t, errors.Is(err, api.ErrConflict))
+}
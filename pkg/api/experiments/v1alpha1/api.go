@@ -19,11 +19,16 @@ package v1alpha1
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/thestormforge/optimize-go/pkg/api"
 	"golang.org/x/oauth2"
 )
 
@@ -70,6 +75,95 @@ func (m *Metadata) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// ListOptions controls the pagination of list requests. Servers may paginate using either offsets or opaque cursors,
+// the "next" link of a list response (or its `NextPageToken`) always reflects the style used by the server.
+type ListOptions struct {
+	// PageSize is the maximum number of items to return per page.
+	PageSize int
+	// PageToken is an opaque cursor, returned as the `NextPageToken` of a previous list response, identifying the page
+	// to return. When set, any offset is ignored.
+	PageToken string
+	// OrderBy is the sort order of the results, in order of precedence. Only the `SortableFields` may be used.
+	OrderBy []OrderBy
+	// Fields restricts the fields included on each item (a sparse fieldset) to reduce the size of the response, the
+	// omitted fields are left as zero values.
+	Fields []string
+}
+
+// OrderBy is a field used to sort the results of a list request.
+type OrderBy struct {
+	// Field is the name of the field to sort on.
+	Field string
+	// Descending reverses the sort order, by default results are sorted in ascending order.
+	Descending bool
+}
+
+// ErrInvalidOrderBy is returned when list results are ordered by a field that cannot be sorted on.
+var ErrInvalidOrderBy = errors.New("invalid order by")
+
+// SortableFields are the names of the fields list results can be ordered by.
+var SortableFields = []string{"name", "displayName", "createdAt", "lastModified", "number", "status"}
+
+// Validate checks that the options can be used for a list request.
+func (o *ListOptions) Validate() error {
+	for _, ob := range o.OrderBy {
+		known := false
+		for _, f := range SortableFields {
+			if ob.Field == f {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("%w: unknown field %q, expected one of: %s", ErrInvalidOrderBy, ob.Field, strings.Join(SortableFields, ", "))
+		}
+	}
+	return nil
+}
+
+// encode adds the pagination parameters to the supplied query, unless a limit has already been set.
+func (o *ListOptions) encode(q url.Values) {
+	if o.PageToken != "" {
+		q.Del("offset")
+		q.Set("cursor", o.PageToken)
+	}
+	if o.PageSize > 0 && q.Get("limit") == "" {
+		q.Set("limit", strconv.Itoa(o.PageSize))
+	}
+	if len(o.OrderBy) > 0 {
+		sorts := make([]string, len(o.OrderBy))
+		for i, ob := range o.OrderBy {
+			sorts[i] = ob.Field
+			if ob.Descending {
+				sorts[i] = "-" + ob.Field
+			}
+		}
+		q.Set("sort", strings.Join(sorts, ","))
+	}
+	if len(o.Fields) > 0 {
+		q.Set("fields", strings.Join(o.Fields, ","))
+	}
+}
+
+// PageURL returns the list URL modified to fetch the page identified by the supplied cursor.
+func PageURL(u *url.URL, pageToken string) string {
+	uu := *u
+	q := uu.Query()
+	(&ListOptions{PageToken: pageToken}).encode(q)
+	uu.RawQuery = q.Encode()
+	return uu.String()
+}
+
+// encodeLabelSelector returns the query parameter value for matching all of the supplied label value pairs.
+func encodeLabelSelector(labels map[string]string) string {
+	ls := make([]string, 0, len(labels))
+	for k, v := range labels {
+		ls = append(ls, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(ls)
+	return strings.Join(ls, ",")
+}
+
 type ErrorType string
 
 const (
@@ -92,16 +186,24 @@ type Error struct {
 	Message    string        `json:"error"`
 	RetryAfter time.Duration `json:"-"`
 	Location   string        `json:"-"`
+
+	cause error
 }
 
 func (e *Error) Error() string {
 	return e.Message
 }
 
+// Unwrap returns the generic API error describing the server response.
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
 // IsUnauthorized check to see if the error is an "unauthorized" error
 func IsUnauthorized(err error) bool {
 	// OAuth errors (e.g. fetching tokens) will come out of `Do` and will be wrapped in url.Error
-	if uerr, ok := err.(*url.Error); ok {
+	var uerr *url.Error
+	if errors.As(err, &uerr) {
 		err = uerr.Unwrap()
 	}
 	if rerr, ok := err.(*oauth2.RetrieveError); ok {
@@ -114,6 +216,9 @@ func IsUnauthorized(err error) bool {
 			return true
 		}
 	}
+	if errors.Is(err, api.ErrUnauthorized) {
+		return true
+	}
 	// TODO This is a hack to work around the way we generate errors during JWT validation
 	if err != nil && err.Error() == "no Bearer token" {
 		return true
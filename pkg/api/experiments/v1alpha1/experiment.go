@@ -18,7 +18,6 @@ package v1alpha1
 
 import (
 	"encoding/json"
-	"fmt"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -57,6 +56,26 @@ type Metric struct {
 	Optimize *bool `json:"optimize,omitempty"`
 }
 
+// Objective is an optimized metric along with the direction of the optimization.
+type Objective struct {
+	// The name of the metric.
+	Name string
+	// The flag indicating the metric is minimized, otherwise it is maximized.
+	Minimize bool
+}
+
+// Objectives returns the metrics of the experiment that are optimized.
+func (e *Experiment) Objectives() []Objective {
+	var objectives []Objective
+	for _, m := range e.Metrics {
+		if m.Optimize != nil && !*m.Optimize {
+			continue
+		}
+		objectives = append(objectives, Objective{Name: m.Name, Minimize: m.Minimize})
+	}
+	return objectives
+}
+
 type ConstraintType string
 
 const (
@@ -124,6 +143,8 @@ type Parameter struct {
 }
 
 type ExperimentMeta struct {
+	ETag         string    `json:"-"`
+	APIVersion   string    `json:"-"`
 	LastModified time.Time `json:"-"`
 	SelfURL      string    `json:"-"`
 	TrialsURL    string    `json:"-"`
@@ -154,6 +175,9 @@ func (m *ExperimentMeta) SetLink(rel, link string) {
 }
 func (m *ExperimentMeta) Headers() http.Header {
 	h := metaMarshal("", m.LastModified)
+	if m.ETag != "" {
+		h.Set("ETag", m.ETag)
+	}
 	metaMarshalLink(h, relationSelf, m.SelfURL)
 	metaMarshalLink(h, relationTrials, m.TrialsURL)
 	metaMarshalLink(h, relationNextTrial, m.NextTrialURL)
@@ -206,6 +230,12 @@ type ExperimentItem struct {
 type ExperimentListMeta struct {
 	Next string `json:"-"`
 	Prev string `json:"-"`
+
+	// NextPageToken is the cursor for the next page, empty if there are no more pages or the server uses offsets.
+	NextPageToken string `json:"next_cursor,omitempty"`
+
+	// Links contains all of the links from the response, indexed by relation type.
+	Links map[string]*url.URL `json:"-"`
 }
 
 func (m *ExperimentListMeta) SetLocation(string)        {}
@@ -220,6 +250,8 @@ func (m *ExperimentListMeta) SetLink(rel, link string) {
 }
 
 type ExperimentListQuery struct {
+	ListOptions
+
 	Offset        int
 	Limit         int
 	LabelSelector map[string]string
@@ -238,12 +270,9 @@ func (p *ExperimentListQuery) Encode() string {
 		q.Set("limit", strconv.Itoa(p.Limit))
 	}
 	if len(p.LabelSelector) > 0 {
-		ls := make([]string, 0, len(p.LabelSelector))
-		for k, v := range p.LabelSelector {
-			ls = append(ls, fmt.Sprintf("%s=%s", k, v))
-		}
-		q.Add("labelSelector", strings.Join(ls, ","))
+		q.Add("labelSelector", encodeLabelSelector(p.LabelSelector))
 	}
+	p.ListOptions.encode(q)
 	return q.Encode()
 }
 
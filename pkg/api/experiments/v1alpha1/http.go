@@ -23,7 +23,6 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
-	"strconv"
 	"strings"
 	"time"
 
@@ -64,11 +63,17 @@ func (h *httpAPI) Options(ctx context.Context) (ServerMeta, error) {
 		sm.Unmarshal(resp.Header)
 		return sm, nil
 	default:
-		return sm, newError(ErrUnexpected, resp, body)
+		return sm, h.newError(ErrUnexpected, resp, body)
 	}
 }
 
 func (h *httpAPI) GetAllExperiments(ctx context.Context, q *ExperimentListQuery) (ExperimentList, error) {
+	if q != nil {
+		if err := q.ListOptions.Validate(); err != nil {
+			return ExperimentList{}, err
+		}
+	}
+
 	u := h.client.URL(endpointExperiment)
 	u.RawQuery = q.Encode()
 
@@ -91,13 +96,17 @@ func (h *httpAPI) GetAllExperimentsByPage(ctx context.Context, u string) (Experi
 	switch resp.StatusCode {
 	case http.StatusOK:
 		metaUnmarshal(resp.Header, &lst.ExperimentListMeta)
-		err = json.Unmarshal(body, &lst)
+		lst.Links = metaLinks(resp.Header)
+		err = api.ClientCodec(h.client).Unmarshal(body, &lst)
+		if lst.Next == "" && lst.NextPageToken != "" {
+			lst.Next = PageURL(req.URL, lst.NextPageToken)
+		}
 		for i := range lst.Experiments {
 			metaUnmarshal(http.Header(lst.Experiments[i].Metadata), &lst.Experiments[i].Experiment.ExperimentMeta)
 		}
 		return lst, err
 	default:
-		return lst, newError(ErrUnexpected, resp, body)
+		return lst, h.newError(ErrUnexpected, resp, body)
 	}
 }
 
@@ -129,12 +138,12 @@ func (h *httpAPI) GetExperiment(ctx context.Context, u string) (Experiment, erro
 	switch resp.StatusCode {
 	case http.StatusOK:
 		metaUnmarshal(resp.Header, &e.ExperimentMeta)
-		err = json.Unmarshal(body, &e)
+		err = api.ClientCodec(h.client).Unmarshal(body, &e)
 		return e, err
 	case http.StatusNotFound:
-		return e, newError(ErrExperimentNotFound, resp, body)
+		return e, h.newError(ErrExperimentNotFound, resp, body)
 	default:
-		return e, newError(ErrUnexpected, resp, body)
+		return e, h.newError(ErrUnexpected, resp, body)
 	}
 }
 
@@ -142,7 +151,7 @@ func (h *httpAPI) CreateExperiment(ctx context.Context, n ExperimentName, exp Ex
 	u := h.client.URL(endpointExperiment + n.Name()).String()
 	e := Experiment{}
 
-	req, err := httpNewJSONRequest(http.MethodPut, u, exp)
+	req, err := h.newJSONRequest(http.MethodPut, u, exp)
 	if err != nil {
 		return e, err
 	}
@@ -155,16 +164,16 @@ func (h *httpAPI) CreateExperiment(ctx context.Context, n ExperimentName, exp Ex
 	switch resp.StatusCode {
 	case http.StatusOK, http.StatusCreated:
 		metaUnmarshal(resp.Header, &e.ExperimentMeta)
-		err = json.Unmarshal(body, &e)
+		err = api.ClientCodec(h.client).Unmarshal(body, &e)
 		return e, err
 	case http.StatusBadRequest:
-		return e, newError(ErrExperimentNameInvalid, resp, body)
+		return e, h.newError(ErrExperimentNameInvalid, resp, body)
 	case http.StatusConflict:
-		return e, newError(ErrExperimentNameConflict, resp, body)
+		return e, h.newError(ErrExperimentNameConflict, resp, body)
 	case http.StatusUnprocessableEntity:
-		return e, newError(ErrExperimentInvalid, resp, body)
+		return e, h.newError(ErrExperimentInvalid, resp, body)
 	default:
-		return e, newError(ErrUnexpected, resp, body)
+		return e, h.newError(ErrUnexpected, resp, body)
 	}
 }
 
@@ -183,15 +192,21 @@ func (h *httpAPI) DeleteExperiment(ctx context.Context, u string) error {
 	case http.StatusNoContent:
 		return nil
 	case http.StatusNotFound:
-		return newError(ErrExperimentNotFound, resp, body)
+		return h.newError(ErrExperimentNotFound, resp, body)
 	default:
-		return newError(ErrUnexpected, resp, body)
+		return h.newError(ErrUnexpected, resp, body)
 	}
 }
 
 func (h *httpAPI) GetAllTrials(ctx context.Context, u string, q *TrialListQuery) (TrialList, error) {
 	lst := TrialList{}
 
+	if q != nil {
+		if err := q.ListOptions.Validate(); err != nil {
+			return lst, err
+		}
+	}
+
 	rawQuery := q.Encode()
 	if rawQuery != "" {
 		if uu, err := url.Parse(u); err == nil {
@@ -213,20 +228,25 @@ func (h *httpAPI) GetAllTrials(ctx context.Context, u string, q *TrialListQuery)
 
 	switch resp.StatusCode {
 	case http.StatusOK:
-		err = json.Unmarshal(body, &lst)
+		metaUnmarshal(resp.Header, &lst.TrialListMeta)
+		lst.Links = metaLinks(resp.Header)
+		err = api.ClientCodec(h.client).Unmarshal(body, &lst)
+		if lst.Next == "" && lst.NextPageToken != "" {
+			lst.Next = PageURL(req.URL, lst.NextPageToken)
+		}
 		for i := range lst.Trials {
 			metaUnmarshal(http.Header(lst.Trials[i].Metadata), &lst.Trials[i].TrialAssignments.TrialMeta)
 		}
 		return lst, err
 	default:
-		return lst, newError(ErrUnexpected, resp, body)
+		return lst, h.newError(ErrUnexpected, resp, body)
 	}
 }
 
 func (h *httpAPI) CreateTrial(ctx context.Context, u string, asm TrialAssignments) (TrialAssignments, error) {
 	ta := TrialAssignments{}
 
-	req, err := httpNewJSONRequest(http.MethodPost, u, asm)
+	req, err := h.newJSONRequest(http.MethodPost, u, asm)
 	if err != nil {
 		return ta, err
 	}
@@ -239,14 +259,14 @@ func (h *httpAPI) CreateTrial(ctx context.Context, u string, asm TrialAssignment
 	switch resp.StatusCode {
 	case http.StatusCreated, http.StatusAccepted:
 		metaUnmarshal(resp.Header, &ta.TrialMeta)
-		err = json.Unmarshal(body, &ta)
+		err = api.ClientCodec(h.client).Unmarshal(body, &ta)
 		return ta, nil // TODO Stop ignoring this when the server starts sending a response body
 	case http.StatusConflict:
-		return ta, newError(ErrExperimentStopped, resp, body)
+		return ta, h.newError(ErrExperimentStopped, resp, body)
 	case http.StatusUnprocessableEntity:
-		return ta, newError(ErrTrialInvalid, resp, body)
+		return ta, h.newError(ErrTrialInvalid, resp, body)
 	default:
-		return ta, newError(ErrUnexpected, resp, body)
+		return ta, h.newError(ErrUnexpected, resp, body)
 	}
 }
 
@@ -266,14 +286,14 @@ func (h *httpAPI) NextTrial(ctx context.Context, u string) (TrialAssignments, er
 	switch resp.StatusCode {
 	case http.StatusOK:
 		metaUnmarshal(resp.Header, &asm.TrialMeta)
-		err = json.Unmarshal(body, &asm)
+		err = api.ClientCodec(h.client).Unmarshal(body, &asm)
 		return asm, err
 	case http.StatusGone:
-		return asm, newError(ErrExperimentStopped, resp, body)
+		return asm, h.newError(ErrExperimentStopped, resp, body)
 	case http.StatusServiceUnavailable:
-		return asm, newError(ErrTrialUnavailable, resp, body)
+		return asm, h.newError(ErrTrialUnavailable, resp, body)
 	default:
-		return asm, newError(ErrUnexpected, resp, body)
+		return asm, h.newError(ErrUnexpected, resp, body)
 	}
 }
 
@@ -290,7 +310,7 @@ func (h *httpAPI) ReportTrial(ctx context.Context, u string, vls TrialValues) er
 		vls.CompletionTime = nil
 	}
 
-	req, err := httpNewJSONRequest(http.MethodPost, u, vls)
+	req, err := h.newJSONRequest(http.MethodPost, u, vls)
 	if err != nil {
 		return err
 	}
@@ -304,13 +324,13 @@ func (h *httpAPI) ReportTrial(ctx context.Context, u string, vls TrialValues) er
 	case http.StatusCreated:
 		return nil
 	case http.StatusNotFound:
-		return newError(ErrTrialNotFound, resp, body)
+		return h.newError(ErrTrialNotFound, resp, body)
 	case http.StatusConflict:
-		return newError(ErrTrialAlreadyReported, resp, body)
+		return h.newError(ErrTrialAlreadyReported, resp, body)
 	case http.StatusUnprocessableEntity:
-		return newError(ErrTrialInvalid, resp, body)
+		return h.newError(ErrTrialInvalid, resp, body)
 	default:
-		return newError(ErrUnexpected, resp, body)
+		return h.newError(ErrUnexpected, resp, body)
 	}
 }
 
@@ -329,14 +349,14 @@ func (h *httpAPI) AbandonRunningTrial(ctx context.Context, u string) error {
 	case http.StatusNoContent:
 		return nil
 	case http.StatusNotFound:
-		return newError(ErrTrialNotFound, resp, body)
+		return h.newError(ErrTrialNotFound, resp, body)
 	default:
-		return newError(ErrUnexpected, resp, body)
+		return h.newError(ErrUnexpected, resp, body)
 	}
 }
 
 func (h *httpAPI) LabelExperiment(ctx context.Context, u string, lbl ExperimentLabels) error {
-	req, err := httpNewJSONRequest(http.MethodPost, u, lbl)
+	req, err := h.newJSONRequest(http.MethodPost, u, lbl)
 	if err != nil {
 		return err
 	}
@@ -350,16 +370,16 @@ func (h *httpAPI) LabelExperiment(ctx context.Context, u string, lbl ExperimentL
 	case http.StatusCreated:
 		return nil
 	case http.StatusNotFound:
-		return newError(ErrTrialNotFound, resp, body)
+		return h.newError(ErrTrialNotFound, resp, body)
 	case http.StatusUnprocessableEntity:
-		return newError(ErrTrialInvalid, resp, body)
+		return h.newError(ErrTrialInvalid, resp, body)
 	default:
-		return newError(ErrUnexpected, resp, body)
+		return h.newError(ErrUnexpected, resp, body)
 	}
 }
 
 func (h *httpAPI) LabelTrial(ctx context.Context, u string, lbl TrialLabels) error {
-	req, err := httpNewJSONRequest(http.MethodPost, u, lbl)
+	req, err := h.newJSONRequest(http.MethodPost, u, lbl)
 	if err != nil {
 		return err
 	}
@@ -373,17 +393,17 @@ func (h *httpAPI) LabelTrial(ctx context.Context, u string, lbl TrialLabels) err
 	case http.StatusCreated:
 		return nil
 	case http.StatusNotFound:
-		return newError(ErrTrialNotFound, resp, body)
+		return h.newError(ErrTrialNotFound, resp, body)
 	case http.StatusUnprocessableEntity:
-		return newError(ErrTrialInvalid, resp, body)
+		return h.newError(ErrTrialInvalid, resp, body)
 	default:
-		return newError(ErrUnexpected, resp, body)
+		return h.newError(ErrUnexpected, resp, body)
 	}
 }
 
-// httpNewJSONRequest returns a new HTTP request with a JSON payload
-func httpNewJSONRequest(method, u string, body interface{}) (*http.Request, error) {
-	b, err := json.Marshal(body)
+// newJSONRequest returns a new HTTP request with a JSON payload
+func (h *httpAPI) newJSONRequest(method, u string, body interface{}) (*http.Request, error) {
+	b, err := api.ClientCodec(h.client).Marshal(body)
 	if err != nil {
 		return nil, err
 	}
@@ -398,8 +418,8 @@ func httpNewJSONRequest(method, u string, body interface{}) (*http.Request, erro
 }
 
 // newError returns a new error with an API specific error condition, it also captures the details of the response
-func newError(t ErrorType, resp *http.Response, body []byte) error {
-	err := &Error{Type: t}
+func (h *httpAPI) newError(t ErrorType, resp *http.Response, body []byte) error {
+	err := &Error{Type: t, cause: api.DecodeError(h.client, resp, body)}
 
 	// Unmarshal the response body into the error to get the server supplied error message
 	// TODO We should be comparing compatible media types here (e.g. charset)
@@ -412,15 +432,15 @@ func newError(t ErrorType, resp *http.Response, body []byte) error {
 		err.Location = resp.Request.URL.String()
 	}
 
-	// Capture the Retry-After header for "service unavailable"
-	if resp.StatusCode == http.StatusServiceUnavailable {
-		if ra, raerr := strconv.Atoi(resp.Header.Get("Retry-After")); raerr == nil {
-			if ra < 1 {
-				ra = 5
-			} else if ra > 120 {
-				ra = 120
+	// Capture the Retry-After header for "service unavailable" and "too many requests"
+	if resp.StatusCode == http.StatusServiceUnavailable || resp.StatusCode == http.StatusTooManyRequests {
+		if ra, ok := api.RetryAfter(resp.Header); ok {
+			if ra < 1*time.Second {
+				ra = 5 * time.Second
+			} else if ra > 120*time.Second {
+				ra = 120 * time.Second
 			}
-			err.RetryAfter = time.Duration(ra) * time.Second
+			err.RetryAfter = ra
 		}
 	}
 
@@ -457,6 +477,11 @@ func newError(t ErrorType, resp *http.Response, body []byte) error {
 	return err
 }
 
+// UnmarshalMeta extracts resource metadata from the supplied response headers, failures are silently ignored.
+func UnmarshalMeta(header http.Header, meta Meta) {
+	metaUnmarshal(header, meta)
+}
+
 // Extract metadata from the response headers, failures are silently ignored, always call before extracting entity body
 func metaUnmarshal(header http.Header, meta Meta) {
 	if location := header.Get("Location"); location != "" {
@@ -469,31 +494,16 @@ func metaUnmarshal(header http.Header, meta Meta) {
 		}
 	}
 
-	for _, rh := range header[http.CanonicalHeaderKey("Link")] {
-		for _, h := range strings.Split(rh, ",") {
-			var link, rel string
-			for _, l := range strings.Split(h, ";") {
-				l = strings.Trim(l, " ")
-				if l == "" {
-					continue
-				}
-
-				if l[0] == '<' && l[len(l)-1] == '>' {
-					link = strings.Trim(l, "<>")
-					continue
-				}
-
-				p := strings.SplitN(l, "=", 2)
-				if len(p) == 2 && strings.ToLower(p[0]) == "rel" {
-					rel = strings.Trim(p[1], "\"")
-					continue
-				}
-			}
-			meta.SetLink(rel, link)
-		}
+	for rel, link := range metaLinks(header) {
+		meta.SetLink(rel, link.String())
 	}
 }
 
+// metaLinks parses all of the Link headers.
+func metaLinks(header http.Header) map[string]*url.URL {
+	return api.ParseLinkHeader(strings.Join(header.Values("Link"), ","))
+}
+
 // metaMarshal is for reconstructing HTTP headers from the unmarshalled metadata.
 func metaMarshal(location string, lastModified time.Time) http.Header {
 	h := make(http.Header)
@@ -0,0 +1,76 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package numstr
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNumberOrString_RoundTrip(t *testing.T) {
+	cases := []string{
+		`9007199254740993`,
+		`-9007199254740993`,
+		`9223372036854775807`,
+		`0.30000000000000004`,
+		`1.0000000000000000000001`,
+		`0`,
+		`"9007199254740993"`,
+		`"abc"`,
+	}
+	for _, c := range cases {
+		t.Run(c, func(t *testing.T) {
+			var v NumberOrString
+			require.NoError(t, json.Unmarshal([]byte(c), &v))
+			b, err := json.Marshal(v)
+			require.NoError(t, err)
+			assert.Equal(t, c, string(b))
+		})
+	}
+}
+
+func TestNumberOrString_MarshalJSON(t *testing.T) {
+	cases := []struct {
+		desc     string
+		value    NumberOrString
+		expected string
+	}{
+		{desc: "zero", value: NumberOrString{}, expected: `0`},
+		{desc: "large int", value: FromInt64(9007199254740993), expected: `9007199254740993`},
+		{desc: "max int", value: FromInt64(math.MaxInt64), expected: `9223372036854775807`},
+		{desc: "whole float", value: FromFloat64(3), expected: `3`},
+		{desc: "large float", value: FromFloat64(1e21), expected: `1000000000000000000000`},
+		{desc: "small float", value: FromFloat64(0.000001), expected: `0.000001`},
+		{desc: "fraction", value: FromFloat64(0.1), expected: `0.1`},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			b, err := json.Marshal(c.value)
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, string(b))
+		})
+	}
+}
+
+func TestNumberOrString_Int64Value(t *testing.T) {
+	v := FromNumber("9007199254740993")
+	assert.Equal(t, int64(9007199254740993), v.Int64Value())
+}
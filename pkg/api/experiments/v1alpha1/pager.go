@@ -0,0 +1,151 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"iter"
+)
+
+// ListAllExperiments returns an iterator over the experiments matching the query, following the "next" links until
+// all pages have been consumed; a link to a page which was already fetched is not followed. Pages are fetched as they
+// are needed. Iteration stops after the first error (which is yielded with a zero value), when the context is done, or
+// after `maxResults` experiments have been produced if it is greater than zero.
+func ListAllExperiments(ctx context.Context, a API, q *ExperimentListQuery, maxResults int) iter.Seq2[ExperimentItem, error] {
+	return func(yield func(ExperimentItem, error) bool) {
+		lst, err := a.GetAllExperiments(ctx, q)
+		visited := visitedPages{}
+		for n := 0; ; {
+			if err != nil {
+				yield(ExperimentItem{}, err)
+				return
+			}
+
+			for i := range lst.Experiments {
+				if maxResults > 0 && n >= maxResults {
+					return
+				}
+				if !yield(lst.Experiments[i], nil) {
+					return
+				}
+				n++
+			}
+
+			if !visited.follow(lst.Next) || (maxResults > 0 && n >= maxResults) {
+				return
+			}
+			if err = ctx.Err(); err == nil {
+				lst, err = a.GetAllExperimentsByPage(ctx, lst.Next)
+			}
+		}
+	}
+}
+
+// ListAllTrials returns an iterator over the trials matching the query, following the "next" links until all pages
+// have been consumed. Iteration behaves the same as `ListAllExperiments`.
+func ListAllTrials(ctx context.Context, a API, u string, q *TrialListQuery, maxResults int) iter.Seq2[TrialItem, error] {
+	return func(yield func(TrialItem, error) bool) {
+		lst, err := a.GetAllTrials(ctx, u, q)
+		visited := visitedPages{u: true}
+		for n := 0; ; {
+			if err != nil {
+				yield(TrialItem{}, err)
+				return
+			}
+
+			for i := range lst.Trials {
+				if maxResults > 0 && n >= maxResults {
+					return
+				}
+				if !yield(lst.Trials[i], nil) {
+					return
+				}
+				n++
+			}
+
+			if !visited.follow(lst.Next) || (maxResults > 0 && n >= maxResults) {
+				return
+			}
+			if err = ctx.Err(); err == nil {
+				lst, err = a.GetAllTrials(ctx, lst.Next, nil)
+			}
+		}
+	}
+}
+
+// ExperimentResult is an experiment (or an error) produced by `ListExperimentsChan`.
+type ExperimentResult struct {
+	// Experiment is the listed experiment, it is the zero value when Err is set.
+	Experiment ExperimentItem
+	// Err is the reason a page of experiments could not be fetched.
+	Err error
+}
+
+// ListExperimentsChan delivers the experiments matching the query on a channel, following the "next" links until all
+// pages have been consumed (see `ListAllExperiments`); it is an alternative to `ListAllExperiments` for code that does
+// not use iterators. The first page is fetched before returning, its error is returned directly. Errors fetching
+// subsequent pages are delivered as the last result on the channel. The channel is closed after the last page or when
+// the context is done.
+//
+// The experiments are produced by a separate goroutine which only exits once the channel is closed: the caller must
+// either drain the channel or cancel the context to avoid leaking it.
+func ListExperimentsChan(ctx context.Context, a API, q *ExperimentListQuery) (<-chan ExperimentResult, error) {
+	lst, err := a.GetAllExperiments(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan ExperimentResult)
+	go func() {
+		defer close(ch)
+		visited := visitedPages{}
+		for {
+			for i := range lst.Experiments {
+				select {
+				case ch <- ExperimentResult{Experiment: lst.Experiments[i]}:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			if !visited.follow(lst.Next) || ctx.Err() != nil {
+				return
+			}
+			if lst, err = a.GetAllExperimentsByPage(ctx, lst.Next); err != nil {
+				select {
+				case ch <- ExperimentResult{Err: err}:
+				case <-ctx.Done():
+				}
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// visitedPages records the pages that have been fetched so a "next" link that leads back to an earlier page (e.g. a
+// server returning a link to the current page) ends the iteration instead of looping forever.
+type visitedPages map[string]bool
+
+// follow returns true if the link should be followed, i.e. it is present and has not been followed already.
+func (v visitedPages) follow(next string) bool {
+	if next == "" || v[next] {
+		return false
+	}
+	v[next] = true
+	return true
+}
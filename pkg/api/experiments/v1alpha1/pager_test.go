@@ -0,0 +1,277 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thestormforge/optimize-go/pkg/api/apitest"
+)
+
+// experimentPage returns a response containing one page of experiments.
+func experimentPage(next string, names ...string) apitest.Response {
+	body := `{"experiments":[`
+	for i, n := range names {
+		if i > 0 {
+			body += ","
+		}
+		body += `{"displayName":"` + n + `"}`
+	}
+	resp := apitest.JSONResponse(http.StatusOK, body+`]}`)
+	if next != "" {
+		resp.Header.Add("Link", `<`+apitest.MockBaseURL+next+`>;rel="next"`)
+	}
+	return resp
+}
+
+func TestListAllExperiments(t *testing.T) {
+	cases := []struct {
+		desc       string
+		maxResults int
+		expected   []string
+		requests   int
+	}{
+		{
+			desc:     "all pages",
+			expected: []string{"a", "b", "c", "d", "e"},
+			requests: 3,
+		},
+		{
+			desc:       "capped",
+			maxResults: 3,
+			expected:   []string{"a", "b", "c"},
+			requests:   2,
+		},
+		{
+			desc:       "capped at page boundary",
+			maxResults: 2,
+			expected:   []string{"a", "b"},
+			requests:   1,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			m := apitest.NewMock().On(http.MethodGet, "/experiments/",
+				experimentPage("/experiments/?offset=2", "a", "b"),
+				experimentPage("/experiments/?offset=4", "c", "d"),
+				experimentPage("", "e"),
+			)
+
+			var actual []string
+			for exp, err := range ListAllExperiments(context.Background(), NewAPI(m), nil, c.maxResults) {
+				if !assert.NoError(t, err) {
+					break
+				}
+				actual = append(actual, exp.DisplayName)
+			}
+			assert.Equal(t, c.expected, actual)
+			assert.Len(t, m.Requests(), c.requests)
+		})
+	}
+}
+
+func TestListAll_NextLoop(t *testing.T) {
+	// The second page links to itself
+	newMock := func() *apitest.Mock {
+		return apitest.NewMock().
+			On(http.MethodGet, "/experiments/",
+				experimentPage("/experiments/?offset=2", "a", "b"),
+				experimentPage("/experiments/?offset=2", "c"),
+			).
+			On(http.MethodGet, "/experiments/my-exp/trials/", apitest.Response{
+				StatusCode: http.StatusOK,
+				Header: http.Header{
+					"Content-Type": {"application/json"},
+					"Link":         {`<` + apitest.MockBaseURL + `/experiments/my-exp/trials/>;rel="next"`},
+				},
+				Body: []byte(`{"trials":[{"number":1}]}`),
+			})
+	}
+
+	t.Run("experiments", func(t *testing.T) {
+		m := newMock()
+		var actual []string
+		for exp, err := range ListAllExperiments(context.Background(), NewAPI(m), nil, 0) {
+			require.NoError(t, err)
+			actual = append(actual, exp.DisplayName)
+		}
+		assert.Equal(t, []string{"a", "b", "c"}, actual)
+		assert.Len(t, m.Requests(), 2)
+	})
+
+	t.Run("trials", func(t *testing.T) {
+		m := newMock()
+		var actual []int64
+		for trial, err := range ListAllTrials(context.Background(), NewAPI(m), apitest.MockBaseURL+"/experiments/my-exp/trials/", nil, 0) {
+			require.NoError(t, err)
+			actual = append(actual, trial.Number)
+		}
+		assert.Equal(t, []int64{1}, actual)
+		assert.Len(t, m.Requests(), 1)
+	})
+
+	t.Run("channel", func(t *testing.T) {
+		m := newMock()
+		ch, err := ListExperimentsChan(context.Background(), NewAPI(m), nil)
+		require.NoError(t, err)
+		var actual []string
+		for r := range ch {
+			require.NoError(t, r.Err)
+			actual = append(actual, r.Experiment.DisplayName)
+		}
+		assert.Equal(t, []string{"a", "b", "c"}, actual)
+		assert.Len(t, m.Requests(), 2)
+	})
+}
+
+func TestListAllExperiments_Canceled(t *testing.T) {
+	m := apitest.NewMock().On(http.MethodGet, "/experiments/", experimentPage("/experiments/?offset=2", "a", "b"))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var actual []string
+	var lastErr error
+	for exp, err := range ListAllExperiments(ctx, NewAPI(m), nil, 0) {
+		if err != nil {
+			lastErr = err
+			break
+		}
+		actual = append(actual, exp.DisplayName)
+		cancel()
+	}
+	assert.Equal(t, []string{"a", "b"}, actual)
+	assert.True(t, errors.Is(lastErr, context.Canceled))
+	assert.Len(t, m.Requests(), 1)
+}
+
+func TestGetAllExperiments_Links(t *testing.T) {
+	resp := experimentPage("/experiments/?offset=2", "a", "b")
+	resp.Header.Add("Link", `<`+apitest.MockBaseURL+`/experiments/?offset=8>; rel="last"; title="last, page"`)
+	m := apitest.NewMock().On(http.MethodGet, "/experiments/", resp)
+
+	lst, err := NewAPI(m).GetAllExperiments(context.Background(), nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, apitest.MockBaseURL+"/experiments/?offset=2", lst.Next)
+		assert.Equal(t, apitest.MockBaseURL+"/experiments/?offset=2", lst.Links["next"].String())
+		assert.Equal(t, apitest.MockBaseURL+"/experiments/?offset=8", lst.Links["last"].String())
+	}
+}
+
+func TestListExperimentsChan(t *testing.T) {
+	m := apitest.NewMock().On(http.MethodGet, "/experiments/",
+		experimentPage("/experiments/?offset=2", "a", "b"),
+		experimentPage("", "c"),
+	)
+
+	ch, err := ListExperimentsChan(context.Background(), NewAPI(m), nil)
+	require.NoError(t, err)
+
+	var actual []string
+	for r := range ch {
+		require.NoError(t, r.Err)
+		actual = append(actual, r.Experiment.DisplayName)
+	}
+	assert.Equal(t, []string{"a", "b", "c"}, actual)
+	assert.Len(t, m.Requests(), 2)
+}
+
+func TestListExperimentsChan_Errors(t *testing.T) {
+	m := apitest.NewMock().On(http.MethodGet, "/experiments/", apitest.JSONResponse(http.StatusInternalServerError, `{"error":"boom"}`))
+	_, err := ListExperimentsChan(context.Background(), NewAPI(m), nil)
+	assert.Error(t, err)
+
+	m = apitest.NewMock().On(http.MethodGet, "/experiments/",
+		experimentPage("/experiments/?offset=2", "a"),
+		apitest.JSONResponse(http.StatusInternalServerError, `{"error":"boom"}`),
+	)
+	ch, err := ListExperimentsChan(context.Background(), NewAPI(m), nil)
+	require.NoError(t, err)
+
+	var results []ExperimentResult
+	for r := range ch {
+		results = append(results, r)
+	}
+	if assert.Len(t, results, 2) {
+		assert.Equal(t, "a", results[0].Experiment.DisplayName)
+		assert.Error(t, results[1].Err)
+	}
+}
+
+func TestListExperimentsChan_Canceled(t *testing.T) {
+	m := apitest.NewMock().On(http.MethodGet, "/experiments/", experimentPage("/experiments/?offset=2", "a", "b"))
+	ctx, cancel := context.WithCancel(context.Background())
+
+	ch, err := ListExperimentsChan(ctx, NewAPI(m), nil)
+	require.NoError(t, err)
+	<-ch
+	cancel()
+
+	// The channel is closed without the consumer draining it
+	for range ch {
+	}
+	assert.Len(t, m.Requests(), 1)
+}
+
+func TestListAllExperiments_Cursor(t *testing.T) {
+	m := apitest.NewMock().On(http.MethodGet, "/experiments/",
+		apitest.JSONResponse(http.StatusOK, `{"experiments":[{"displayName":"a"},{"displayName":"b"}],"next_cursor":"c2"}`),
+		apitest.JSONResponse(http.StatusOK, `{"experiments":[{"displayName":"c"}]}`),
+	)
+
+	var actual []string
+	for exp, err := range ListAllExperiments(context.Background(), NewAPI(m), &ExperimentListQuery{ListOptions: ListOptions{PageSize: 2}}, 0) {
+		require.NoError(t, err)
+		actual = append(actual, exp.DisplayName)
+	}
+	assert.Equal(t, []string{"a", "b", "c"}, actual)
+	if reqs := m.Requests(); assert.Len(t, reqs, 2) {
+		assert.Equal(t, "2", reqs[0].URL.Query().Get("limit"))
+		assert.Empty(t, reqs[0].URL.Query().Get("cursor"))
+		assert.Equal(t, "2", reqs[1].URL.Query().Get("limit"))
+		assert.Equal(t, "c2", reqs[1].URL.Query().Get("cursor"))
+	}
+}
+
+func TestGetAllExperiments_PageToken(t *testing.T) {
+	m := apitest.NewMock().On(http.MethodGet, "/experiments/",
+		apitest.JSONResponse(http.StatusOK, `{"experiments":[{"displayName":"c"}],"next_cursor":"c3"}`),
+		experimentPage("/experiments/?offset=4", "d"),
+	)
+	a := NewAPI(m)
+
+	// Cursors take precedence over offsets
+	lst, err := a.GetAllExperiments(context.Background(), &ExperimentListQuery{Offset: 2, ListOptions: ListOptions{PageToken: "c2"}})
+	require.NoError(t, err)
+	assert.Equal(t, "c3", lst.NextPageToken)
+	assert.Equal(t, apitest.MockBaseURL+"/experiments/?cursor=c3", lst.Next)
+
+	// Servers using offsets do not return a token
+	lst, err = a.GetAllExperiments(context.Background(), &ExperimentListQuery{Offset: 3})
+	require.NoError(t, err)
+	assert.Empty(t, lst.NextPageToken)
+	assert.Equal(t, apitest.MockBaseURL+"/experiments/?offset=4", lst.Next)
+
+	if reqs := m.Requests(); assert.Len(t, reqs, 2) {
+		assert.Equal(t, "cursor=c2", reqs[0].URL.RawQuery)
+		assert.Equal(t, "offset=3", reqs[1].URL.RawQuery)
+	}
+}
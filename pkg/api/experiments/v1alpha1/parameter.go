@@ -18,6 +18,7 @@ package v1alpha1
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -122,3 +123,271 @@ func CheckParameterValue(p *Parameter, v *numstr.NumberOrString) error {
 	}
 	return nil
 }
+
+// TypedParameter is the fully decoded definition of a parameter, one of `IntParameter`, `DoubleParameter` or
+// `CategoricalParameter`.
+type TypedParameter interface {
+	// ParameterName returns the name of the parameter.
+	ParameterName() string
+	// Check validates that the supplied value can be assigned to the parameter.
+	Check(v numstr.NumberOrString) error
+}
+
+// IntParameter is an integer parameter with inclusive bounds.
+type IntParameter struct {
+	Name string
+	Min  int64
+	Max  int64
+}
+
+// ParameterName returns the name of the parameter.
+func (p IntParameter) ParameterName() string { return p.Name }
+
+// Check validates that the supplied value is an integer within the bounds.
+func (p IntParameter) Check(v numstr.NumberOrString) error {
+	if v.IsString {
+		return fmt.Errorf("numeric value must not be a string: %s", v.String())
+	}
+	val, err := v.NumVal.Int64()
+	if err != nil {
+		return fmt.Errorf("integer value must be a whole number: %s", v.String())
+	}
+	if val < p.Min || val > p.Max {
+		return fmt.Errorf("integer value is out of range [%d-%d]: %d", p.Min, p.Max, val)
+	}
+	return nil
+}
+
+// DoubleParameter is a floating point parameter with inclusive bounds.
+type DoubleParameter struct {
+	Name string
+	Min  float64
+	Max  float64
+}
+
+// ParameterName returns the name of the parameter.
+func (p DoubleParameter) ParameterName() string { return p.Name }
+
+// Check validates that the supplied value is a number within the bounds.
+func (p DoubleParameter) Check(v numstr.NumberOrString) error {
+	if v.IsString {
+		return fmt.Errorf("numeric value must not be a string: %s", v.String())
+	}
+	val, err := v.NumVal.Float64()
+	if err != nil {
+		return fmt.Errorf("double value is not a number: %s", v.String())
+	}
+	if val < p.Min || val > p.Max {
+		return fmt.Errorf("double value is out of range [%f-%f]: %f", p.Min, p.Max, val)
+	}
+	return nil
+}
+
+// CategoricalParameter is a parameter whose value must be one of an ordered list of strings.
+type CategoricalParameter struct {
+	Name   string
+	Values []string
+}
+
+// ParameterName returns the name of the parameter.
+func (p CategoricalParameter) ParameterName() string { return p.Name }
+
+// Check validates that the supplied value is one of the allowed values.
+func (p CategoricalParameter) Check(v numstr.NumberOrString) error {
+	if !v.IsString {
+		return fmt.Errorf("categorical value must be a string: %s", v.String())
+	}
+	for _, allowed := range p.Values {
+		if v.StrVal == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("categorical value is out of range: %s [%s]", v.String(), strings.Join(p.Values, ", "))
+}
+
+// Typed decodes the parameter definition into an `IntParameter`, `DoubleParameter` or `CategoricalParameter`.
+func (p *Parameter) Typed() (TypedParameter, error) {
+	switch p.Type {
+	case ParameterTypeCategorical:
+		if len(p.Values) == 0 {
+			return nil, fmt.Errorf("categorical parameter %q has no values", p.Name)
+		}
+		return CategoricalParameter{Name: p.Name, Values: p.Values}, nil
+
+	case ParameterTypeInteger:
+		if p.Bounds == nil {
+			return nil, fmt.Errorf("integer parameter %q has no bounds", p.Name)
+		}
+		min, err := p.Bounds.Min.Int64()
+		if err != nil {
+			return nil, fmt.Errorf("invalid minimum for integer parameter %q: %w", p.Name, err)
+		}
+		max, err := p.Bounds.Max.Int64()
+		if err != nil {
+			return nil, fmt.Errorf("invalid maximum for integer parameter %q: %w", p.Name, err)
+		}
+		return IntParameter{Name: p.Name, Min: min, Max: max}, nil
+
+	case ParameterTypeDouble:
+		if p.Bounds == nil {
+			return nil, fmt.Errorf("double parameter %q has no bounds", p.Name)
+		}
+		min, err := p.Bounds.Min.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("invalid minimum for double parameter %q: %w", p.Name, err)
+		}
+		max, err := p.Bounds.Max.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("invalid maximum for double parameter %q: %w", p.Name, err)
+		}
+		return DoubleParameter{Name: p.Name, Min: min, Max: max}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown parameter type: %s", p.Type)
+	}
+}
+
+// TypedParameters decodes all of the experiment's parameter definitions.
+func (e *Experiment) TypedParameters() ([]TypedParameter, error) {
+	params := make([]TypedParameter, 0, len(e.Parameters))
+	for i := range e.Parameters {
+		p, err := e.Parameters[i].Typed()
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, p)
+	}
+	return params, nil
+}
+
+// ErrUnknownParameter is reported for assignments to parameters the experiment does not define.
+var ErrUnknownParameter = errors.New("unknown parameter")
+
+// ErrMissingAssignment is reported for parameters of the experiment which were not assigned.
+var ErrMissingAssignment = errors.New("missing assignment for parameter")
+
+// AssignmentViolation describes a single problem found while validating assignments.
+type AssignmentViolation struct {
+	// ParameterName is the name of the offending parameter, empty for constraint violations.
+	ParameterName string
+	// Err is the reason the assignments are invalid.
+	Err error
+}
+
+// Error returns a description of the violation.
+func (v *AssignmentViolation) Error() string {
+	switch {
+	case v.ParameterName == "":
+		return v.Err.Error()
+	case errors.Is(v.Err, ErrUnknownParameter), errors.Is(v.Err, ErrMissingAssignment):
+		return fmt.Sprintf("%s %q", v.Err, v.ParameterName)
+	default:
+		return fmt.Sprintf("invalid assignment for parameter %q: %s", v.ParameterName, v.Err)
+	}
+}
+
+// Unwrap returns the reason the assignments are invalid.
+func (v *AssignmentViolation) Unwrap() error {
+	return v.Err
+}
+
+// AssignmentsError is returned when assignments are not valid for an experiment, it lists every violation found.
+type AssignmentsError struct {
+	Violations []*AssignmentViolation
+}
+
+// Error returns the description of each violation, one per line.
+func (e *AssignmentsError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i := range e.Violations {
+		msgs[i] = e.Violations[i].Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Unwrap returns the individual violations.
+func (e *AssignmentsError) Unwrap() []error {
+	errs := make([]error, len(e.Violations))
+	for i := range e.Violations {
+		errs[i] = e.Violations[i]
+	}
+	return errs
+}
+
+// ValidateAssignments checks that the assignments include a value for every parameter of the experiment, that no
+// unknown parameters are assigned, that every value is within the parameter's bounds (or allowed values) and that
+// the experiment's constraints are satisfied. If there are any problems, an `*AssignmentsError` listing all of them
+// is returned.
+func ValidateAssignments(exp *Experiment, assignments []Assignment) error {
+	params, err := exp.TypedParameters()
+	if err != nil {
+		return err
+	}
+
+	values := make(map[string]numstr.NumberOrString, len(assignments))
+	for _, a := range assignments {
+		values[a.ParameterName] = a.Value
+	}
+
+	aerr := &AssignmentsError{}
+	known := make(map[string]bool, len(params))
+	for _, p := range params {
+		known[p.ParameterName()] = true
+		v, ok := values[p.ParameterName()]
+		if !ok {
+			aerr.Violations = append(aerr.Violations, &AssignmentViolation{ParameterName: p.ParameterName(), Err: ErrMissingAssignment})
+			continue
+		}
+		if err := p.Check(v); err != nil {
+			aerr.Violations = append(aerr.Violations, &AssignmentViolation{ParameterName: p.ParameterName(), Err: err})
+		}
+	}
+	for _, a := range assignments {
+		if !known[a.ParameterName] {
+			aerr.Violations = append(aerr.Violations, &AssignmentViolation{ParameterName: a.ParameterName, Err: ErrUnknownParameter})
+		}
+	}
+
+	// Only evaluate constraints once the individual values are known to be good
+	if len(aerr.Violations) == 0 {
+		for _, c := range exp.Constraints {
+			if err := checkConstraint(&c, values); err != nil {
+				aerr.Violations = append(aerr.Violations, &AssignmentViolation{Err: err})
+			}
+		}
+	}
+
+	if len(aerr.Violations) > 0 {
+		return aerr
+	}
+	return nil
+}
+
+// checkConstraint validates that the supplied values satisfy a constraint.
+func checkConstraint(c *Constraint, values map[string]numstr.NumberOrString) error {
+	name := c.Name
+	if name == "" {
+		name = string(c.ConstraintType)
+	}
+
+	switch c.ConstraintType {
+	case ConstraintSum:
+		var sum float64
+		for _, p := range c.SumConstraint.Parameters {
+			v := values[p.Name]
+			sum += p.Weight * v.Float64Value()
+		}
+		if c.IsUpperBound && sum > c.Bound {
+			return fmt.Errorf("constraint %q is not satisfied: %f exceeds %f", name, sum, c.Bound)
+		}
+		if !c.IsUpperBound && sum < c.Bound {
+			return fmt.Errorf("constraint %q is not satisfied: %f is less than %f", name, sum, c.Bound)
+		}
+	case ConstraintOrder:
+		lower, upper := values[c.LowerParameter], values[c.UpperParameter]
+		if lower.Float64Value() > upper.Float64Value() {
+			return fmt.Errorf("constraint %q is not satisfied: %s must not exceed %s", name, c.LowerParameter, c.UpperParameter)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1/numstr"
+)
+
+func TestExperiment_TypedParameters(t *testing.T) {
+	exp := Experiment{}
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"metrics":[{"name":"cost","minimize":true},{"name":"throughput"},{"name":"duration","optimize":false}],
+		"parameters":[
+			{"name":"replicas","type":"int","bounds":{"min":1,"max":5}},
+			{"name":"ratio","type":"double","bounds":{"min":0.1,"max":0.9}},
+			{"name":"gc","type":"categorical","values":["serial","parallel","g1"]}
+		]
+	}`), &exp))
+
+	params, err := exp.TypedParameters()
+	require.NoError(t, err)
+	assert.Equal(t, []TypedParameter{
+		IntParameter{Name: "replicas", Min: 1, Max: 5},
+		DoubleParameter{Name: "ratio", Min: 0.1, Max: 0.9},
+		CategoricalParameter{Name: "gc", Values: []string{"serial", "parallel", "g1"}},
+	}, params)
+
+	assert.Equal(t, []Objective{{Name: "cost", Minimize: true}, {Name: "throughput"}}, exp.Objectives())
+}
+
+func TestValidateAssignments(t *testing.T) {
+	exp := &Experiment{
+		Parameters: []Parameter{
+			{Name: "min", Type: ParameterTypeInteger, Bounds: &Bounds{Min: "1", Max: "10"}},
+			{Name: "max", Type: ParameterTypeInteger, Bounds: &Bounds{Min: "1", Max: "10"}},
+			{Name: "gc", Type: ParameterTypeCategorical, Values: []string{"serial", "g1"}},
+		},
+		Constraints: []Constraint{
+			{Name: "min-max", ConstraintType: ConstraintOrder, OrderConstraint: OrderConstraint{LowerParameter: "min", UpperParameter: "max"}},
+		},
+	}
+
+	cases := []struct {
+		desc        string
+		assignments []Assignment
+		expectedErr []string
+	}{
+		{
+			desc: "valid",
+			assignments: []Assignment{
+				{ParameterName: "min", Value: numstr.FromInt64(2)},
+				{ParameterName: "max", Value: numstr.FromInt64(4)},
+				{ParameterName: "gc", Value: numstr.FromString("g1")},
+			},
+		},
+		{
+			desc: "out of bounds",
+			assignments: []Assignment{
+				{ParameterName: "min", Value: numstr.FromInt64(0)},
+				{ParameterName: "max", Value: numstr.FromNumber("4.5")},
+				{ParameterName: "gc", Value: numstr.FromString("cms")},
+			},
+			expectedErr: []string{
+				`invalid assignment for parameter "min": integer value is out of range [1-10]: 0`,
+				`invalid assignment for parameter "max": integer value must be a whole number: 4.5`,
+				`invalid assignment for parameter "gc": categorical value is out of range: cms [serial, g1]`,
+			},
+		},
+		{
+			desc: "missing and unknown",
+			assignments: []Assignment{
+				{ParameterName: "min", Value: numstr.FromInt64(2)},
+				{ParameterName: "max", Value: numstr.FromInt64(4)},
+				{ParameterName: "heap", Value: numstr.FromInt64(4)},
+			},
+			expectedErr: []string{
+				`missing assignment for parameter "gc"`,
+				`unknown parameter "heap"`,
+			},
+		},
+		{
+			desc: "constraint",
+			assignments: []Assignment{
+				{ParameterName: "min", Value: numstr.FromInt64(5)},
+				{ParameterName: "max", Value: numstr.FromInt64(4)},
+				{ParameterName: "gc", Value: numstr.FromString("serial")},
+			},
+			expectedErr: []string{
+				`constraint "min-max" is not satisfied: min must not exceed max`,
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			err := ValidateAssignments(exp, c.assignments)
+			if len(c.expectedErr) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			var aerr *AssignmentsError
+			if assert.True(t, errors.As(err, &aerr)) {
+				assert.Len(t, aerr.Violations, len(c.expectedErr))
+				assert.Equal(t, c.expectedErr, strings.Split(err.Error(), "\n"))
+			}
+		})
+	}
+	err := ValidateAssignments(exp, []Assignment{{ParameterName: "min", Value: numstr.FromInt64(2)}})
+	assert.True(t, errors.Is(err, ErrMissingAssignment))
+	assert.False(t, errors.Is(err, ErrUnknownParameter))
+}
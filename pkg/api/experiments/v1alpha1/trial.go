@@ -17,6 +17,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/url"
 	"strconv"
@@ -27,8 +28,9 @@ import (
 )
 
 type TrialMeta struct {
-	SelfURL   string `json:"-"`
-	LabelsURL string `json:"-"`
+	SelfURL    string `json:"-"`
+	LabelsURL  string `json:"-"`
+	APIVersion string `json:"-"`
 }
 
 func (m *TrialMeta) SetLocation(location string) { m.SelfURL = location }
@@ -64,8 +66,8 @@ type TrialAssignments struct {
 type Value struct {
 	// The name of the metric in the experiment the value corresponds to.
 	MetricName string `json:"metricName"`
-	// The observed value of the metric.
-	Value float64 `json:"value"`
+	// The observed value of the metric, represented exactly as it was reported.
+	Value numstr.NumberOrString `json:"value"`
 	// The observed error of the metric.
 	Error float64 `json:"error,omitempty"`
 }
@@ -79,12 +81,16 @@ type TrialValues struct {
 	FailureReason string `json:"failureReason,omitempty"`
 	// FailureMessage is a human-readable explanation of the failure, if Failed is true.
 	FailureMessage string `json:"failureMessage,omitempty"`
+	// FailureDetails is optional structured information about the failure, if Failed is true.
+	FailureDetails map[string]string `json:"failureDetails,omitempty"`
 	// StartTime is the time at which the trial was started.
 	StartTime *time.Time `json:"startTime,omitempty"`
 	// CompletionTime is the time at which the trial was completed.
 	CompletionTime *time.Time `json:"completionTime,omitempty"`
 }
 
+// TrialStatus is the current state of a trial. Statuses added by newer versions of the server are decoded as
+// `TrialUnknown`, an empty status indicates the status was not reported.
 type TrialStatus string
 
 const (
@@ -93,8 +99,45 @@ const (
 	TrialCompleted TrialStatus = "completed"
 	TrialFailed    TrialStatus = "failed"
 	TrialAbandoned TrialStatus = "abandoned"
+	TrialUnknown   TrialStatus = "unknown"
 )
 
+// String returns the status value.
+func (s TrialStatus) String() string { return string(s) }
+
+// IsTerminal checks to see if the trial can no longer change state.
+func (s TrialStatus) IsTerminal() bool {
+	switch s {
+	case TrialCompleted, TrialFailed, TrialAbandoned:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsKnown checks to see if the status is one of the statuses defined by this package.
+func (s TrialStatus) IsKnown() bool {
+	switch s {
+	case TrialStaged, TrialActive, TrialCompleted, TrialFailed, TrialAbandoned:
+		return true
+	default:
+		return false
+	}
+}
+
+// UnmarshalJSON decodes the status, mapping unrecognized values to `TrialUnknown`.
+func (s *TrialStatus) UnmarshalJSON(b []byte) error {
+	var v string
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	*s = TrialStatus(v)
+	if v != "" && !s.IsKnown() {
+		*s = TrialUnknown
+	}
+	return nil
+}
+
 type TrialItem struct {
 	TrialAssignments
 	TrialValues
@@ -124,9 +167,11 @@ func (t *TrialItem) Name() string {
 }
 
 type TrialListQuery struct {
+	ListOptions
+
 	// Comma separated list of statuses to fetch.
 	Status []TrialStatus
-	// Comma separated list of label value pairs to match on.
+	// Label value pairs to match on, a trial must match every pair to be included.
 	LabelSelector map[string]string
 }
 
@@ -143,16 +188,37 @@ func (p *TrialListQuery) Encode() string {
 		q.Add("status", strings.Join(strs, ","))
 	}
 	if len(p.LabelSelector) > 0 {
-		ls := make([]string, 0, len(p.LabelSelector))
-		for k, v := range p.LabelSelector {
-			ls = append(ls, fmt.Sprintf("%s=%s", k, v))
-		}
-		q.Add("labelSelector", strings.Join(ls, ","))
+		q.Add("labelSelector", encodeLabelSelector(p.LabelSelector))
 	}
+	p.ListOptions.encode(q)
 	return q.Encode()
 }
 
+type TrialListMeta struct {
+	Next string `json:"-"`
+	Prev string `json:"-"`
+
+	// NextPageToken is the cursor for the next page, empty if there are no more pages or the server uses offsets.
+	NextPageToken string `json:"next_cursor,omitempty"`
+
+	// Links contains all of the links from the response, indexed by relation type.
+	Links map[string]*url.URL `json:"-"`
+}
+
+func (m *TrialListMeta) SetLocation(string)        {}
+func (m *TrialListMeta) SetLastModified(time.Time) {}
+func (m *TrialListMeta) SetLink(rel, link string) {
+	switch strings.ToLower(rel) {
+	case relationNext:
+		m.Next = link
+	case relationPrev, relationPrevious:
+		m.Prev = link
+	}
+}
+
 type TrialList struct {
+	TrialListMeta
+
 	// The list of trials.
 	Trials []TrialItem `json:"trials"`
 
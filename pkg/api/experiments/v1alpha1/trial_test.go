@@ -1,9 +1,11 @@
 package v1alpha1
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestSplitTrialName(t *testing.T) {
@@ -61,3 +63,46 @@ func TestSplitTrialName(t *testing.T) {
 		})
 	}
 }
+
+func TestTrialItem_RoundTrip(t *testing.T) {
+	in := `{"assignments":[{"parameterName":"seed","value":9007199254740993},{"parameterName":"ratio","value":0.30000000000000004}],` +
+		`"values":[{"metricName":"requests","value":9007199254740993},{"metricName":"cost","value":1.5}],"status":"completed","number":1}`
+
+	trial := TrialItem{}
+	require.NoError(t, json.Unmarshal([]byte(in), &trial))
+	assert.Equal(t, int64(9007199254740993), trial.Assignments[0].Value.Int64Value())
+	assert.Equal(t, "9007199254740993", trial.Values[0].Value.String())
+
+	out, err := json.Marshal(&trial)
+	require.NoError(t, err)
+	assert.JSONEq(t, in, string(out))
+	assert.Contains(t, string(out), `"value":9007199254740993`)
+}
+
+func TestTrialStatus(t *testing.T) {
+	cases := []struct {
+		in       string
+		expected TrialStatus
+		terminal bool
+	}{
+		{in: `"staged"`, expected: TrialStaged},
+		{in: `"active"`, expected: TrialActive},
+		{in: `"completed"`, expected: TrialCompleted, terminal: true},
+		{in: `"failed"`, expected: TrialFailed, terminal: true},
+		{in: `"abandoned"`, expected: TrialAbandoned, terminal: true},
+		{in: `"paused"`, expected: TrialUnknown},
+		{in: `""`, expected: ""},
+	}
+	for _, c := range cases {
+		t.Run(c.in, func(t *testing.T) {
+			var s TrialStatus
+			require.NoError(t, json.Unmarshal([]byte(c.in), &s))
+			assert.Equal(t, c.expected, s)
+			assert.Equal(t, c.terminal, s.IsTerminal())
+			assert.Equal(t, string(c.expected), s.String())
+		})
+	}
+
+	var s TrialStatus
+	assert.Error(t, json.Unmarshal([]byte(`1`), &s))
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiments
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/thestormforge/optimize-go/pkg/api"
+	"github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+)
+
+const (
+	// watchBufferSize is the number of events that can be buffered by a watch before the stream stops being read.
+	watchBufferSize = 64
+	// watchMaxReconnects is the number of consecutive failed attempts to reconnect before a watch gives up.
+	watchMaxReconnects = 5
+	// watchReconnectDelay is the default amount of time to wait before reconnecting.
+	watchReconnectDelay = time.Second
+)
+
+// TrialEvent is a change to the status of a trial delivered by the server.
+type TrialEvent struct {
+	// ID is the server assigned event identifier.
+	ID string
+	// Type is the type of event, e.g. "created" or "updated".
+	Type string
+	// Trial is the current state of the trial.
+	Trial v1alpha1.TrialItem
+	// Err is set when the event could not be decoded or the watch could not be resumed; no events are delivered
+	// after a reconnection failure.
+	Err error
+}
+
+func (h *httpAPI) WatchTrials(ctx context.Context, experiment string) (<-chan TrialEvent, error) {
+	u := h.experimentURL(experiment) + "/trials/"
+	body, err := h.openEventStream(ctx, u, "")
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan TrialEvent, watchBufferSize)
+	go func() {
+		defer close(ch)
+
+		w := &eventWatch{ctx: ctx, ch: ch, codec: api.ClientCodec(h.client), delay: watchReconnectDelay}
+		for {
+			clean := w.read(body)
+			_ = body.Close()
+			if clean || ctx.Err() != nil {
+				return
+			}
+
+			// Resume the stream from the last event we saw
+			for failures := 0; ; {
+				if api.ClientClock(h.client).Sleep(ctx, w.delay) != nil {
+					return
+				}
+
+				if body, err = h.openEventStream(ctx, u, w.lastID); err == nil {
+					break
+				}
+				if failures++; failures >= watchMaxReconnects || ctx.Err() != nil {
+					w.send(TrialEvent{Err: err})
+					return
+				}
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// openEventStream requests the event stream at the specified location. The client timeout would also limit the time
+// spent reading the stream, so it is disabled; the stream remains open until the context is done.
+func (h *httpAPI) openEventStream(ctx context.Context, u, lastEventID string) (io.ReadCloser, error) {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	resp, body, err := h.client.DoStream(api.WithRequestOption(ctx, api.WithRequestTimeout(0)), req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer body.Close()
+		b, _ := io.ReadAll(io.LimitReader(body, 1<<20))
+		return nil, api.DecodeError(h.client, resp, b)
+	}
+
+	return body, nil
+}
+
+// eventWatch holds the state of a watch across reconnects.
+type eventWatch struct {
+	ctx    context.Context
+	ch     chan<- TrialEvent
+	codec  api.Codec
+	lastID string
+	delay  time.Duration
+}
+
+// read parses a `text/event-stream` and delivers the events, returning true if the stream ended cleanly or the
+// context is done.
+func (w *eventWatch) read(r io.Reader) bool {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), 1<<20)
+
+	var id, event string
+	var data strings.Builder
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+
+		// A blank line dispatches the event
+		if line == "" {
+			if data.Len() > 0 {
+				w.lastID = id
+				if !w.send(newTrialEvent(w.codec, id, event, data.String())) {
+					return true
+				}
+			}
+			event = ""
+			data.Reset()
+			continue
+		}
+
+		field, value := line, ""
+		if i := strings.IndexByte(line, ':'); i >= 0 {
+			field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+		switch field {
+		case "": // Comment
+		case "id":
+			id = value
+		case "event":
+			event = value
+		case "data":
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+				w.delay = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+
+	return scanner.Err() == nil || errors.Is(scanner.Err(), context.Canceled)
+}
+
+// send delivers an event, blocking until there is room in the buffer or returning false if the context is done.
+func (w *eventWatch) send(e TrialEvent) bool {
+	select {
+	case w.ch <- e:
+		return true
+	case <-w.ctx.Done():
+		return false
+	}
+}
+
+// newTrialEvent decodes the data of an event.
+func newTrialEvent(codec api.Codec, id, event, data string) TrialEvent {
+	e := TrialEvent{ID: id, Type: event}
+	if e.Type == "" {
+		e.Type = "message"
+	}
+	if err := codec.Unmarshal([]byte(data), &e.Trial); err != nil {
+		e.Err = err
+		return e
+	}
+	v1alpha1.UnmarshalMeta(http.Header(e.Trial.Metadata), &e.Trial.TrialAssignments.TrialMeta)
+	return e
+}
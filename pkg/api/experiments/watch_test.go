@@ -0,0 +1,116 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiments
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thestormforge/optimize-go/pkg/api"
+)
+
+func TestAPI_WatchTrials(t *testing.T) {
+	var connections int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/experiments/my-exp/trials/", r.URL.Path)
+		assert.Equal(t, "text/event-stream", r.Header.Get("Accept"))
+		w.Header().Set("Content-Type", "text/event-stream")
+
+		switch atomic.AddInt32(&connections, 1) {
+		case 1:
+			_, _ = fmt.Fprint(w, ": comment\nretry: 1\n\nid: 1\nevent: created\ndata: {\"number\":1,\ndata: \"status\":\"active\"}\n\n")
+			_, _ = fmt.Fprint(w, "id: 2\ndata: {\"num")
+			w.(http.Flusher).Flush()
+
+			// Simulate a lost connection in the middle of an event
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			_ = conn.Close()
+		case 2:
+			assert.Equal(t, "1", r.Header.Get("Last-Event-ID"))
+			_, _ = fmt.Fprint(w, "id: 2\r\nevent: updated\r\ndata: {\"number\":1,\"status\":\"completed\"}\r\n\r\n")
+		}
+	}))
+	defer ts.Close()
+
+	endpoints, err := api.Endpoints(ts.URL, nil)
+	require.NoError(t, err)
+	client, err := api.NewClient(context.Background(), api.StaticTokenConfig("", endpoints))
+	require.NoError(t, err)
+	a := NewAPI(client)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ch, err := a.WatchTrials(ctx, "my-exp")
+	require.NoError(t, err)
+
+	var events []TrialEvent
+	for e := range ch {
+		require.NoError(t, e.Err)
+		events = append(events, e)
+	}
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, "1", events[0].ID)
+		assert.Equal(t, "created", events[0].Type)
+		assert.Equal(t, "active", string(events[0].Trial.Status))
+		assert.Equal(t, "2", events[1].ID)
+		assert.Equal(t, "updated", events[1].Type)
+		assert.Equal(t, "completed", string(events[1].Trial.Status))
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&connections))
+}
+
+func TestAPI_WatchTrials_ClientTimeout(t *testing.T) {
+	var connections int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&connections, 1)
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprint(w, "id: 1\ndata: {\"number\":1,\"status\":\"active\"}\n\n")
+		w.(http.Flusher).Flush()
+
+		// Keep the stream open longer than the client timeout
+		time.Sleep(200 * time.Millisecond)
+		_, _ = fmt.Fprint(w, "id: 2\ndata: {\"number\":1,\"status\":\"completed\"}\n\n")
+	}))
+	defer ts.Close()
+
+	endpoints, err := api.Endpoints(ts.URL, nil)
+	require.NoError(t, err)
+	client, err := api.NewClient(context.Background(), api.StaticTokenConfig("", endpoints), api.WithTimeout(50*time.Millisecond))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ch, err := NewAPI(client).WatchTrials(ctx, "my-exp")
+	require.NoError(t, err)
+
+	var ids []string
+	for e := range ch {
+		require.NoError(t, e.Err)
+		ids = append(ids, e.ID)
+	}
+	assert.Equal(t, []string{"1", "2"}, ids)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&connections))
+}
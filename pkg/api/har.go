@@ -0,0 +1,380 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptrace"
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// WithHARRecorder records every request and response, writing them to the supplied writer as an HTTP Archive (HAR 1.2)
+// when the client is closed or `FlushHAR` is called, so interactions can be shared with tools that do not speak Go.
+// Credentials, including authorization headers and cookies, are redacted. If bodies are included they are buffered in
+// memory, with the exception of event streams whose bodies are never recorded. Entries are recorded once the response
+// body is closed.
+func WithHARRecorder(w io.Writer, includeBodies bool) Option {
+	return func(c *httpClient) {
+		c.har = &harRecorder{w: w, bodies: includeBodies}
+	}
+}
+
+// FlushHAR writes the interactions recorded by a client created using `WithHARRecorder` since the last flush, see
+// `(*httpClient).FlushHAR`. Clients that do not record interactions are left unchanged.
+func FlushHAR(c Client) error {
+	if fc, ok := c.(interface{ FlushHAR() error }); ok {
+		return fc.FlushHAR()
+	}
+	return nil
+}
+
+// FlushHAR writes a complete archive of the interactions recorded since the last flush, nothing is written if
+// there are none (or the client does not record interactions).
+func (c *httpClient) FlushHAR() error {
+	if c.har == nil {
+		return nil
+	}
+	return c.har.flush()
+}
+
+// harRecorder accumulates HAR entries.
+type harRecorder struct {
+	w      io.Writer
+	bodies bool
+
+	mu      sync.Mutex
+	entries []harEntry
+}
+
+// record adds a completed entry.
+func (r *harRecorder) record(e harEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, e)
+}
+
+// flush writes the accumulated entries as an archive.
+func (r *harRecorder) flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.entries) == 0 {
+		return nil
+	}
+
+	entries := r.entries
+	r.entries = nil
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].started.Before(entries[j].started) })
+
+	h := har{Log: harLog{Version: "1.2", Creator: harCreator{Name: "optimize-go", Version: Version}, Entries: entries}}
+	b, err := json.MarshalIndent(&h, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = r.w.Write(append(b, '\n'))
+	return err
+}
+
+type har struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	started time.Time
+
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+	Error       string         `json:"_error,omitempty"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// harTimings are expressed in milliseconds, using -1 for phases that do not apply.
+type harTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+// harTransport records requests and responses.
+type harTransport struct {
+	recorder *harRecorder
+	base     http.RoundTripper
+}
+
+// RoundTrip performs the round trip, recording the entry once the response body is closed.
+func (t *harTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if t.recorder.bodies && req.Body != nil && req.Body != http.NoBody {
+		var err error
+		reqBody, err = ioutil.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		// Replace the body on a copy of the request so the original is not consumed
+		req = req.Clone(req.Context())
+		req.Body = ioutil.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	var mu sync.Mutex
+	var timings ConnectionTimings
+	trace := NewConnectionTrace(func(ct ConnectionTimings) {
+		mu.Lock()
+		defer mu.Unlock()
+		timings = ct
+	})
+	e := &harEntry{started: time.Now(), Request: newHARRequest(req, reqBody, t.recorder.bodies)}
+	resp, err := transport(t.base).RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+	waited := time.Since(e.started)
+
+	mu.Lock()
+	e.Timings = newHARTimings(timings, waited)
+	mu.Unlock()
+
+	if err != nil {
+		e.Response = harResponse{HTTPVersion: req.Proto, Cookies: []harNameValue{}, Headers: []harNameValue{}, HeadersSize: -1, BodySize: -1, Error: err.Error()}
+		e.finish(0)
+		t.recorder.record(*e)
+		return nil, err
+	}
+
+	e.Request.HTTPVersion = resp.Proto
+	e.Response = harResponse{
+		Status:      resp.StatusCode,
+		StatusText:  http.StatusText(resp.StatusCode),
+		HTTPVersion: resp.Proto,
+		Cookies:     []harNameValue{},
+		Headers:     harHeaders(resp.Header),
+		Content:     harContent{MimeType: resp.Header.Get("Content-Type")},
+		RedirectURL: resp.Header.Get("Location"),
+		HeadersSize: -1,
+	}
+	resp.Body = &harBody{
+		ReadCloser: resp.Body,
+		entry:      e,
+		recorder:   t.recorder,
+		capture:    t.recorder.bodies && !isMediaType(resp.Header, "text/event-stream"),
+		received:   time.Now(),
+	}
+	return resp, nil
+}
+
+// newHARRequest returns the HAR representation of a request.
+func newHARRequest(req *http.Request, body []byte, bodies bool) harRequest {
+	r := harRequest{
+		Method:      req.Method,
+		URL:         req.URL.Redacted(),
+		HTTPVersion: req.Proto,
+		Cookies:     []harNameValue{},
+		Headers:     harHeaders(req.Header),
+		QueryString: []harNameValue{},
+		HeadersSize: -1,
+		BodySize:    req.ContentLength,
+	}
+	if req.Body == nil || req.Body == http.NoBody {
+		r.BodySize = 0
+	}
+
+	q := req.URL.Query()
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range q[k] {
+			r.QueryString = append(r.QueryString, harNameValue{Name: k, Value: v})
+		}
+	}
+
+	if bodies && body != nil {
+		r.BodySize = int64(len(body))
+		r.PostData = &harPostData{MimeType: req.Header.Get("Content-Type"), Text: string(body)}
+	}
+	return r
+}
+
+// harHeaders returns the HAR representation of the supplied headers, redacting credentials.
+func harHeaders(header http.Header) []harNameValue {
+	header = redactHeaders(header)
+	keys := make([]string, 0, len(header))
+	for k := range header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	nvs := []harNameValue{}
+	for _, k := range keys {
+		for _, v := range header[k] {
+			nvs = append(nvs, harNameValue{Name: k, Value: v})
+		}
+	}
+	return nvs
+}
+
+// newHARTimings returns the HAR timings given the connection timings and the time spent waiting for the response.
+func newHARTimings(ct ConnectionTimings, waited time.Duration) harTimings {
+	t := harTimings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1}
+	wait := waited
+	if !ct.Reused {
+		t.DNS = millis(ct.DNS)
+		t.Connect = millis(ct.Connect + ct.TLSHandshake)
+		wait -= ct.DNS + ct.Connect + ct.TLSHandshake
+		if ct.TLSHandshake > 0 {
+			t.SSL = millis(ct.TLSHandshake)
+		}
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	t.Wait = millis(wait)
+	return t
+}
+
+// finish completes the entry once the response has been received.
+func (e *harEntry) finish(receive time.Duration) {
+	e.Timings.Receive = millis(receive)
+	e.StartedDateTime = e.started.Format("2006-01-02T15:04:05.000Z07:00")
+
+	// The SSL time is already included in the connect time
+	e.Time = e.Timings.Send + e.Timings.Wait + e.Timings.Receive
+	for _, d := range []float64{e.Timings.Blocked, e.Timings.DNS, e.Timings.Connect} {
+		if d > 0 {
+			e.Time += d
+		}
+	}
+}
+
+// millis converts a duration to fractional milliseconds.
+func millis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// harBody records the entry when the response body is closed.
+type harBody struct {
+	io.ReadCloser
+	entry    *harEntry
+	recorder *harRecorder
+	capture  bool
+	received time.Time
+
+	mu   sync.Mutex
+	buf  bytes.Buffer
+	size int64
+	once sync.Once
+}
+
+// Read reads from the underlying body, capturing the content if necessary.
+func (b *harBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.size += int64(n)
+	if b.capture {
+		b.buf.Write(p[:n])
+	}
+	return n, err
+}
+
+// Close closes the underlying body and records the entry.
+func (b *harBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.entry.Response.BodySize = b.size
+		b.entry.Response.Content.Size = b.size
+		if b.capture && b.size > 0 {
+			if utf8.Valid(b.buf.Bytes()) {
+				b.entry.Response.Content.Text = b.buf.String()
+			} else {
+				b.entry.Response.Content.Text = base64.StdEncoding.EncodeToString(b.buf.Bytes())
+				b.entry.Response.Content.Encoding = "base64"
+			}
+		}
+		b.entry.finish(time.Since(b.received))
+		b.recorder.record(*b.entry)
+	})
+	return err
+}
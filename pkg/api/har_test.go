@@ -0,0 +1,130 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithHARRecorder(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write(append([]byte("echo: "), b...))
+	}))
+	defer ts.Close()
+
+	type archive struct {
+		Log struct {
+			Version string
+			Entries []struct {
+				StartedDateTime string
+				Time            float64
+				Request         struct {
+					Method      string
+					URL         string
+					Headers     []harNameValue
+					QueryString []harNameValue
+					PostData    *harPostData
+					BodySize    int64
+				}
+				Response struct {
+					Status  int
+					Content harContent
+					Error   string `json:"_error"`
+				}
+				Timings harTimings
+			}
+		}
+	}
+
+	cases := []struct {
+		desc          string
+		includeBodies bool
+	}{
+		{desc: "headers only"},
+		{desc: "bodies", includeBodies: true},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			var out bytes.Buffer
+			client, err := NewClient(context.Background(), StaticTokenConfig("secret-token", map[string]*url.URL{"/": mustParseURL(t, ts.URL)}),
+				WithHARRecorder(&out, c.includeBodies))
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPost, client.URL("/test").String()+"?b=2&a=1", strings.NewReader("request body"))
+			require.NoError(t, err)
+			_, body, err := client.Do(context.Background(), req)
+			require.NoError(t, err)
+			assert.Equal(t, "echo: request body", string(body))
+
+			assert.NotContains(t, out.String(), "secret-token")
+			require.NoError(t, FlushHAR(client))
+
+			var har archive
+			require.NoError(t, json.Unmarshal(out.Bytes(), &har))
+			assert.Equal(t, "1.2", har.Log.Version)
+			require.Len(t, har.Log.Entries, 1)
+			e := har.Log.Entries[0]
+			assert.NotEmpty(t, e.StartedDateTime)
+			assert.GreaterOrEqual(t, e.Time, e.Timings.Wait)
+			assert.Equal(t, http.MethodPost, e.Request.Method)
+			assert.Equal(t, ts.URL+"/test?b=2&a=1", e.Request.URL)
+			assert.Contains(t, e.Request.Headers, harNameValue{Name: "Authorization", Value: "Bearer [REDACTED]"})
+			assert.Equal(t, []harNameValue{{Name: "a", Value: "1"}, {Name: "b", Value: "2"}}, e.Request.QueryString)
+			assert.Equal(t, int64(len("request body")), e.Request.BodySize)
+			assert.Equal(t, http.StatusOK, e.Response.Status)
+			assert.Equal(t, int64(len("echo: request body")), e.Response.Content.Size)
+			assert.Equal(t, "text/plain", e.Response.Content.MimeType)
+			if c.includeBodies {
+				if assert.NotNil(t, e.Request.PostData) {
+					assert.Equal(t, "request body", e.Request.PostData.Text)
+				}
+				assert.Equal(t, "echo: request body", e.Response.Content.Text)
+			} else {
+				assert.Nil(t, e.Request.PostData)
+				assert.Empty(t, e.Response.Content.Text)
+			}
+
+			// Nothing new to flush, failed requests are written when the client is closed
+			out.Reset()
+			require.NoError(t, FlushHAR(client))
+			assert.Empty(t, out.String())
+
+			req, err = http.NewRequest(http.MethodGet, "http://127.0.0.1:0/", nil)
+			require.NoError(t, err)
+			_, _, err = client.Do(context.Background(), req)
+			assert.Error(t, err)
+			require.NoError(t, client.(interface{ Close() error }).Close())
+			require.NoError(t, json.Unmarshal(out.Bytes(), &har))
+			if assert.Len(t, har.Log.Entries, 1) {
+				assert.NotEmpty(t, har.Log.Entries[0].Response.Error)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"log"
+	"net/http"
+)
+
+// WithDefaultHeaders adds headers to every request that does not already set them (e.g. a tenant identifier or a
+// feature flag). Headers set on the request itself always take precedence. Credentials must be supplied through the
+// configuration, so any Authorization or Proxy-Authorization headers are ignored. This option may be supplied
+// multiple times, later values replace earlier values for the same header.
+func WithDefaultHeaders(header http.Header) Option {
+	return func(c *httpClient) {
+		// Always build a new map, a copy of the client made using `With` must not modify the original
+		h := c.defaultHeaders.Clone()
+		if h == nil {
+			h = make(http.Header, len(header))
+		}
+		for k, v := range header {
+			k = http.CanonicalHeaderKey(k)
+			if k == "Authorization" || k == "Proxy-Authorization" {
+				log.Printf("optimize-go: the %s header cannot be set using default headers", k)
+				continue
+			}
+			h[k] = append([]string(nil), v...)
+		}
+		c.defaultHeaders = h
+	}
+}
+
+// setDefaultHeaders adds the default headers that are not already present.
+func setDefaultHeaders(header, defaults http.Header) {
+	for k, v := range defaults {
+		if _, ok := header[k]; !ok {
+			header[k] = append([]string(nil), v...)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithDefaultHeaders(t *testing.T) {
+	var received http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+	}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts, WithDefaultHeaders(http.Header{
+		"x-tenant":      {"acme"},
+		"X-Feature":     {"beta"},
+		"Authorization": {"Bearer leaked"},
+	}))
+	do := func(c Client, header http.Header) {
+		req, err := http.NewRequest(http.MethodGet, c.URL("/").String(), nil)
+		require.NoError(t, err)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		_, _, err = c.Do(context.Background(), req)
+		require.NoError(t, err)
+	}
+
+	do(client, nil)
+	assert.Equal(t, "acme", received.Get("X-Tenant"))
+	assert.Equal(t, "beta", received.Get("X-Feature"))
+	assert.Empty(t, received.Get("Authorization"))
+
+	// Headers on the request take precedence
+	do(client, http.Header{"X-Tenant": {"globex"}})
+	assert.Equal(t, []string{"globex"}, received.Values("X-Tenant"))
+	assert.Equal(t, "beta", received.Get("X-Feature"))
+
+	// Derived clients can add headers without changing the original
+	derived := With(client, WithDefaultHeaders(http.Header{"X-Feature": {"gamma"}, "X-Subsystem": {"reports"}}))
+	do(derived, nil)
+	assert.Equal(t, "acme", received.Get("X-Tenant"))
+	assert.Equal(t, "gamma", received.Get("X-Feature"))
+	assert.Equal(t, "reports", received.Get("X-Subsystem"))
+	do(client, nil)
+	assert.Equal(t, "beta", received.Get("X-Feature"))
+	assert.Empty(t, received.Get("X-Subsystem"))
+}
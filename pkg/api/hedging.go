@@ -0,0 +1,154 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// WithHedging sends up to `maxExtra` additional copies of an idempotent request if a response has not been received
+// within `delay` of the previous attempt; the first response received is used and the remaining attempts are
+// cancelled. No further attempts are sent once the request context is done; the context error is returned as-is so
+// callers can distinguish cancellation from an expired deadline. Hedging reduces tail latency at the cost of additional
+// load on the server, so it is disabled by default.
+func WithHedging(delay time.Duration, maxExtra int) Option {
+	return func(c *httpClient) {
+		c.hedgeDelay = delay
+		c.hedgeMaxExtra = maxExtra
+	}
+}
+
+// hedgingTransport races multiple attempts of the same request.
+type hedgingTransport struct {
+	delay    time.Duration
+	maxExtra int
+	clock    Clock
+	base     http.RoundTripper
+}
+
+// hedgeResult is the outcome of a single attempt.
+type hedgeResult struct {
+	attempt int
+	resp    *http.Response
+	err     error
+}
+
+// RoundTrip returns the first response produced by one of the attempts.
+func (t *hedgingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.maxExtra <= 0 || !isIdempotent(req.Method) {
+		return transport(t.base).RoundTrip(req)
+	}
+
+	ctx := req.Context()
+	req = req.Clone(ctx)
+	if err := rewindable(req); err != nil {
+		return nil, err
+	}
+
+	results := make(chan hedgeResult, t.maxExtra+1)
+	var cancels []context.CancelFunc
+	send := func() error {
+		actx, cancel := context.WithCancel(ctx)
+		r := req.Clone(actx)
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				cancel()
+				return err
+			}
+			r.Body = body
+		}
+
+		attempt := len(cancels)
+		cancels = append(cancels, cancel)
+		go func() {
+			resp, err := transport(t.base).RoundTrip(r)
+			results <- hedgeResult{attempt: attempt, resp: resp, err: err}
+		}()
+		return nil
+	}
+
+	// abandon cancels all outstanding attempts, cleaning up any responses they still produce
+	pending := 0
+	abandon := func(except int) {
+		for i, cancel := range cancels {
+			if i != except {
+				cancel()
+			}
+		}
+		go func(n int) {
+			for ; n > 0; n-- {
+				if r := <-results; r.resp != nil {
+					discard(r.resp)
+				}
+			}
+		}(pending)
+	}
+
+	if err := send(); err != nil {
+		return nil, err
+	}
+	pending++
+
+	timer := clockOf(t.clock).NewTimer(t.delay)
+	defer timer.Stop()
+	for {
+		select {
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				abandon(r.attempt)
+				r.resp.Body = &cancelBody{ReadCloser: r.resp.Body, cancel: cancels[r.attempt]}
+				return r.resp, nil
+			}
+
+			cancels[r.attempt]()
+			if pending == 0 {
+				return nil, r.err
+			}
+
+		case <-timer.C():
+			if len(cancels) <= t.maxExtra {
+				if err := send(); err != nil {
+					abandon(-1)
+					return nil, err
+				}
+				pending++
+				timer.Reset(t.delay)
+			}
+
+		case <-ctx.Done():
+			abandon(-1)
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// cancelBody is a response body that cancels the context of the request when it is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close closes the underlying body and cancels the request context.
+func (b *cancelBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
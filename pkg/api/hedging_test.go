@@ -0,0 +1,121 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithHedging(t *testing.T) {
+	var count int32
+	cancelled := make(chan struct{}, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&count, 1)
+		if n == 1 {
+			select {
+			case <-r.Context().Done():
+				cancelled <- struct{}{}
+				return
+			case <-time.After(200 * time.Millisecond):
+			}
+		}
+		_, _ = w.Write([]byte(strconv.Itoa(int(n))))
+	}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts, WithHedging(20*time.Millisecond, 2))
+
+	t.Run("idempotent", func(t *testing.T) {
+		atomic.StoreInt32(&count, 0)
+		req, err := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+		require.NoError(t, err)
+
+		start := time.Now()
+		_, body, err := client.Do(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, "2", string(body))
+		assert.Less(t, time.Since(start), 150*time.Millisecond)
+
+		select {
+		case <-cancelled:
+		case <-time.After(time.Second):
+			assert.Fail(t, "slow attempt was not cancelled")
+		}
+		assert.Equal(t, int32(2), atomic.LoadInt32(&count))
+	})
+
+	t.Run("not idempotent", func(t *testing.T) {
+		atomic.StoreInt32(&count, 0)
+		req, err := http.NewRequest(http.MethodPost, client.URL("/").String(), nil)
+		require.NoError(t, err)
+
+		_, body, err := client.Do(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, "1", string(body))
+		assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+	})
+}
+
+func TestWithHedging_Cancellation(t *testing.T) {
+	var count int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		<-r.Context().Done()
+	}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts, WithHedging(20*time.Millisecond, 5))
+	do := func(ctx context.Context) error {
+		req, err := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+		require.NoError(t, err)
+		_, _, err = client.Do(ctx, req)
+		return err
+	}
+
+	t.Run("cancelled", func(t *testing.T) {
+		atomic.StoreInt32(&count, 0)
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(30*time.Millisecond, cancel)
+
+		err := do(ctx)
+		assert.True(t, errors.Is(err, context.Canceled))
+		assert.False(t, errors.Is(err, context.DeadlineExceeded))
+		sent := atomic.LoadInt32(&count)
+		time.Sleep(50 * time.Millisecond)
+		assert.Equal(t, sent, atomic.LoadInt32(&count), "no hedges after cancellation")
+	})
+
+	t.Run("deadline", func(t *testing.T) {
+		atomic.StoreInt32(&count, 0)
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+		defer cancel()
+
+		err := do(ctx)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		assert.False(t, errors.Is(err, context.Canceled))
+	})
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// HMACConfig authorizes requests by signing them using a shared secret. By default, the signature is the base64
+// encoded HMAC-SHA256 of the following newline separated values:
+//
+//	the request method (e.g. "GET")
+//	the escaped request path and query (e.g. "/v1/experiments/?limit=10")
+//	the value of the Date header
+//	the hex encoded SHA-256 of the request body
+//	the lower case name and value of each signed header (e.g. "host:api.example.com"), one per line
+//
+// The signature is sent as `Authorization: HMAC-SHA256 keyId="...",headers="date host",signature="..."`. A Date
+// header is added to requests that do not have one; each attempt of a retried request is signed separately.
+type HMACConfig struct {
+	// KeyID identifies the shared secret.
+	KeyID string
+	// Secret is the shared secret used to compute the signature.
+	Secret []byte
+	// SignedHeaders are the names of additional request headers included in the signature.
+	SignedHeaders []string
+	// StringToSign overrides the default canonicalization of the request, it is supplied the request and the hex
+	// encoded SHA-256 of the body.
+	StringToSign func(req *http.Request, bodyHash string) string
+	// EndpointURLs maps endpoint prefixes to their locations, see `StaticTokenConfig`.
+	EndpointURLs map[string]*url.URL
+}
+
+// Endpoints returns a resolver for the configured endpoint prefixes.
+func (c *HMACConfig) Endpoints() (func(string) *url.URL, error) {
+	return prefixResolver(c.EndpointURLs), nil
+}
+
+// Authorize returns a transport that signs each request.
+func (c *HMACConfig) Authorize(_ context.Context, transport http.RoundTripper) (http.RoundTripper, error) {
+	return &hmacTransport{config: c, base: transport}, nil
+}
+
+// stringToSign returns the canonical representation of the request.
+func (c *HMACConfig) stringToSign(req *http.Request, bodyHash string) string {
+	if c.StringToSign != nil {
+		return c.StringToSign(req, bodyHash)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(req.Method)
+	sb.WriteByte('\n')
+	sb.WriteString(req.URL.RequestURI())
+	sb.WriteByte('\n')
+	sb.WriteString(req.Header.Get("Date"))
+	sb.WriteByte('\n')
+	sb.WriteString(bodyHash)
+	for _, h := range c.SignedHeaders {
+		sb.WriteByte('\n')
+		sb.WriteString(strings.ToLower(h))
+		sb.WriteByte(':')
+		sb.WriteString(strings.TrimSpace(headerValue(req, h)))
+	}
+	return sb.String()
+}
+
+// headerValue returns the value of a request header, including the host.
+func headerValue(req *http.Request, key string) string {
+	if strings.EqualFold(key, "Host") {
+		if req.Host != "" {
+			return req.Host
+		}
+		return req.URL.Host
+	}
+	return req.Header.Get(key)
+}
+
+// hmacTransport signs requests.
+type hmacTransport struct {
+	config *HMACConfig
+	clock  Clock
+	base   http.RoundTripper
+}
+
+// RoundTrip signs a copy of the request.
+func (t *hmacTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if isCrossOriginRedirect(req) {
+		return transport(t.base).RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+	if req.Header.Get("Date") == "" {
+		req.Header.Set("Date", clockOf(t.clock).Now().UTC().Format(http.TimeFormat))
+	}
+
+	sum := sha256.New()
+	if req.Body != nil && req.Body != http.NoBody {
+		b, err := ioutil.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(b))
+		sum.Write(b)
+	}
+	bodyHash := hex.EncodeToString(sum.Sum(nil))
+
+	mac := hmac.New(sha256.New, t.config.Secret)
+	mac.Write([]byte(t.config.stringToSign(req, bodyHash)))
+
+	headers := []string{"date"}
+	for _, h := range t.config.SignedHeaders {
+		headers = append(headers, strings.ToLower(h))
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf(`HMAC-SHA256 keyId=%q,headers=%q,signature=%q`,
+		t.config.KeyID, strings.Join(headers, " "), base64.StdEncoding.EncodeToString(mac.Sum(nil))))
+	return transport(t.base).RoundTrip(req)
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHMACConfig(t *testing.T) {
+	secret := []byte("shared secret")
+	var attempts int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		body, _ := ioutil.ReadAll(r.Body)
+		sum := sha256.Sum256(body)
+
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(strings.Join([]string{
+			r.Method,
+			r.URL.RequestURI(),
+			r.Header.Get("Date"),
+			hex.EncodeToString(sum[:]),
+			"host:" + r.Host,
+		}, "\n")))
+		expected := fmt.Sprintf(`HMAC-SHA256 keyId="my-key",headers="date host",signature=%q`, base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+
+		switch {
+		case r.Header.Get("Authorization") != expected:
+			w.WriteHeader(http.StatusUnauthorized)
+		case attempts == 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer ts.Close()
+
+	u, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+
+	cfg := &HMACConfig{
+		KeyID:         "my-key",
+		Secret:        secret,
+		SignedHeaders: []string{"Host"},
+		EndpointURLs:  map[string]*url.URL{"/": u},
+	}
+	client, err := NewClient(context.Background(), cfg, WithRetry(2, time.Millisecond))
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPut, client.URL("/experiments/a?x=1").String(), strings.NewReader(`{"budget":10}`))
+	require.NoError(t, err)
+
+	resp, _, err := client.Do(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Equal(t, 2, attempts)
+	assert.Empty(t, req.Header.Get("Date"))
+}
+
+func TestHMACConfig_Clock(t *testing.T) {
+	var date string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		date = r.Header.Get("Date")
+	}))
+	defer ts.Close()
+
+	u, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+
+	// The Date header comes from the clock of the client
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	cfg := &HMACConfig{KeyID: "my-key", Secret: []byte("shared secret"), EndpointURLs: map[string]*url.URL{"/": u}}
+	client, err := NewClient(context.Background(), cfg, WithClock(&sleepRecorder{now: now}))
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+	require.NoError(t, err)
+	_, _, err = client.Do(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "Wed, 01 Jan 2020 00:00:00 GMT", date)
+}
@@ -0,0 +1,45 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import "net/http"
+
+// HeaderIdempotencyKey is the name of the header used to identify repeated attempts of the same logical operation.
+// Servers use the key to deduplicate requests, making it safe to retry requests which would otherwise create duplicate
+// resources (for example, creating a trial).
+const HeaderIdempotencyKey = "Idempotency-Key"
+
+// WithIdempotencyKeyGenerator enables automatic idempotency keys for POST requests which do not already have one.
+// The key is generated once per call to the client, all retry attempts for that call carry the same key; a nil
+// generator uses random UUIDs. Requests with an idempotency key are retried as if they were idempotent.
+func WithIdempotencyKeyGenerator(f func() string) Option {
+	return func(c *httpClient) {
+		if f == nil {
+			f = NewUUID
+		}
+		c.idempotencyKey = f
+	}
+}
+
+// SetIdempotencyKey sets the idempotency key of the request, allowing it to be safely retried. If the supplied key
+// is empty, a random UUID is used.
+func SetIdempotencyKey(req *http.Request, key string) {
+	if key == "" {
+		key = NewUUID()
+	}
+	req.Header.Set(HeaderIdempotencyKey, key)
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithIdempotencyKeyGenerator(t *testing.T) {
+	var mu sync.Mutex
+	var keys []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		keys = append(keys, r.Header.Get(HeaderIdempotencyKey))
+		if len(keys) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer ts.Close()
+
+	var generated int
+	client := newTestClient(t, ts,
+		WithRetry(3, time.Millisecond),
+		WithIdempotencyKeyGenerator(func() string { generated++; return "key" }),
+	)
+	req, err := http.NewRequest(http.MethodPost, client.URL("/trials").String(), strings.NewReader("{}"))
+	require.NoError(t, err)
+
+	resp, _, err := client.Do(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, []string{"key", "key", "key"}, keys)
+	assert.Equal(t, 1, generated)
+	assert.Empty(t, req.Header.Get(HeaderIdempotencyKey), "caller request was modified")
+}
+
+func TestSetIdempotencyKey(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, "http://example.com/", nil)
+	require.NoError(t, err)
+
+	SetIdempotencyKey(req, "")
+	assert.Len(t, req.Header.Get(HeaderIdempotencyKey), 36)
+
+	SetIdempotencyKey(req, "custom")
+	assert.Equal(t, "custom", req.Header.Get(HeaderIdempotencyKey))
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"net/http"
+)
+
+// DoJSON performs the request using the supplied client and decodes the JSON response body into a new value. Any
+// unsuccessful response is returned as an `*Error`; a "204 No Content" response produces the zero value. A successful
+// response that is not one of the supplied media types (by default, "application/json") is returned as a
+// `*ContentTypeError`, see `CheckContentType`.
+func DoJSON[T any](ctx context.Context, c Client, req *http.Request, mediaTypes ...string) (T, error) {
+	var v T
+
+	resp, body, err := c.Do(ctx, req)
+	if err != nil {
+		return v, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return v, DecodeError(c, resp, body)
+	}
+
+	if resp.StatusCode == http.StatusNoContent || len(body) == 0 {
+		return v, nil
+	}
+
+	if err := CheckContentType(resp, body, mediaTypes...); err != nil {
+		return v, err
+	}
+
+	err = ClientCodec(c).Unmarshal(body, &v)
+	return v, err
+}
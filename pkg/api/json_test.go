@@ -0,0 +1,86 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoJSON(t *testing.T) {
+	type thing struct {
+		Name string `json:"name"`
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/thing":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"name":"foo"}`))
+		case "/empty":
+			w.WriteHeader(http.StatusNoContent)
+		case "/proxy":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = w.Write([]byte("<html><body>Bad Gateway</body></html>"))
+		case "/custom":
+			w.Header().Set("Content-Type", "application/vnd.thing+json")
+			_, _ = w.Write([]byte(`{"name":"bar"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts)
+	newRequest := func(ep string) *http.Request {
+		req, err := http.NewRequest(http.MethodGet, client.URL(ep).String(), nil)
+		require.NoError(t, err)
+		return req
+	}
+
+	v, err := DoJSON[thing](context.Background(), client, newRequest("/thing"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, thing{Name: "foo"}, v)
+	}
+
+	v, err = DoJSON[thing](context.Background(), client, newRequest("/empty"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, thing{}, v)
+	}
+
+	_, err = DoJSON[thing](context.Background(), client, newRequest("/missing"))
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	_, err = DoJSON[thing](context.Background(), client, newRequest("/proxy"))
+	var cterr *ContentTypeError
+	if assert.True(t, errors.As(err, &cterr)) {
+		assert.True(t, errors.Is(err, ErrUnexpectedContentType))
+		assert.Equal(t, "text/html; charset=utf-8", cterr.ContentType)
+		assert.Equal(t, "<html><body>Bad Gateway</body></html>", cterr.Snippet)
+	}
+
+	v, err = DoJSON[thing](context.Background(), client, newRequest("/custom"), "application/json", "application/vnd.thing+json")
+	if assert.NoError(t, err) {
+		assert.Equal(t, thing{Name: "bar"}, v)
+	}
+}
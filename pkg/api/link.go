@@ -0,0 +1,127 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"net/url"
+	"strings"
+)
+
+// ParseLinkHeader parses the value of an RFC 8288 (formerly RFC 5988) "Link" header into a map of relation types to
+// target URLs, e.g. the "next", "prev", "first" and "last" pages of a list. Multiple header values may be joined with
+// commas; commas and semicolons inside quoted parameter values are handled correctly. Relation types are lowercase and
+// the first link for each relation type wins. Relative references are returned unresolved, malformed links are ignored.
+func ParseLinkHeader(h string) map[string]*url.URL {
+	links := make(map[string]*url.URL)
+	for {
+		h = strings.TrimLeft(h, " \t,")
+		if h == "" {
+			return links
+		}
+
+		// Each link value must start with the target in angle brackets
+		end := strings.IndexByte(h, '>')
+		if h[0] != '<' || end < 0 {
+			h = skipLinkValue(h)
+			continue
+		}
+		target := h[1:end]
+		h = h[end+1:]
+
+		var rels []string
+		for {
+			h = strings.TrimLeft(h, " \t")
+			if !strings.HasPrefix(h, ";") {
+				break
+			}
+
+			var name, value string
+			name, value, h = nextLinkParam(h[1:])
+			if strings.EqualFold(name, "rel") && rels == nil {
+				rels = strings.Fields(strings.ToLower(value))
+			}
+		}
+
+		// Discard anything unexpected before the next link value
+		h = skipLinkValue(h)
+
+		u, err := url.Parse(target)
+		if err != nil {
+			continue
+		}
+		for _, rel := range rels {
+			if _, ok := links[rel]; !ok {
+				links[rel] = u
+			}
+		}
+	}
+}
+
+// nextLinkParam returns the name and (unquoted) value of the link parameter at the start of the string along with
+// the remainder of the string.
+func nextLinkParam(h string) (name, value, rest string) {
+	h = strings.TrimLeft(h, " \t")
+	i := strings.IndexAny(h, "=;,")
+	if i < 0 || h[i] != '=' {
+		if i < 0 {
+			i = len(h)
+		}
+		return strings.TrimSpace(h[:i]), "", h[i:]
+	}
+	name = strings.TrimSpace(h[:i])
+	h = strings.TrimLeft(h[i+1:], " \t")
+
+	// Token values end at the next delimiter
+	if !strings.HasPrefix(h, `"`) {
+		i = strings.IndexAny(h, ";,")
+		if i < 0 {
+			i = len(h)
+		}
+		return name, strings.TrimSpace(h[:i]), h[i:]
+	}
+
+	// Quoted values may contain delimiters and escaped characters
+	var sb strings.Builder
+	for i = 1; i < len(h); i++ {
+		switch c := h[i]; {
+		case c == '\\' && i+1 < len(h):
+			i++
+			sb.WriteByte(h[i])
+		case c == '"':
+			return name, sb.String(), h[i+1:]
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return name, sb.String(), ""
+}
+
+// skipLinkValue discards everything up to the next comma which is not inside a quoted string.
+func skipLinkValue(h string) string {
+	var quoted bool
+	for i := 0; i < len(h); i++ {
+		switch c := h[i]; {
+		case c == '\\' && quoted:
+			i++
+		case c == '"':
+			quoted = !quoted
+		case c == ',' && !quoted:
+			return h[i+1:]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLinkHeader(t *testing.T) {
+	cases := []struct {
+		desc     string
+		header   string
+		expected map[string]string
+	}{
+		{
+			desc:     "empty",
+			expected: map[string]string{},
+		},
+		{
+			desc:   "pagination",
+			header: `<https://x/experiments/?offset=10>; rel="next", <https://x/experiments/?offset=0>; rel="prev", <https://x/experiments/>; rel=first, <https://x/experiments/?offset=90>; rel="last"`,
+			expected: map[string]string{
+				"next":  "https://x/experiments/?offset=10",
+				"prev":  "https://x/experiments/?offset=0",
+				"first": "https://x/experiments/",
+				"last":  "https://x/experiments/?offset=90",
+			},
+		},
+		{
+			desc:   "commas in quoted values",
+			header: `<https://x/a>; title="a, b; c"; rel="next", <https://x/b>; title="escaped \", quote"; rel="prev"`,
+			expected: map[string]string{
+				"next": "https://x/a",
+				"prev": "https://x/b",
+			},
+		},
+		{
+			desc:   "multiple relation types",
+			header: `<https://x/a>;rel="NEXT last"`,
+			expected: map[string]string{
+				"next": "https://x/a",
+				"last": "https://x/a",
+			},
+		},
+		{
+			desc:   "first link wins",
+			header: `<https://x/a>;rel=next,<https://x/b>;rel=next`,
+			expected: map[string]string{
+				"next": "https://x/a",
+			},
+		},
+		{
+			desc:   "malformed",
+			header: `https://x/a; rel="next", <https://x/b>; rel="prev", <https://x/c; rel="last"`,
+			expected: map[string]string{
+				"prev": "https://x/b",
+			},
+		},
+		{
+			desc:   "relative",
+			header: `</experiments/?offset=10>; rel="next"`,
+			expected: map[string]string{
+				"next": "/experiments/?offset=10",
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			actual := make(map[string]string)
+			for rel, u := range ParseLinkHeader(c.header) {
+				actual[rel] = u.String()
+			}
+			assert.Equal(t, c.expected, actual)
+		})
+	}
+}
+
+func TestParseLinkHeader_URL(t *testing.T) {
+	links := ParseLinkHeader(`<https://x/experiments/?offset=10&limit=5>; rel="next"`)
+	assert.Equal(t, &url.URL{Scheme: "https", Host: "x", Path: "/experiments/", RawQuery: "offset=10&limit=5"}, links["next"])
+}
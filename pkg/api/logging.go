@@ -0,0 +1,146 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultBodyLoggingLimit is the default number of bytes of each body included when body logging is enabled.
+const DefaultBodyLoggingLimit = 1024
+
+// RequestInfo describes a completed request for logging. It never includes request or response headers.
+type RequestInfo struct {
+	// Method is the HTTP method of the request.
+	Method string
+	// URL is the location of the request, excluding any user information.
+	URL string
+	// StatusCode is the status code of the response, or zero if no response was received.
+	StatusCode int
+	// Duration is the time from sending the request until the response body was closed.
+	Duration time.Duration
+	// RequestID is the identifier used to correlate the request with the server logs.
+	RequestID string
+	// RequestBytes is the size of the request body, or -1 if it is unknown.
+	RequestBytes int64
+	// ResponseBytes is the number of response body bytes read.
+	ResponseBytes int64
+	// Connection describes the connection used for the request, only available when connection timings are enabled.
+	Connection ConnectionTimings
+	// Err is the error that prevented the request from completing, if any.
+	Err error
+	// RequestBody is the (possibly truncated) request body, only available when body logging is enabled.
+	RequestBody []byte
+	// ResponseBody is the (possibly truncated) response body, only available when body logging is enabled.
+	ResponseBody []byte
+}
+
+// WithLogger registers a function to be invoked for every request made by the client, including requests which fail
+// without producing a response. For requests that produce a response, the logger is invoked when the response body
+// is closed.
+func WithLogger(logger func(ctx context.Context, info RequestInfo)) Option {
+	return func(c *httpClient) {
+		c.logger = logger
+	}
+}
+
+// WithBodyLogging includes request and response bodies in the logged request information. Bodies are truncated to
+// the body logging limit. Note that request bodies are only available if they can be re-read.
+func WithBodyLogging(enabled bool) Option {
+	return func(c *httpClient) {
+		c.logBodies = enabled
+	}
+}
+
+// WithBodyLoggingLimit sets the maximum number of bytes of each body included when body logging is enabled.
+func WithBodyLoggingLimit(n int) Option {
+	return func(c *httpClient) {
+		c.logBodyLimit = n
+	}
+}
+
+// newRequestInfo returns the initial request information for a request.
+func (c *httpClient) newRequestInfo(req *http.Request) *RequestInfo {
+	u := *req.URL
+	u.User = nil
+
+	info := &RequestInfo{
+		Method:       req.Method,
+		URL:          u.String(),
+		RequestID:    req.Header.Get(HeaderRequestID),
+		RequestBytes: req.ContentLength,
+	}
+	if req.Body == nil || req.Body == http.NoBody {
+		info.RequestBytes = 0
+	}
+
+	if c.logBodies && req.GetBody != nil {
+		if rc, err := req.GetBody(); err == nil {
+			info.RequestBody, _ = ioutil.ReadAll(io.LimitReader(rc, int64(c.logBodyLimit)))
+			_ = rc.Close()
+		}
+	}
+
+	return info
+}
+
+// loggingBody is a response body that invokes the logger when it is closed.
+type loggingBody struct {
+	ctx    context.Context
+	rc     io.ReadCloser
+	logger func(context.Context, RequestInfo)
+	info   *RequestInfo
+	start  time.Time
+	limit  int
+	buf    bytes.Buffer
+	once   sync.Once
+}
+
+// Read records the number of bytes read (and optionally the content).
+func (b *loggingBody) Read(p []byte) (int, error) {
+	n, err := b.rc.Read(p)
+	b.info.ResponseBytes += int64(n)
+	if r := b.limit - b.buf.Len(); r > 0 {
+		if r > n {
+			r = n
+		}
+		b.buf.Write(p[:r])
+	}
+	if err != nil && err != io.EOF && b.info.Err == nil {
+		b.info.Err = err
+	}
+	return n, err
+}
+
+// Close closes the underlying body and logs the request.
+func (b *loggingBody) Close() error {
+	err := b.rc.Close()
+	b.once.Do(func() {
+		b.info.Duration = time.Since(b.start)
+		if b.limit > 0 {
+			b.info.ResponseBody = b.buf.Bytes()
+		}
+		b.logger(b.ctx, *b.info)
+	})
+	return err
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithLogger(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("0123456789"))
+	}))
+	defer ts.Close()
+
+	var logged []RequestInfo
+	logger := func(_ context.Context, info RequestInfo) { logged = append(logged, info) }
+
+	post := func(client Client) error {
+		req, err := http.NewRequest(http.MethodPost, client.URL("/things").String(), strings.NewReader("payload"))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer secret")
+		_, _, err = client.Do(context.Background(), req)
+		return err
+	}
+
+	t.Run("default", func(t *testing.T) {
+		logged = nil
+		require.NoError(t, post(newTestClient(t, ts, WithLogger(logger))))
+		require.Len(t, logged, 1)
+
+		info := logged[0]
+		assert.Equal(t, http.MethodPost, info.Method)
+		assert.Equal(t, ts.URL+"/things", info.URL)
+		assert.Equal(t, http.StatusCreated, info.StatusCode)
+		assert.NotEmpty(t, info.RequestID)
+		assert.Equal(t, int64(7), info.RequestBytes)
+		assert.Equal(t, int64(10), info.ResponseBytes)
+		assert.Positive(t, int64(info.Duration))
+		assert.NoError(t, info.Err)
+		assert.Nil(t, info.RequestBody)
+		assert.Nil(t, info.ResponseBody)
+		assert.NotContains(t, fmt.Sprintf("%+v", info), "secret")
+	})
+
+	t.Run("bodies", func(t *testing.T) {
+		logged = nil
+		require.NoError(t, post(newTestClient(t, ts, WithLogger(logger), WithBodyLogging(true), WithBodyLoggingLimit(4))))
+		require.Len(t, logged, 1)
+
+		assert.Equal(t, "payl", string(logged[0].RequestBody))
+		assert.Equal(t, "0123", string(logged[0].ResponseBody))
+		assert.Equal(t, int64(10), logged[0].ResponseBytes)
+	})
+
+	t.Run("transport error", func(t *testing.T) {
+		logged = nil
+		closed := httptest.NewServer(http.NotFoundHandler())
+		closed.Close()
+
+		assert.Error(t, post(newTestClient(t, closed, WithLogger(logger))))
+		require.Len(t, logged, 1)
+		assert.Zero(t, logged[0].StatusCode)
+		assert.Error(t, logged[0].Err)
+	})
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics provides Prometheus instrumentation for API clients. It is a separate module so that only programs
+// which use it depend on the Prometheus client.
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptrace"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thestormforge/optimize-go/pkg/api"
+)
+
+// Option is used to customize the metrics transport.
+type Option func(*transport)
+
+// WithBuckets sets the histogram buckets (in seconds) used to record request latency.
+func WithBuckets(buckets []float64) Option {
+	return func(t *transport) {
+		t.buckets = buckets
+	}
+}
+
+// WithNamespace sets the namespace of the recorded metrics, the default is "optimize_client".
+func WithNamespace(namespace string) Option {
+	return func(t *transport) {
+		t.namespace = namespace
+	}
+}
+
+// WithConnectionTimings additionally records the time spent on the DNS, connect and TLS phases of new connections,
+// labeled by phase. The timings are obtained using `net/http/httptrace`, which adds overhead to every request.
+func WithConnectionTimings() Option {
+	return func(t *transport) {
+		t.connectionTimings = true
+	}
+}
+
+// WrapTransport returns a transport that records request metrics into the supplied registry before delegating to the
+// supplied base transport (or the default transport if the base is nil). Requests are labeled using the templated
+// endpoint path to keep the cardinality bounded.
+func WrapTransport(base http.RoundTripper, reg prometheus.Registerer, opts ...Option) (http.RoundTripper, error) {
+	t := &transport{
+		base:      base,
+		namespace: "optimize_client",
+		buckets:   prometheus.DefBuckets,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	t.requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: t.namespace,
+		Name:      "requests_total",
+		Help:      "Total number of requests by method, endpoint and status code class.",
+	}, []string{"method", "endpoint", "code"})
+	t.inFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: t.namespace,
+		Name:      "requests_in_flight",
+		Help:      "Number of requests currently in flight.",
+	})
+	t.duration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: t.namespace,
+		Name:      "request_duration_seconds",
+		Help:      "Request latency by method and endpoint.",
+		Buckets:   t.buckets,
+	}, []string{"method", "endpoint"})
+
+	collectors := []prometheus.Collector{t.requests, t.inFlight, t.duration}
+	if t.connectionTimings {
+		t.connection = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: t.namespace,
+			Name:      "connection_duration_seconds",
+			Help:      "Time spent establishing new connections by phase.",
+			Buckets:   t.buckets,
+		}, []string{"phase"})
+		collectors = append(collectors, t.connection)
+	}
+
+	for _, c := range collectors {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return t, nil
+}
+
+type transport struct {
+	base              http.RoundTripper
+	namespace         string
+	buckets           []float64
+	connectionTimings bool
+
+	requests   *prometheus.CounterVec
+	inFlight   prometheus.Gauge
+	duration   *prometheus.HistogramVec
+	connection *prometheus.HistogramVec
+}
+
+// RoundTrip records metrics for the request.
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	endpoint := api.EndpointPattern(req.URL.Path)
+
+	t.inFlight.Inc()
+	defer t.inFlight.Dec()
+
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	if t.connection != nil {
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), api.NewConnectionTrace(t.observeConnection)))
+	}
+
+	start := time.Now()
+	resp, err := base.RoundTrip(req)
+	t.duration.WithLabelValues(req.Method, endpoint).Observe(time.Since(start).Seconds())
+
+	code := "error"
+	if err == nil {
+		code = fmt.Sprintf("%dxx", resp.StatusCode/100)
+	}
+	t.requests.WithLabelValues(req.Method, endpoint, code).Inc()
+
+	return resp, err
+}
+
+// observeConnection records the phases of establishing a new connection.
+func (t *transport) observeConnection(ct api.ConnectionTimings) {
+	if ct.Reused {
+		return
+	}
+	for phase, d := range map[string]time.Duration{"dns": ct.DNS, "connect": ct.Connect, "tls": ct.TLSHandshake} {
+		if d > 0 {
+			t.connection.WithLabelValues(phase).Observe(d.Seconds())
+		}
+	}
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrapTransport(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/missing") {
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	reg := prometheus.NewPedanticRegistry()
+	rt, err := WrapTransport(nil, reg, WithBuckets([]float64{0.1, 1}))
+	require.NoError(t, err)
+
+	client := &http.Client{Transport: rt}
+	for _, p := range []string{"/experiments/foo", "/experiments/bar", "/experiments/missing"} {
+		resp, err := client.Get(ts.URL + p)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+	}
+
+	expected := `
+# HELP optimize_client_requests_total Total number of requests by method, endpoint and status code class.
+# TYPE optimize_client_requests_total counter
+optimize_client_requests_total{code="2xx",endpoint="/experiments/{name}",method="GET"} 2
+optimize_client_requests_total{code="4xx",endpoint="/experiments/{name}",method="GET"} 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "optimize_client_requests_total"))
+	// Two request counters, the in-flight gauge and a single latency histogram
+	assert.Equal(t, 4, testutil.CollectAndCount(reg, "optimize_client_requests_total", "optimize_client_requests_in_flight", "optimize_client_request_duration_seconds"))
+
+	_, err = WrapTransport(nil, reg)
+	assert.Error(t, err, "duplicate registration")
+}
+
+func TestWithConnectionTimings(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	reg := prometheus.NewPedanticRegistry()
+	rt, err := WrapTransport(ts.Client().Transport, reg, WithConnectionTimings())
+	require.NoError(t, err)
+
+	client := &http.Client{Transport: rt}
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(ts.URL + "/experiments/")
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+	}
+
+	// Only the first request establishes a connection
+	assert.Equal(t, 2, testutil.CollectAndCount(reg, "optimize_client_connection_duration_seconds"))
+	mfs, err := reg.Gather()
+	require.NoError(t, err)
+	for _, mf := range mfs {
+		if mf.GetName() == "optimize_client_connection_duration_seconds" {
+			for _, m := range mf.GetMetric() {
+				assert.Equal(t, uint64(1), m.GetHistogram().GetSampleCount())
+			}
+		}
+	}
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"net/http"
+)
+
+// Middleware wraps a transport to observe or modify the requests made by a client.
+type Middleware func(http.RoundTripper) http.RoundTripper
+
+// WithMiddleware adds middleware to the client's transport. The resulting layers, from outermost to innermost, are:
+//
+//	retries (each attempt passes through all of the following layers)
+//	middleware, in the order supplied (the first middleware is the outermost)
+//	hedging, circuit breaking, client side rate limiting, adaptive concurrency and rate limit observers
+//	the User-Agent, response caching, content encoding and upload progress
+//	authorization (see `Config.Authorize`)
+//	HAR recording and debug dumps
+//	the base transport (see `WithTransport`)
+//
+// Middleware therefore sees every attempt of a retried request, but not the credentials added by the configuration.
+// This option may be supplied multiple times, each adds further middleware inside the previously added middleware.
+func WithMiddleware(middleware ...Middleware) Option {
+	return func(c *httpClient) {
+		c.middleware = append(c.middleware, middleware...)
+	}
+}
+
+// RoundTripperFunc is an adapter to allow the use of ordinary functions as transports, for example when implementing
+// middleware.
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip calls f(req).
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
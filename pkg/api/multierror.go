@@ -0,0 +1,121 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ItemError is the failure of a single item of a bulk operation.
+type ItemError struct {
+	// Index is the position of the item in the input to the operation.
+	Index int
+	// Key optionally identifies the item, e.g. by name.
+	Key string
+	// Err is the reason the item failed.
+	Err error
+}
+
+// Error returns the reason the item failed, prefixed with the item's key (or index).
+func (e *ItemError) Error() string {
+	if e.Key != "" {
+		return fmt.Sprintf("%s: %v", e.Key, e.Err)
+	}
+	return fmt.Sprintf("item %d: %v", e.Index, e.Err)
+}
+
+// Unwrap returns the reason the item failed.
+func (e *ItemError) Unwrap() error {
+	return e.Err
+}
+
+// MultiError is returned when some of the items of a bulk operation fail. The operation is not aborted by the failure
+// of an individual item, the items which are not listed succeeded. Use `errors.Is` or `errors.As` to match against the
+// individual failures and `Partition` to separate the failed items (e.g. to retry just the failures).
+type MultiError struct {
+	// Total is the number of items in the operation.
+	Total int
+	// Errors are the failed items, ordered by index.
+	Errors []*ItemError
+}
+
+// Add records the failure of an item, nil errors are ignored. It is not safe to add errors concurrently.
+func (e *MultiError) Add(index int, key string, err error) {
+	if err == nil {
+		return
+	}
+	e.Errors = append(e.Errors, &ItemError{Index: index, Key: key, Err: err})
+	sort.SliceStable(e.Errors, func(i, j int) bool { return e.Errors[i].Index < e.Errors[j].Index })
+}
+
+// ErrorOrNil returns the multi-error if any items failed, otherwise nil.
+func (e *MultiError) ErrorOrNil() error {
+	if e == nil || len(e.Errors) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Failed checks to see if the item at the specified index failed.
+func (e *MultiError) Failed(index int) bool {
+	i := sort.Search(len(e.Errors), func(i int) bool { return e.Errors[i].Index >= index })
+	return i < len(e.Errors) && e.Errors[i].Index == index
+}
+
+// Error summarizes the failures.
+func (e *MultiError) Error() string {
+	switch len(e.Errors) {
+	case 0:
+		return fmt.Sprintf("0 of %d items failed", e.Total)
+	case 1:
+		return fmt.Sprintf("1 of %d items failed: %v", e.Total, e.Errors[0])
+	default:
+		return fmt.Sprintf("%d of %d items failed: %v (and %d more)", len(e.Errors), e.Total, e.Errors[0], len(e.Errors)-1)
+	}
+}
+
+// Unwrap returns the individual failures so they can be matched using `errors.Is` and `errors.As`.
+func (e *MultiError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i := range e.Errors {
+		errs[i] = e.Errors[i]
+	}
+	return errs
+}
+
+// Partition splits the items of a bulk operation into those which succeeded and those which failed according to the
+// error returned by the operation. If the error is not a `*MultiError`, all of the items are considered to have
+// failed unless the error is nil.
+func Partition[T any](items []T, err error) (succeeded, failed []T) {
+	if err == nil {
+		return items, nil
+	}
+	var merr *MultiError
+	if !errors.As(err, &merr) {
+		return nil, items
+	}
+	for i := range items {
+		if merr.Failed(i) {
+			failed = append(failed, items[i])
+		} else {
+			succeeded = append(succeeded, items[i])
+		}
+	}
+	return succeeded, failed
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiError(t *testing.T) {
+	merr := &MultiError{Total: 4}
+	assert.NoError(t, merr.ErrorOrNil())
+
+	merr.Add(0, "", nil)
+	merr.Add(3, "d", &Error{StatusCode: http.StatusConflict, Message: "Conflict"})
+	merr.Add(1, "", errors.New("boom"))
+	err := merr.ErrorOrNil()
+	require.Error(t, err)
+
+	assert.Equal(t, "2 of 4 items failed: item 1: boom (and 1 more)", err.Error())
+	assert.True(t, errors.Is(err, ErrConflict))
+	assert.False(t, errors.Is(err, ErrNotFound))
+
+	var aerr *Error
+	require.True(t, errors.As(err, &aerr))
+	assert.Equal(t, http.StatusConflict, aerr.StatusCode)
+
+	var ierr *ItemError
+	require.True(t, errors.As(err, &ierr))
+	assert.Equal(t, 1, ierr.Index)
+
+	succeeded, failed := Partition([]string{"a", "b", "c", "d"}, err)
+	assert.Equal(t, []string{"a", "c"}, succeeded)
+	assert.Equal(t, []string{"b", "d"}, failed)
+
+	succeeded, failed = Partition([]string{"a", "b"}, nil)
+	assert.Equal(t, []string{"a", "b"}, succeeded)
+	assert.Empty(t, failed)
+
+	succeeded, failed = Partition([]string{"a", "b"}, errors.New("boom"))
+	assert.Empty(t, succeeded)
+	assert.Equal(t, []string{"a", "b"}, failed)
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultPollInterval is the amount of time to wait between polls of an operation unless otherwise specified.
+const DefaultPollInterval = time.Second
+
+// OperationError is returned when an asynchronous operation fails.
+type OperationError struct {
+	// Location is the URL of the operation.
+	Location string
+	// Status is the final status reported for the operation.
+	Status string
+	// Message is the reason the operation failed, if reported by the server.
+	Message string
+	// Body is the raw operation resource.
+	Body []byte
+}
+
+// Error returns the failure message.
+func (e *OperationError) Error() string {
+	if e.Message != "" {
+		return "operation " + e.Status + ": " + e.Message
+	}
+	return "operation " + e.Status
+}
+
+// operationStatus is the subset of an operation resource used to determine if the operation is complete.
+type operationStatus struct {
+	Status string          `json:"status"`
+	Done   *bool           `json:"done"`
+	Error  json.RawMessage `json:"error"`
+}
+
+// PollOperation polls an asynchronous operation (typically from the Location header of a "202 Accepted" response)
+// until it completes, returning the final response. The operation is considered in progress while the server
+// responds with "202 Accepted" or the resource reports a "pending" or "running" status (or "done": false); the
+// server's Retry-After is used in place of the interval when present. Failed operations produce an `*OperationError`
+// and unsuccessful responses produce an `*Error`.
+func PollOperation(ctx context.Context, c Client, u string, interval time.Duration) (*http.Response, []byte, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	clock := ClientClock(c)
+
+	for {
+		req, err := http.NewRequest(http.MethodGet, u, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		req.Header.Set("Accept", "application/json")
+
+		resp, body, err := c.Do(ctx, req)
+		if err != nil {
+			return nil, nil, err
+		}
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return resp, body, DecodeError(c, resp, body)
+		}
+
+		if resp.StatusCode != http.StatusAccepted {
+			var op operationStatus
+			if !isMediaType(resp.Header, "application/json") || json.Unmarshal(body, &op) != nil {
+				return resp, body, nil
+			}
+
+			switch strings.ToLower(op.Status) {
+			case "pending", "running", "in_progress":
+			case "failed", "error", "canceled", "cancelled":
+				return resp, body, &OperationError{Location: u, Status: strings.ToLower(op.Status), Message: operationMessage(op.Error), Body: body}
+			default:
+				if op.Done == nil || *op.Done {
+					return resp, body, nil
+				}
+			}
+		}
+
+		delay := interval
+		if ra, ok := retryAfter(resp.Header, clock.Now()); ok && ra > 0 {
+			delay = ra
+		}
+
+		if err := clock.Sleep(ctx, delay); err != nil {
+			return nil, nil, err
+		}
+	}
+}
+
+// operationMessage extracts a message from the error of an operation, which may be a string or an object.
+func operationMessage(raw json.RawMessage) string {
+	var msg string
+	if json.Unmarshal(raw, &msg) == nil {
+		return msg
+	}
+
+	var obj struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(raw, &obj) == nil {
+		return obj.Message
+	}
+	return ""
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPollOperation(t *testing.T) {
+	cases := []struct {
+		desc             string
+		responses        []string
+		expectedBody     string
+		expectedErr      string
+		expectedAttempts int32
+	}{
+		{
+			desc:             "succeeded",
+			responses:        []string{"", `{"status":"running"}`, `{"status":"succeeded","name":"foo"}`},
+			expectedBody:     `{"status":"succeeded","name":"foo"}`,
+			expectedAttempts: 3,
+		},
+		{
+			desc:             "done",
+			responses:        []string{`{"done":false}`, `{"done":true}`},
+			expectedBody:     `{"done":true}`,
+			expectedAttempts: 2,
+		},
+		{
+			desc:             "failed",
+			responses:        []string{`{"status":"pending"}`, `{"status":"FAILED","error":{"message":"quota exceeded"}}`},
+			expectedErr:      "operation failed: quota exceeded",
+			expectedAttempts: 2,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			var attempts int32
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body := c.responses[atomic.AddInt32(&attempts, 1)-1]
+				if body == "" {
+					w.Header().Set("Retry-After", "0")
+					w.WriteHeader(http.StatusAccepted)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(body))
+			}))
+			defer ts.Close()
+
+			client := newTestClient(t, ts)
+			_, body, err := PollOperation(context.Background(), client, client.URL("/operations/1").String(), time.Millisecond)
+			if c.expectedErr != "" {
+				var opErr *OperationError
+				assert.True(t, errors.As(err, &opErr))
+				assert.EqualError(t, err, c.expectedErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, c.expectedBody, string(body))
+			}
+			assert.Equal(t, c.expectedAttempts, atomic.LoadInt32(&attempts))
+		})
+	}
+}
+
+func TestPollOperation_Canceled(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, _, err := PollOperation(ctx, client, client.URL("/operations/1").String(), time.Hour)
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Option is used to customize the behavior of a client.
+type Option func(*httpClient)
+
+// NewClientWithTransport returns a new client using the supplied transport for all requests made to the API server.
+//
+// Deprecated: Use NewClient with the WithTransport option instead.
+func NewClientWithTransport(ctx context.Context, cfg Config, transport http.RoundTripper, opts ...Option) (Client, error) {
+	return NewClient(ctx, cfg, append([]Option{WithTransport(transport)}, opts...)...)
+}
+
+// WithTransport sets the transport used for all requests made to the API server; a nil transport creates a new
+// transport using the default transport settings and any transport options. The transport is still wrapped by the
+// configuration's authorization and any other features enabled on the client.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *httpClient) {
+		c.transport = rt
+	}
+}
+
+// WithTimeout sets the time limit for each request made by the client. A timeout of zero or less disables the
+// client timeout entirely so only the context deadline applies; otherwise the shorter of the two wins.
+func WithTimeout(d time.Duration) Option {
+	return func(c *httpClient) {
+		if d < 0 {
+			d = 0
+		}
+		c.client.Timeout = d
+	}
+}
+
+// DefaultMaxResponseBytes is the largest response body read by a client unless otherwise configured.
+const DefaultMaxResponseBytes = 32 << 20
+
+// ErrResponseTooLarge is returned when a response body exceeds the maximum allowed size.
+var ErrResponseTooLarge = errors.New("response body too large")
+
+// WithMaxResponseBytes limits the size of response bodies read into memory by `Do`. Reading stops as soon as the
+// limit is exceeded and the connection is closed rather than drained. A limit of zero or less disables the check.
+// Bodies consumed through `DoStream` are not limited.
+func WithMaxResponseBytes(n int64) Option {
+	return func(c *httpClient) {
+		c.maxResponseBytes = n
+	}
+}
+
+// WithCookieJar sets the cookie jar used to store and send cookies, for example to maintain a session with a reverse
+// proxy. By default, the client does not use a cookie jar and no cookies are retained between requests.
+func WithCookieJar(jar http.CookieJar) Option {
+	return func(c *httpClient) {
+		c.client.Jar = jar
+	}
+}
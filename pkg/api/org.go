@@ -0,0 +1,57 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"net/url"
+)
+
+// HeaderOrganizationID is the name of the request header used to identify the organization in header mode.
+const HeaderOrganizationID = "X-Org-ID"
+
+// OrganizationMode controls how the organization is identified to the server.
+type OrganizationMode int
+
+const (
+	// OrganizationHeader identifies the organization using the X-Org-ID header of each request.
+	OrganizationHeader OrganizationMode = iota
+	// OrganizationPath identifies the organization by prefixing endpoint paths with "/orgs/{org}".
+	OrganizationPath
+)
+
+// WithOrganization scopes every request made by the client to the supplied organization. By default the organization
+// is sent using the X-Org-ID header (unless the request already sets it), see `WithOrganizationMode` for deployments
+// that expect the organization in the path instead.
+func WithOrganization(org string) Option {
+	return func(c *httpClient) {
+		c.organization = org
+	}
+}
+
+// WithOrganizationMode changes how the organization supplied to `WithOrganization` is sent. In path mode the prefix
+// is added to resolved endpoints, so it applies equally to `Client.URL`, `URLWithQuery` and the typed clients; links
+// returned by the server are expected to include it already.
+func WithOrganizationMode(mode OrganizationMode) Option {
+	return func(c *httpClient) {
+		c.organizationMode = mode
+	}
+}
+
+// organizationPath returns the path prefix for the organization.
+func organizationPath(org string) (string, string) {
+	return "/orgs/" + org, "/orgs/" + url.PathEscape(org)
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithOrganization(t *testing.T) {
+	var path, org string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, org = r.URL.Path, r.Header.Get(HeaderOrganizationID)
+	}))
+	defer ts.Close()
+
+	endpoints, err := Endpoints(ts.URL+"/v1/", nil)
+	require.NoError(t, err)
+	newClient := func(opts ...Option) Client {
+		c, err := NewClient(context.Background(), StaticTokenConfig("", endpoints), opts...)
+		require.NoError(t, err)
+		return c
+	}
+	do := func(c Client, u *url.URL) {
+		req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+		require.NoError(t, err)
+		_, _, err = c.Do(context.Background(), req)
+		require.NoError(t, err)
+	}
+
+	t.Run("header", func(t *testing.T) {
+		c := newClient(WithOrganization("acme"))
+		assert.Equal(t, ts.URL+"/v1/experiments/foo", c.URL("/experiments/foo").String())
+
+		do(c, c.URL("/experiments/foo"))
+		assert.Equal(t, "/v1/experiments/foo", path)
+		assert.Equal(t, "acme", org)
+	})
+
+	t.Run("path", func(t *testing.T) {
+		c := newClient(WithOrganization("acme"), WithOrganizationMode(OrganizationPath))
+		assert.Equal(t, ts.URL+"/v1/orgs/acme/experiments/foo", c.URL("/experiments/foo").String())
+		assert.Equal(t, ts.URL+"/v1/orgs/acme/experiments/", c.URL("/experiments/").String())
+		assert.Equal(t, ts.URL+"/v1/orgs/acme/health", c.URL("/health").String())
+		assert.Equal(t, ts.URL+"/v1/orgs/acme/experiments/?limit=1", URLWithQuery(c, "/experiments/", url.Values{"limit": {"1"}}).String())
+
+		do(c, c.URL("/experiments/foo"))
+		assert.Equal(t, "/v1/orgs/acme/experiments/foo", path)
+		assert.Empty(t, org)
+	})
+
+	t.Run("override", func(t *testing.T) {
+		endpoints, err := Endpoints(ts.URL+"/v1/", map[string]string{"/experiments/": "https://experiments.example.com/"})
+		require.NoError(t, err)
+		c, err := NewClient(context.Background(), StaticTokenConfig("", endpoints), WithOrganization("acme"), WithOrganizationMode(OrganizationPath))
+		require.NoError(t, err)
+		assert.Equal(t, "https://experiments.example.com/orgs/acme/foo", c.URL("/experiments/foo").String())
+	})
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package otel provides OpenTelemetry tracing for API clients. It is a separate module so that only programs which
+// use it depend on OpenTelemetry.
+package otel
+
+import (
+	"net/http"
+
+	"github.com/thestormforge/optimize-go/pkg/api"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies this package as the source of the spans.
+const instrumentationName = "github.com/thestormforge/optimize-go/pkg/api/otel"
+
+// Option is used to customize the tracing transport.
+type Option func(*transport)
+
+// WithTracerProvider sets the provider used to create the tracer, by default the global provider is used.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(t *transport) {
+		t.tracerProvider = tp
+	}
+}
+
+// WithPropagator sets the propagator used to inject the trace context into the outbound request headers, by default
+// the W3C Trace Context format is used.
+func WithPropagator(p propagation.TextMapPropagator) Option {
+	return func(t *transport) {
+		t.propagator = p
+	}
+}
+
+// WrapTransport returns a transport that starts a client span for each request before delegating to the supplied base
+// transport (or the default transport if the base is nil). Spans are named using the method and the templated
+// endpoint path.
+func WrapTransport(base http.RoundTripper, opts ...Option) http.RoundTripper {
+	t := &transport{
+		base:           base,
+		tracerProvider: otel.GetTracerProvider(),
+		propagator:     propagation.TraceContext{},
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	t.tracer = t.tracerProvider.Tracer(instrumentationName, trace.WithInstrumentationVersion(api.Version))
+	return t
+}
+
+type transport struct {
+	base           http.RoundTripper
+	tracerProvider trace.TracerProvider
+	tracer         trace.Tracer
+	propagator     propagation.TextMapPropagator
+}
+
+// RoundTrip records a span for the request.
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Do not record the query string or user information
+	u := *req.URL
+	u.User = nil
+	u.RawQuery = ""
+
+	ctx, span := t.tracer.Start(req.Context(), req.Method+" "+api.EndpointPattern(req.URL.Path),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.method", req.Method),
+			attribute.String("http.url", u.String()),
+		))
+	defer span.End()
+
+	req = req.Clone(ctx)
+	t.propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+	if resp.StatusCode >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+	}
+	return resp, nil
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// DefaultPingTimeout is the time limit for health checks when the context does not have an earlier deadline.
+const DefaultPingTimeout = 5 * time.Second
+
+// endpointHealth is the endpoint used for health checks.
+const endpointHealth = "/health"
+
+// ErrNoHealthEndpoint is returned when the client configuration does not include a health endpoint.
+var ErrNoHealthEndpoint = errors.New("health endpoint is not configured")
+
+// Health is the result of a successful health check.
+type Health struct {
+	// Status is the server reported status, if any.
+	Status string `json:"status,omitempty"`
+	// Version is the server reported version, if any.
+	Version string `json:"version,omitempty"`
+	// Latency is the amount of time it took to get a response.
+	Latency time.Duration `json:"-"`
+}
+
+// Ping checks the health of the API server using the supplied client, so the same authorization and transport as any
+// other request are used. Any response other than "200 OK" is returned as an `*Error`.
+func Ping(ctx context.Context, c Client) (*Health, error) {
+	u := c.URL(endpointHealth)
+	if u == nil {
+		return nil, ErrNoHealthEndpoint
+	}
+
+	if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) > DefaultPingTimeout {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultPingTimeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	start := time.Now()
+	resp, body, err := c.Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, DecodeError(c, resp, body)
+	}
+
+	h := &Health{Latency: time.Since(start)}
+	if isMediaType(resp.Header, "application/json") {
+		_ = json.Unmarshal(body, h)
+	}
+	return h, nil
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPing(t *testing.T) {
+	healthy := true
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path != "/v1/health" || r.Header.Get("Authorization") != "Bearer token":
+			w.WriteHeader(http.StatusNotFound)
+		case healthy:
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"status":"ok","version":"1.2.3"}`))
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer ts.Close()
+
+	endpoints, err := Endpoints(ts.URL+"/v1", nil)
+	require.NoError(t, err)
+	client, err := NewClient(context.Background(), StaticTokenConfig("token", endpoints))
+	require.NoError(t, err)
+
+	h, err := Ping(context.Background(), client)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "ok", h.Status)
+		assert.Equal(t, "1.2.3", h.Version)
+	}
+
+	healthy = false
+	_, err = Ping(context.Background(), client)
+	var apiErr *Error
+	if assert.True(t, errors.As(err, &apiErr)) {
+		assert.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+	}
+
+	client, err = NewClient(context.Background(), StaticTokenConfig("token", map[string]*url.URL{}))
+	require.NoError(t, err)
+	_, err = Ping(context.Background(), client)
+	assert.True(t, errors.Is(err, ErrNoHealthEndpoint))
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// uploadProgressInterval is the minimum amount of time between upload progress reports.
+const uploadProgressInterval = 100 * time.Millisecond
+
+// WithUploadProgress reports the number of request body bytes sent along with the total size of the body (or -1 if the
+// size is not known in advance). Progress is reported as soon as the first bytes are sent, at most every 100ms while it
+// is in progress and once more when the entire body has been sent. Each attempt of a retried request starts reporting
+// from zero again; the callback may be invoked concurrently when requests are hedged.
+func WithUploadProgress(progress func(bytesSent, total int64)) Option {
+	return func(c *httpClient) {
+		c.uploadProgress = progress
+	}
+}
+
+// uploadProgressTransport reports the progress of sending request bodies.
+type uploadProgressTransport struct {
+	progress func(bytesSent, total int64)
+	base     http.RoundTripper
+}
+
+// RoundTrip wraps the request body so the bytes read by the base transport are reported.
+func (t *uploadProgressTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return transport(t.base).RoundTrip(req)
+	}
+
+	total := req.ContentLength
+	if total <= 0 {
+		total = -1
+	}
+
+	r := req.Clone(req.Context())
+	r.Body = &progressBody{ReadCloser: req.Body, total: total, progress: t.progress}
+	if req.GetBody != nil {
+		// The base transport may rewind the body to resend it, in which case the progress starts over
+		r.GetBody = func() (io.ReadCloser, error) {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			return &progressBody{ReadCloser: body, total: total, progress: t.progress}, nil
+		}
+	}
+	return transport(t.base).RoundTrip(r)
+}
+
+// progressBody is a request body that reports the number of bytes read from it.
+type progressBody struct {
+	io.ReadCloser
+	total    int64
+	progress func(bytesSent, total int64)
+
+	mu       sync.Mutex
+	sent     int64
+	reported time.Time
+	done     bool
+}
+
+// Read reads from the underlying body, periodically reporting the progress.
+func (b *progressBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sent += int64(n)
+	switch {
+	case b.done:
+	case err == io.EOF || (b.total > 0 && b.sent >= b.total):
+		b.done = true
+		b.progress(b.sent, b.total)
+	case b.reported.IsZero() || time.Since(b.reported) >= uploadProgressInterval:
+		b.reported = time.Now()
+		b.progress(b.sent, b.total)
+	}
+	return n, err
+}
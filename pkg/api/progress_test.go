@@ -0,0 +1,70 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithUploadProgress(t *testing.T) {
+	var attempts int32
+	ts := httptest.NewServer(flakyHandler(1, http.StatusServiceUnavailable, &attempts))
+	defer ts.Close()
+
+	var mu sync.Mutex
+	var reports [][2]int64
+	client := newTestClient(t, ts, WithRetry(2, time.Millisecond), WithUploadProgress(func(sent, total int64) {
+		mu.Lock()
+		defer mu.Unlock()
+		reports = append(reports, [2]int64{sent, total})
+	}))
+
+	payload := strings.Repeat("x", 1<<20)
+	req, err := http.NewRequest(http.MethodPut, client.URL("/").String(), bytes.NewReader([]byte(payload)))
+	require.NoError(t, err)
+	_, _, err = client.Do(context.Background(), req)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), atomic.LoadInt32(&attempts))
+
+	mu.Lock()
+	defer mu.Unlock()
+	total := int64(len(payload))
+	var completed, restarts int
+	for i, r := range reports {
+		assert.Equal(t, total, r[1])
+		if r[0] == total {
+			completed++
+		}
+		if i > 0 && r[0] < reports[i-1][0] {
+			restarts++
+		}
+	}
+	assert.Equal(t, 2, completed, "each attempt should report completion once")
+	assert.Equal(t, 1, restarts, "the retry should start over")
+	assert.Less(t, len(reports), 50, "progress is reported too frequently")
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// ErrRateLimitWait is returned without sending the request when the client side rate limit cannot be satisfied before
+// the request context is done; the error also wraps the underlying cause (e.g. `context.DeadlineExceeded`).
+var ErrRateLimitWait = errors.New("rate limit wait would exceed deadline")
+
+// RateLimitStatus is the server side rate limit status reported on a response.
+type RateLimitStatus struct {
+	// Limit is the maximum number of requests allowed in the current window.
+	Limit int64
+	// Remaining is the number of requests left in the current window.
+	Remaining int64
+	// Reset is the time at which the current window ends, if reported.
+	Reset time.Time
+}
+
+// ParseRateLimit returns the rate limit status from the X-RateLimit-Limit, X-RateLimit-Remaining and
+// X-RateLimit-Reset headers, the result is false if none of the headers are present. The reset time may be expressed
+// as either a Unix timestamp or a number of seconds from now. Since these headers are available before the body is
+// read, use `DoStream` to check the rate limit status without buffering the response.
+func ParseRateLimit(header http.Header) (RateLimitStatus, bool) {
+	return parseRateLimit(header, time.Now())
+}
+
+// parseRateLimit returns the rate limit status, relative reset times are relative to the supplied time.
+func parseRateLimit(header http.Header, now time.Time) (RateLimitStatus, bool) {
+	var s RateLimitStatus
+	limit, hasLimit := headerInt(header, "X-RateLimit-Limit")
+	remaining, hasRemaining := headerInt(header, "X-RateLimit-Remaining")
+	reset, hasReset := headerInt(header, "X-RateLimit-Reset")
+
+	s.Limit = limit
+	s.Remaining = remaining
+	if hasReset {
+		// Anything before 2001-09-09 is assumed to be relative
+		if reset >= 1e9 {
+			s.Reset = time.Unix(reset, 0)
+		} else {
+			s.Reset = now.Add(time.Duration(reset) * time.Second)
+		}
+	}
+	return s, hasLimit || hasRemaining || hasReset
+}
+
+// headerInt returns the non-negative integer value of a header.
+func headerInt(header http.Header, key string) (int64, bool) {
+	v, err := strconv.ParseInt(strings.TrimSpace(header.Get(key)), 10, 64)
+	if err != nil || v < 0 {
+		return 0, false
+	}
+	return v, true
+}
+
+// WithRateLimit limits the rate at which the client sends requests to `rps` requests per second, allowing bursts of
+// up to `burst` requests. The limit is shared by all requests (including retry attempts) made by the client; requests
+// block until they are allowed to proceed or their context is done.
+func WithRateLimit(rps float64, burst int) Option {
+	return func(c *httpClient) {
+		c.limiter = rate.NewLimiter(rate.Limit(rps), burst)
+	}
+}
+
+// WithRateLimitObserver registers a function that is called with the server side rate limit status of every response
+// (including responses to retried attempts) that reports one, regardless of the status code. Headers which are
+// missing or cannot be parsed leave the corresponding fields as zero. The observer is called synchronously before the
+// response is returned and must be safe for concurrent use; it may be used along with `WithRateLimit` to slow down
+// before the server starts rejecting requests.
+func WithRateLimitObserver(observer func(RateLimitStatus)) Option {
+	return func(c *httpClient) {
+		c.rateLimitObserver = observer
+	}
+}
+
+// rateLimitObserverTransport reports the rate limit status of each response.
+type rateLimitObserverTransport struct {
+	observer func(RateLimitStatus)
+	clock    Clock
+	base     http.RoundTripper
+}
+
+// RoundTrip delegates to the base transport and reports the rate limit status of the response.
+func (t *rateLimitObserverTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := transport(t.base).RoundTrip(req)
+	if err == nil {
+		if s, ok := parseRateLimit(resp.Header, clockOf(t.clock).Now()); ok {
+			t.observer(s)
+		}
+	}
+	return resp, err
+}
+
+// rateLimitTransport delays requests to satisfy a rate limit.
+type rateLimitTransport struct {
+	limiter *rate.Limiter
+	clock   Clock
+	base    http.RoundTripper
+}
+
+// RoundTrip waits for the rate limiter before delegating to the base transport.
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// The transport contract requires closing the body even if the request is never sent
+	fail := func(err error) (*http.Response, error) {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, err
+	}
+
+	ctx := req.Context()
+	if err := ctx.Err(); err != nil {
+		return fail(err)
+	}
+
+	clock := clockOf(t.clock)
+	now := clock.Now()
+	r := t.limiter.ReserveN(now, 1)
+	if !r.OK() {
+		return fail(fmt.Errorf("%w: request exceeds the burst of %d", ErrRateLimitWait, t.limiter.Burst()))
+	}
+
+	// Fail early if the wait would exceed the deadline
+	delay := r.DelayFrom(now)
+	if deadline, ok := ctx.Deadline(); ok && deadline.Sub(now) < delay {
+		r.CancelAt(now)
+		return fail(fmt.Errorf("%w: %w", ErrRateLimitWait, context.DeadlineExceeded))
+	}
+	if delay > 0 {
+		if err := clock.Sleep(ctx, delay); err != nil {
+			r.CancelAt(clock.Now())
+			return fail(err)
+		}
+	}
+
+	return transport(t.base).RoundTrip(req)
+}
+
+// transport returns the supplied round tripper or the default transport if it is nil.
+func transport(rt http.RoundTripper) http.RoundTripper {
+	if rt != nil {
+		return rt
+	}
+	return http.DefaultTransport
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func TestWithRateLimit(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts, WithRateLimit(20, 1))
+	get := func(ctx context.Context) error {
+		req, err := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+		require.NoError(t, err)
+		_, _, err = client.Do(ctx, req)
+		return err
+	}
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, get(context.Background()))
+	}
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(90*time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.True(t, errors.Is(get(ctx), context.Canceled))
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	err := get(ctx)
+	assert.True(t, errors.Is(err, ErrRateLimitWait))
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestRateLimitTransport_CloseBody(t *testing.T) {
+	drained := rate.NewLimiter(rate.Every(time.Hour), 1)
+	require.True(t, drained.Allow())
+
+	cases := []struct {
+		desc     string
+		limiter  *rate.Limiter
+		timeout  time.Duration
+		expected []error
+	}{
+		{
+			desc:     "deadline",
+			limiter:  drained,
+			timeout:  time.Minute,
+			expected: []error{ErrRateLimitWait, context.DeadlineExceeded},
+		},
+		{
+			desc:     "burst",
+			limiter:  rate.NewLimiter(1, 0),
+			expected: []error{ErrRateLimitWait},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			ctx := context.Background()
+			if c.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, c.timeout)
+				defer cancel()
+			}
+
+			body := &closeRecorder{Reader: strings.NewReader("body")}
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://example.invalid/", body)
+			require.NoError(t, err)
+
+			_, err = (&rateLimitTransport{limiter: c.limiter}).RoundTrip(req)
+			for _, expected := range c.expected {
+				assert.True(t, errors.Is(err, expected))
+			}
+			assert.True(t, body.closed)
+		})
+	}
+}
+
+func TestParseRateLimit(t *testing.T) {
+	reset := time.Now().Add(time.Minute).Truncate(time.Second)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/absolute":
+			w.Header().Set("X-RateLimit-Limit", "100")
+			w.Header().Set("X-RateLimit-Remaining", "42")
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		case "/relative":
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", "60")
+		case "/invalid":
+			w.Header().Set("X-RateLimit-Limit", "lots")
+		}
+		_, _ = w.Write([]byte("body"))
+	}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts)
+	status := func(ep string) (RateLimitStatus, bool) {
+		req, err := http.NewRequest(http.MethodGet, client.URL(ep).String(), nil)
+		require.NoError(t, err)
+		resp, body, err := client.DoStream(context.Background(), req)
+		require.NoError(t, err)
+		defer body.Close()
+		return ParseRateLimit(resp.Header)
+	}
+
+	s, ok := status("/absolute")
+	assert.True(t, ok)
+	assert.Equal(t, int64(100), s.Limit)
+	assert.Equal(t, int64(42), s.Remaining)
+	assert.True(t, reset.Equal(s.Reset))
+
+	s, ok = status("/relative")
+	assert.True(t, ok)
+	assert.Equal(t, int64(0), s.Remaining)
+	assert.WithinDuration(t, reset, s.Reset, 2*time.Second)
+
+	_, ok = status("/invalid")
+	assert.False(t, ok)
+}
+
+func TestWithRateLimitObserver(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.Header().Set("X-RateLimit-Limit", "100")
+			w.Header().Set("X-RateLimit-Remaining", "3")
+		case "/garbled":
+			w.Header().Set("X-RateLimit-Limit", "lots")
+			w.Header().Set("X-RateLimit-Remaining", "2")
+		}
+	}))
+	defer ts.Close()
+
+	var observed []RateLimitStatus
+	client := newTestClient(t, ts, WithRateLimitObserver(func(s RateLimitStatus) { observed = append(observed, s) }))
+	for _, ep := range []string{"/ok", "/garbled", "/none"} {
+		req, err := http.NewRequest(http.MethodGet, client.URL(ep).String(), nil)
+		require.NoError(t, err)
+		_, _, err = client.Do(context.Background(), req)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, []RateLimitStatus{{Limit: 100, Remaining: 3}, {Remaining: 2}}, observed)
+}
+
+func TestWithRateLimitObserver_Clock(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Reset", "60")
+	}))
+	defer ts.Close()
+
+	// Relative reset times are relative to the clock of the client
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	var observed []RateLimitStatus
+	client := newTestClient(t, ts, WithClock(&sleepRecorder{now: now}),
+		WithRateLimitObserver(func(s RateLimitStatus) { observed = append(observed, s) }))
+	req, err := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+	require.NoError(t, err)
+	_, _, err = client.Do(context.Background(), req)
+	require.NoError(t, err)
+
+	assert.Equal(t, []RateLimitStatus{{Reset: now.Add(time.Minute)}}, observed)
+}
+
+// closeRecorder is a request body which records whether it was closed.
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (r *closeRecorder) Close() error {
+	r.closed = true
+	return nil
+}
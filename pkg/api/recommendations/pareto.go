@@ -0,0 +1,165 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package recommendations
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/thestormforge/optimize-go/pkg/api"
+	"github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+)
+
+// ParetoTrial is a trial on the Pareto front of a multi-objective experiment.
+type ParetoTrial struct {
+	// Number is the trial number.
+	Number int64 `json:"number"`
+	// Assignments are the parameter assignments of the trial.
+	Assignments []v1alpha1.Assignment `json:"assignments"`
+	// Objectives are the observed values of each objective, in the same order as the front's metrics.
+	Objectives []float64 `json:"objectives"`
+}
+
+// ParetoFront is the set of non-dominated trials of an experiment.
+type ParetoFront struct {
+	// Metrics are the names of the optimized metrics, in the order used by the objective vectors.
+	Metrics []string `json:"metrics"`
+	// Trials are the trials on the front.
+	Trials []ParetoTrial `json:"trials"`
+	// Computed is true if the server does not provide the front and it was computed from the completed trials.
+	Computed bool `json:"-"`
+}
+
+func (h *httpAPI) GetParetoFront(ctx context.Context, experiment string) (ParetoFront, error) {
+	front := ParetoFront{}
+
+	u := h.client.URL(endpointExperiments + url.PathEscape(experiment) + "/paretoFront").String()
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return front, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, body, err := h.client.Do(ctx, req)
+	if err != nil {
+		return front, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusNotImplemented:
+		return h.computeParetoFront(ctx, experiment)
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return front, api.DecodeError(h.client, resp, body)
+	}
+
+	if err := api.CheckContentType(resp, body); err != nil {
+		return front, err
+	}
+	err = api.ClientCodec(h.client).Unmarshal(body, &front)
+	return front, err
+}
+
+// computeParetoFront computes the Pareto front from the completed trials of the experiment.
+func (h *httpAPI) computeParetoFront(ctx context.Context, experiment string) (ParetoFront, error) {
+	front := ParetoFront{Computed: true}
+
+	v1 := v1alpha1.NewAPI(h.client)
+	exp, err := v1.GetExperimentByName(ctx, v1alpha1.NewExperimentName(experiment))
+	if err != nil {
+		return front, err
+	}
+
+	// Minimized objectives are compared as is, maximized objectives are negated
+	var signs []float64
+	for _, o := range exp.Objectives() {
+		front.Metrics = append(front.Metrics, o.Name)
+		if o.Minimize {
+			signs = append(signs, 1)
+		} else {
+			signs = append(signs, -1)
+		}
+	}
+
+	trialsURL := exp.TrialsURL
+	if trialsURL == "" {
+		trialsURL = h.client.URL(endpointExperiments + url.PathEscape(experiment) + "/trials/").String()
+	}
+
+	q := &v1alpha1.TrialListQuery{Status: []v1alpha1.TrialStatus{v1alpha1.TrialCompleted}}
+	var candidates []ParetoTrial
+	for t, err := range v1alpha1.ListAllTrials(ctx, v1, trialsURL, q, 0) {
+		if err != nil {
+			return front, err
+		}
+		if t.Status != v1alpha1.TrialCompleted {
+			continue
+		}
+		if objectives, ok := objectiveVector(front.Metrics, t.Values); ok {
+			candidates = append(candidates, ParetoTrial{Number: t.Number, Assignments: t.Assignments, Objectives: objectives})
+		}
+	}
+
+	for i := range candidates {
+		dominated := false
+		for j := range candidates {
+			if i != j && dominates(candidates[j].Objectives, candidates[i].Objectives, signs) {
+				dominated = true
+				break
+			}
+		}
+		if !dominated {
+			front.Trials = append(front.Trials, candidates[i])
+		}
+	}
+
+	return front, nil
+}
+
+// objectiveVector returns the values of the named metrics, failing if any are missing.
+func objectiveVector(metrics []string, values []v1alpha1.Value) ([]float64, bool) {
+	v := make([]float64, len(metrics))
+	for i, name := range metrics {
+		found := false
+		for _, value := range values {
+			if value.MetricName == name {
+				v[i], found = value.Value.Float64Value(), true
+				break
+			}
+		}
+		if !found {
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// dominates checks to see if objective vector a is at least as good as b for every objective and strictly better
+// for at least one; the signs make every objective a minimization.
+func dominates(a, b, signs []float64) bool {
+	better := false
+	for i := range a {
+		x, y := a[i]*signs[i], b[i]*signs[i]
+		if x > y {
+			return false
+		}
+		if x < y {
+			better = true
+		}
+	}
+	return better
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package recommendations
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thestormforge/optimize-go/pkg/api/apitest"
+)
+
+func TestAPI_GetParetoFront(t *testing.T) {
+	m := apitest.NewMock().
+		On(http.MethodGet, "/experiments/server/paretoFront", apitest.JSONResponse(http.StatusOK, `{
+			"metrics":["cost","throughput"],
+			"trials":[{"number":3,"assignments":[{"parameterName":"cpu","value":250}],"objectives":[1.5,200]}]
+		}`))
+
+	front, err := NewAPI(m).GetParetoFront(context.Background(), "server")
+	require.NoError(t, err)
+	assert.False(t, front.Computed)
+	assert.Equal(t, []string{"cost", "throughput"}, front.Metrics)
+	if assert.Len(t, front.Trials, 1) {
+		assert.Equal(t, int64(3), front.Trials[0].Number)
+		assert.Equal(t, []float64{1.5, 200}, front.Trials[0].Objectives)
+	}
+}
+
+func TestAPI_GetParetoFront_Computed(t *testing.T) {
+	m := apitest.NewMock().
+		On(http.MethodGet, "/experiments/client/paretoFront", apitest.JSONResponse(http.StatusNotFound, `{"error":"not found"}`)).
+		On(http.MethodGet, "/experiments/client", apitest.JSONResponse(http.StatusOK, `{
+			"metrics":[{"name":"cost","minimize":true},{"name":"throughput"},{"name":"duration","optimize":false}]
+		}`)).
+		On(http.MethodGet, "/experiments/client/trials/", apitest.JSONResponse(http.StatusOK, `{"trials":[
+			{"number":1,"status":"completed","values":[{"metricName":"cost","value":1},{"metricName":"throughput","value":100}]},
+			{"number":2,"status":"completed","values":[{"metricName":"cost","value":2},{"metricName":"throughput","value":300}]},
+			{"number":3,"status":"completed","values":[{"metricName":"cost","value":3},{"metricName":"throughput","value":200}]},
+			{"number":4,"status":"completed","values":[{"metricName":"cost","value":2},{"metricName":"throughput","value":300}]},
+			{"number":5,"status":"completed","values":[{"metricName":"cost","value":0.5}]}
+		]}`))
+
+	front, err := NewAPI(m).GetParetoFront(context.Background(), "client")
+	require.NoError(t, err)
+	assert.True(t, front.Computed)
+	assert.Equal(t, []string{"cost", "throughput"}, front.Metrics)
+
+	var numbers []int64
+	for _, trial := range front.Trials {
+		numbers = append(numbers, trial.Number)
+	}
+	assert.Equal(t, []int64{1, 2, 4}, numbers)
+}
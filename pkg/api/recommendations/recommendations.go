@@ -0,0 +1,103 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package recommendations provides a typed client for the recommendations of the experiments API.
+package recommendations
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/thestormforge/optimize-go/pkg/api"
+	"github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+)
+
+const endpointExperiments = "/experiments/"
+
+// ErrNoRecommendation is returned when the server has not produced a recommendation for an experiment yet.
+var ErrNoRecommendation = errors.New("no recommendation available")
+
+// Point is a set of parameter assignments along with the associated metric values.
+type Point struct {
+	// Assignments are the recommended parameter assignments.
+	Assignments []v1alpha1.Assignment `json:"assignments"`
+	// Values are the observed (or predicted) metric values for the assignments.
+	Values []v1alpha1.Value `json:"values,omitempty"`
+	// Objective is the value of the experiment's objective for the assignments, if it has a single objective.
+	Objective *float64 `json:"objective,omitempty"`
+	// TrialNumber is the number of the trial the values were observed on, or zero for a predicted point.
+	TrialNumber int64 `json:"trialNumber,omitempty"`
+}
+
+// Recommendation is the server's recommendation for an experiment.
+type Recommendation struct {
+	// BestObserved is the best point observed so far.
+	BestObserved *Point `json:"bestObserved,omitempty"`
+	// PredictedOptimal is the point the server predicts to be optimal, which may not have been tried yet.
+	PredictedOptimal *Point `json:"predictedOptimal,omitempty"`
+}
+
+// API is a typed client for experiment recommendations. All unsuccessful responses are returned as an `*api.Error`.
+type API interface {
+	// GetRecommendation returns the current recommendation for the named experiment. If the server has not produced
+	// a recommendation yet (indicated by a "204 No Content"), `ErrNoRecommendation` is returned.
+	GetRecommendation(ctx context.Context, experiment string) (Recommendation, error)
+	// GetParetoFront returns the non-dominated trials of the named multi-objective experiment. The front is obtained
+	// from the server when available; if the server does not provide it (responding with "404 Not Found" or
+	// "501 Not Implemented"), the front is computed from the completed trials using the objective directions of the
+	// experiment's metrics and the result is marked as `Computed`.
+	GetParetoFront(ctx context.Context, experiment string) (ParetoFront, error)
+}
+
+// NewAPI returns a new recommendations API using the supplied client.
+func NewAPI(c api.Client) API {
+	return &httpAPI{client: c}
+}
+
+type httpAPI struct {
+	client api.Client
+}
+
+func (h *httpAPI) GetRecommendation(ctx context.Context, experiment string) (Recommendation, error) {
+	rec := Recommendation{}
+
+	u := h.client.URL(endpointExperiments + url.PathEscape(experiment) + "/recommendation").String()
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return rec, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, body, err := h.client.Do(ctx, req)
+	if err != nil {
+		return rec, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNoContent:
+		return rec, ErrNoRecommendation
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return rec, api.DecodeError(h.client, resp, body)
+	}
+
+	if err := api.CheckContentType(resp, body); err != nil {
+		return rec, err
+	}
+	err = api.ClientCodec(h.client).Unmarshal(body, &rec)
+	return rec, err
+}
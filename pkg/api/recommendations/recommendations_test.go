@@ -0,0 +1,79 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package recommendations
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thestormforge/optimize-go/pkg/api"
+	"github.com/thestormforge/optimize-go/pkg/api/apitest"
+)
+
+func TestAPI_GetRecommendation(t *testing.T) {
+	m := apitest.NewMock().
+		On(http.MethodGet, "/experiments/ready/recommendation", apitest.JSONResponse(http.StatusOK, `{
+			"bestObserved":{"trialNumber":7,"assignments":[{"parameterName":"cpu","value":250}],"values":[{"metricName":"cost","value":1.5}],"objective":1.5},
+			"predictedOptimal":{"assignments":[{"parameterName":"cpu","value":240}]}
+		}`)).
+		On(http.MethodGet, "/experiments/pending/recommendation", apitest.Response{StatusCode: http.StatusNoContent}).
+		On(http.MethodGet, "/experiments/missing/recommendation", apitest.JSONResponse(http.StatusNotFound, `{"error":"experiment not found"}`))
+
+	a := NewAPI(m)
+
+	rec, err := a.GetRecommendation(context.Background(), "ready")
+	require.NoError(t, err)
+	if assert.NotNil(t, rec.BestObserved) {
+		assert.Equal(t, int64(7), rec.BestObserved.TrialNumber)
+		assert.Equal(t, "250", rec.BestObserved.Assignments[0].Value.String())
+		if assert.NotNil(t, rec.BestObserved.Objective) {
+			assert.Equal(t, 1.5, *rec.BestObserved.Objective)
+		}
+	}
+	if assert.NotNil(t, rec.PredictedOptimal) {
+		assert.Nil(t, rec.PredictedOptimal.Objective)
+	}
+
+	_, err = a.GetRecommendation(context.Background(), "pending")
+	assert.True(t, errors.Is(err, ErrNoRecommendation))
+
+	_, err = a.GetRecommendation(context.Background(), "missing")
+	assert.True(t, errors.Is(err, api.ErrNotFound))
+	assert.False(t, errors.Is(err, ErrNoRecommendation))
+}
+
+func TestAPI_GetRecommendation_ErrorDecoder(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	endpoints, err := api.Endpoints(ts.URL, nil)
+	require.NoError(t, err)
+	errCustom := errors.New("custom")
+	client, err := api.NewClient(context.Background(), api.StaticTokenConfig("", endpoints),
+		api.WithErrorDecoder(func(*http.Response, []byte) error { return errCustom }))
+	require.NoError(t, err)
+
+	_, err = NewAPI(client).GetRecommendation(context.Background(), "missing")
+	assert.True(t, errors.Is(err, errCustom))
+}
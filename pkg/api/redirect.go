@@ -0,0 +1,67 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"net/http"
+	"strings"
+)
+
+// WithCheckRedirect sets the policy for following redirects, see `http.Client.CheckRedirect`. By default, up to 10
+// redirects are followed. Regardless of the policy, the authorization added by the client's configuration is never
+// sent when a request is redirected to a different host or scheme (e.g. a downgrade from "https" to "http").
+func WithCheckRedirect(checkRedirect func(req *http.Request, via []*http.Request) error) Option {
+	return func(c *httpClient) {
+		c.client.CheckRedirect = checkRedirect
+	}
+}
+
+// WithNoRedirects disables following redirects, the redirect response is returned to the caller instead.
+func WithNoRedirects() Option {
+	return WithCheckRedirect(func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	})
+}
+
+// isCrossOriginRedirect checks to see if the request was created by following a redirect to a different host or
+// scheme than the original request, in which case the authorization layer must not add credentials.
+func isCrossOriginRedirect(req *http.Request) bool {
+	if req.Response == nil {
+		return false
+	}
+
+	orig := req
+	for orig.Response != nil && orig.Response.Request != nil {
+		orig = orig.Response.Request
+	}
+	return orig.URL.Host != req.URL.Host || !strings.EqualFold(orig.URL.Scheme, req.URL.Scheme)
+}
+
+// redirectGuardTransport bypasses the authorization of a configuration for cross origin redirects, so transports
+// that are unaware of redirects (e.g. `oauth2.Transport`) never send credentials to another origin.
+type redirectGuardTransport struct {
+	authorized http.RoundTripper
+	base       http.RoundTripper
+}
+
+// RoundTrip uses the unauthorized base transport for cross origin redirects.
+func (t *redirectGuardTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if isCrossOriginRedirect(req) {
+		return transport(t.base).RoundTrip(req)
+	}
+	return transport(t.authorized).RoundTrip(req)
+}
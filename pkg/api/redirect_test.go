@@ -0,0 +1,129 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedirects(t *testing.T) {
+	var authorization []string
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = append(authorization, r.Header.Get("Authorization"))
+	}))
+	defer other.Close()
+
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = append(authorization, r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/same":
+			http.Redirect(w, r, ts.URL+"/final", http.StatusFound)
+		case "/other":
+			http.Redirect(w, r, other.URL+"/final", http.StatusFound)
+		}
+	}))
+	defer ts.Close()
+
+	u, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+	cfg := StaticTokenConfig("secret", map[string]*url.URL{"/": u})
+
+	cases := []struct {
+		desc     string
+		path     string
+		opts     []Option
+		status   int
+		expected []string
+	}{
+		{desc: "same host", path: "/same", status: http.StatusOK, expected: []string{"Bearer secret", "Bearer secret"}},
+		{desc: "other host", path: "/other", status: http.StatusOK, expected: []string{"Bearer secret", ""}},
+		{desc: "no redirects", path: "/other", opts: []Option{WithNoRedirects()}, status: http.StatusFound, expected: []string{"Bearer secret"}},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			authorization = nil
+			client, err := NewClient(context.Background(), cfg, c.opts...)
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodGet, client.URL(c.path).String(), nil)
+			require.NoError(t, err)
+
+			resp, _, err := client.Do(context.Background(), req)
+			require.NoError(t, err)
+			assert.Equal(t, c.status, resp.StatusCode)
+			assert.Equal(t, c.expected, authorization)
+		})
+	}
+}
+
+// headerConfig is a test configuration whose authorization ignores redirects.
+type headerConfig struct {
+	testConfig
+}
+
+func (hc *headerConfig) Authorize(_ context.Context, transport http.RoundTripper) (http.RoundTripper, error) {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", "Bearer secret")
+		return transport.RoundTrip(req)
+	}), nil
+}
+
+func TestRedirects_ConfigAuthorization(t *testing.T) {
+	var authorization []string
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = append(authorization, r.Header.Get("Authorization"))
+	}))
+	defer other.Close()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = append(authorization, r.Header.Get("Authorization"))
+		http.Redirect(w, r, other.URL+"/final", http.StatusFound)
+	}))
+	defer ts.Close()
+
+	client, err := NewClient(context.Background(), &headerConfig{testConfig: testConfig{base: ts.URL}})
+	require.NoError(t, err)
+	req, err := http.NewRequest(http.MethodGet, client.URL("/other").String(), nil)
+	require.NoError(t, err)
+	_, _, err = client.Do(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Bearer secret", ""}, authorization)
+}
+
+func TestIsCrossOriginRedirect(t *testing.T) {
+	redirect := func(from, to string) *http.Request {
+		orig, err := http.NewRequest(http.MethodGet, from, nil)
+		require.NoError(t, err)
+		req, err := http.NewRequest(http.MethodGet, to, nil)
+		require.NoError(t, err)
+		req.Response = &http.Response{Request: orig}
+		return req
+	}
+
+	assert.False(t, isCrossOriginRedirect(redirect("https://example.com/a", "https://example.com/b")))
+	assert.True(t, isCrossOriginRedirect(redirect("https://example.com/a", "https://other.example.com/b")))
+	assert.True(t, isCrossOriginRedirect(redirect("https://example.com/a", "http://example.com/b")))
+	assert.False(t, isCrossOriginRedirect(&http.Request{URL: &url.URL{Scheme: "http", Host: "example.com"}}))
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+)
+
+// HeaderRequestID is the name of the header used to correlate client and server requests.
+const HeaderRequestID = "X-Request-ID"
+
+// WithRequestIDGenerator changes how request identifiers are generated for requests which do not already have one. By
+// default, a random UUID is used; a nil generator prevents request identifiers from being added.
+func WithRequestIDGenerator(f func() string) Option {
+	return func(c *httpClient) {
+		c.requestID = f
+	}
+}
+
+// RequestID returns the effective request identifier for a response.
+func RequestID(resp *http.Response) string {
+	if id := resp.Header.Get(HeaderRequestID); id != "" {
+		return id
+	}
+	if resp.Request != nil {
+		return resp.Request.Header.Get(HeaderRequestID)
+	}
+	return ""
+}
+
+// NewUUID returns a new random (version 4) UUID.
+func NewUUID() string {
+	var u [16]byte
+	if _, err := rand.Read(u[:]); err != nil {
+		panic(err)
+	}
+	u[6] = (u[6] & 0x0f) | 0x40
+	u[8] = (u[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16])
+}
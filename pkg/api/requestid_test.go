@@ -0,0 +1,71 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestID(t *testing.T) {
+	var seen []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.Header.Get(HeaderRequestID))
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	get := func(client Client, id string) error {
+		req, err := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+		require.NoError(t, err)
+		if id != "" {
+			req.Header.Set(HeaderRequestID, id)
+		}
+		resp, body, err := client.Do(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, id, req.Header.Get(HeaderRequestID), "caller request was modified")
+		return NewError(resp, body)
+	}
+
+	var apiErr *Error
+
+	err := get(newTestClient(t, ts), "")
+	if assert.True(t, errors.As(err, &apiErr)) {
+		assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`), apiErr.RequestID)
+		assert.Equal(t, seen[len(seen)-1], apiErr.RequestID)
+	}
+
+	err = get(newTestClient(t, ts, WithRequestIDGenerator(func() string { return "generated" })), "")
+	if assert.True(t, errors.As(err, &apiErr)) {
+		assert.Equal(t, "generated", apiErr.RequestID)
+	}
+
+	err = get(newTestClient(t, ts), "explicit")
+	if assert.True(t, errors.As(err, &apiErr)) {
+		assert.Equal(t, "explicit", apiErr.RequestID)
+	}
+
+	_ = get(newTestClient(t, ts, WithRequestIDGenerator(nil)), "")
+	assert.Empty(t, seen[len(seen)-1])
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// RequestOption overrides a client default for a single call.
+type RequestOption func(*requestOptions)
+
+// requestOptions are the per-call overrides carried by a context.
+type requestOptions struct {
+	header         http.Header
+	idempotencyKey string
+	retry          *retryPolicy
+	timeout        *time.Duration
+}
+
+type requestOptionsKey struct{}
+
+// WithRequestOption returns a context that overrides the client defaults for calls made using it. Options already
+// present on the supplied context are preserved unless they are overridden. Context options take precedence over the
+// options the client was created with, however headers (including the idempotency key) set directly on the request
+// always take precedence over both.
+func WithRequestOption(ctx context.Context, opts ...RequestOption) context.Context {
+	ro := &requestOptions{}
+	if prev, ok := ctx.Value(requestOptionsKey{}).(*requestOptions); ok {
+		*ro = *prev
+		ro.header = prev.header.Clone()
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(ro)
+		}
+	}
+	return context.WithValue(ctx, requestOptionsKey{}, ro)
+}
+
+// WithRequestHeader adds a header to the call, replacing any default header of the same name.
+func WithRequestHeader(key, value string) RequestOption {
+	return func(ro *requestOptions) {
+		if ro.header == nil {
+			ro.header = make(http.Header)
+		}
+		ro.header.Add(key, value)
+	}
+}
+
+// WithRequestIdempotencyKey sets the idempotency key of the call, allowing it to be safely retried. If the supplied
+// key is empty, a random UUID is used.
+func WithRequestIdempotencyKey(key string) RequestOption {
+	return func(ro *requestOptions) {
+		if key == "" {
+			key = NewUUID()
+		}
+		ro.idempotencyKey = key
+	}
+}
+
+// WithRequestRetry overrides the retry policy of the call, see `WithRetry`. Use a `maxAttempts` of one (or less) to
+// disable retries.
+func WithRequestRetry(maxAttempts int, baseDelay time.Duration) RequestOption {
+	return func(ro *requestOptions) {
+		ro.retry = &retryPolicy{maxAttempts: maxAttempts, baseDelay: baseDelay}
+	}
+}
+
+// WithRequestTimeout overrides the client timeout of the call, see `WithTimeout`.
+func WithRequestTimeout(d time.Duration) RequestOption {
+	return func(ro *requestOptions) {
+		if d < 0 {
+			d = 0
+		}
+		ro.timeout = &d
+	}
+}
+
+// withRequestOptions returns the client to use for a call made with the supplied context, along with the options
+// that apply to the request itself.
+func (c *httpClient) withRequestOptions(ctx context.Context) (*httpClient, *requestOptions) {
+	ro, ok := ctx.Value(requestOptionsKey{}).(*requestOptions)
+	if !ok {
+		return c, nil
+	}
+	if ro.retry == nil && ro.timeout == nil {
+		return c, ro
+	}
+
+	hc := *c
+	if ro.retry != nil {
+		hc.retry.maxAttempts = ro.retry.maxAttempts
+		hc.retry.baseDelay = ro.retry.baseDelay
+	}
+	if ro.timeout != nil {
+		hc.client.Timeout = *ro.timeout
+	}
+	return &hc, ro
+}
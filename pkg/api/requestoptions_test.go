@@ -0,0 +1,116 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithRequestOption(t *testing.T) {
+	var attempts int32
+	var received http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(100 * time.Millisecond)
+			return
+		}
+		atomic.AddInt32(&attempts, 1)
+		received = r.Header.Clone()
+		if r.URL.Path == "/unavailable" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts,
+		WithRetry(3, time.Millisecond),
+		WithDefaultHeaders(http.Header{"X-Tenant": {"acme"}, "X-Feature": {"beta"}}),
+	)
+	do := func(ctx context.Context, method, path string, header http.Header) (*http.Response, error) {
+		atomic.StoreInt32(&attempts, 0)
+		req, err := http.NewRequest(method, client.URL(path).String(), nil)
+		require.NoError(t, err)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		resp, _, err := client.Do(ctx, req)
+		return resp, err
+	}
+
+	t.Run("headers", func(t *testing.T) {
+		ctx := WithRequestOption(context.Background(), WithRequestHeader("X-Feature", "gamma"), WithRequestHeader("X-Call", "1"))
+		_, err := do(ctx, http.MethodGet, "/", http.Header{"X-Call": {"2"}})
+		require.NoError(t, err)
+		assert.Equal(t, "acme", received.Get("X-Tenant"))
+		assert.Equal(t, []string{"gamma"}, received.Values("X-Feature"))
+		assert.Equal(t, []string{"2"}, received.Values("X-Call"), "request headers take precedence")
+
+		// Options accumulate without modifying the parent context
+		child := WithRequestOption(ctx, WithRequestHeader("X-Other", "yes"))
+		_, err = do(child, http.MethodGet, "/", nil)
+		require.NoError(t, err)
+		assert.Equal(t, "gamma", received.Get("X-Feature"))
+		assert.Equal(t, "yes", received.Get("X-Other"))
+		_, err = do(ctx, http.MethodGet, "/", nil)
+		require.NoError(t, err)
+		assert.Empty(t, received.Get("X-Other"))
+	})
+
+	t.Run("idempotency key", func(t *testing.T) {
+		ctx := WithRequestOption(context.Background(), WithRequestIdempotencyKey("abc"))
+		resp, err := do(ctx, http.MethodPost, "/unavailable", nil)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.Equal(t, "abc", received.Get(HeaderIdempotencyKey))
+		assert.Equal(t, int32(3), atomic.LoadInt32(&attempts), "calls with an idempotency key are retried")
+	})
+
+	t.Run("retry", func(t *testing.T) {
+		_, err := do(WithRequestOption(context.Background(), WithRequestRetry(1, 0)), http.MethodGet, "/unavailable", nil)
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+
+		_, err = do(WithRequestOption(context.Background(), WithRequestRetry(5, time.Millisecond)), http.MethodGet, "/unavailable", nil)
+		require.NoError(t, err)
+		assert.Equal(t, int32(5), atomic.LoadInt32(&attempts))
+
+		_, err = do(context.Background(), http.MethodGet, "/unavailable", nil)
+		require.NoError(t, err)
+		assert.Equal(t, int32(3), atomic.LoadInt32(&attempts), "client defaults are unchanged")
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		ctx := WithRequestOption(context.Background(), WithRequestTimeout(10*time.Millisecond), WithRequestRetry(1, 0))
+		_, err := do(ctx, http.MethodGet, "/slow", nil)
+		var netErr interface{ Timeout() bool }
+		if assert.True(t, errors.As(err, &netErr)) {
+			assert.True(t, netErr.Timeout())
+		}
+
+		_, err = do(context.Background(), http.MethodGet, "/slow", nil)
+		assert.NoError(t, err)
+	})
+}
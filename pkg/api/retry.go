@@ -0,0 +1,270 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxBackoffDelay is the upper limit on the amount of time spent waiting between attempts.
+const maxBackoffDelay = 2 * time.Minute
+
+// DefaultMaxRetryAfter is the default upper limit on the amount of time a server can ask us to wait using Retry-After.
+const DefaultMaxRetryAfter = 2 * time.Minute
+
+// WithRetry enables automatic retries of idempotent requests (including requests with an idempotency key) that fail
+// with a transient error. Up to `maxAttempts` attempts will be made (including the initial attempt), waiting an
+// exponentially increasing, randomized amount of time starting with `baseDelay` between attempts. Requests cancelled by
+// the caller are never retried; a request that exceeds the client timeout is retried as long as the caller's own
+// context is still live.
+func WithRetry(maxAttempts int, baseDelay time.Duration) Option {
+	return func(c *httpClient) {
+		c.retry.maxAttempts = maxAttempts
+		c.retry.baseDelay = baseDelay
+	}
+}
+
+// WithMaxRetryAfter limits the amount of time the client will wait when a server responds with a Retry-After header.
+func WithMaxRetryAfter(d time.Duration) Option {
+	return func(c *httpClient) {
+		c.retry.maxRetryAfter = d
+	}
+}
+
+// WithBackoffRand sets the source of the randomized jitter added to the delay between attempts, for example to allow
+// tests to supply a seeded source and expect exact delays. The source is safe to share between clients. By default,
+// the randomly seeded global source is used.
+func WithBackoffRand(r *rand.Rand) Option {
+	return func(c *httpClient) {
+		c.retry.rand = nil
+		if r != nil {
+			c.retry.rand = &lockedRand{r: r}
+		}
+	}
+}
+
+// retryPolicy describes how failed requests should be retried.
+type retryPolicy struct {
+	maxAttempts   int
+	baseDelay     time.Duration
+	maxRetryAfter time.Duration
+	rand          *lockedRand
+	clock         Clock
+}
+
+// lockedRand allows a random source to be used concurrently.
+type lockedRand struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+// int63n returns a random number in [0,n), using the global source if there is no random source.
+func (lr *lockedRand) int63n(n int64) int64 {
+	if lr == nil {
+		return rand.Int63n(n)
+	}
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	return lr.r.Int63n(n)
+}
+
+// wait blocks for the specified amount of time or until the context is done.
+func (p *retryPolicy) wait(ctx context.Context, d time.Duration) error {
+	return clockOf(p.clock).Sleep(ctx, d)
+}
+
+// backoff returns the amount of time to wait after the specified (1-based) attempt.
+func (p *retryPolicy) backoff(attempt int) time.Duration {
+	d := p.baseDelay
+	for i := 1; i < attempt && d < maxBackoffDelay; i++ {
+		d *= 2
+	}
+	if d > maxBackoffDelay {
+		d = maxBackoffDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+
+	// Use "equal jitter" so we always wait at least half the computed delay
+	return d/2 + time.Duration(p.rand.int63n(int64(d/2)+1))
+}
+
+// delay returns the amount of time to wait after the specified (1-based) attempt produced the supplied response.
+func (p *retryPolicy) delay(attempt int, resp *http.Response) time.Duration {
+	if resp != nil && (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable) {
+		if ra, ok := retryAfter(resp.Header, clockOf(p.clock).Now()); ok {
+			if ra > p.maxRetryAfter {
+				ra = p.maxRetryAfter
+			}
+			return ra
+		}
+	}
+	return p.backoff(attempt)
+}
+
+// send performs the request, retrying transient failures according to the retry policy.
+func (c *httpClient) send(req *http.Request) (*http.Response, error) {
+	if c.retry.maxAttempts <= 1 || !(isIdempotent(req.Method) || req.Header.Get(HeaderIdempotencyKey) != "") {
+		return c.client.Do(req)
+	}
+
+	if err := rewindable(req); err != nil {
+		return nil, err
+	}
+
+	ctx := req.Context()
+	if c.retryBudget != nil {
+		c.retryBudget.request()
+	}
+	for attempt := 1; ; attempt++ {
+		resp, err := c.client.Do(req)
+		if attempt >= c.retry.maxAttempts || !isTransient(resp, err) || ctx.Err() != nil {
+			return resp, err
+		}
+
+		// Do not bother waiting if the context will expire before the next attempt
+		delay := c.retry.delay(attempt, resp)
+		if deadline, ok := ctx.Deadline(); ok && deadline.Sub(clockOf(c.retry.clock).Now()) < delay {
+			return resp, err
+		}
+
+		// Do not retry once the budget is exhausted
+		if c.retryBudget != nil && !c.retryBudget.withdraw() {
+			return resp, err
+		}
+
+		if resp != nil {
+			discard(resp)
+		}
+
+		if err := c.retry.wait(ctx, delay); err != nil {
+			return nil, err
+		}
+
+		if req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// isIdempotent checks to see if requests using the specified method are safe to retry.
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+// isTransient checks to see if the outcome of a request is worth retrying.
+func isTransient(resp *http.Response, err error) bool {
+	if err != nil {
+		// Cancellation is a decision made by the caller, not a failure of the server
+		if errors.Is(err, context.Canceled) {
+			return false
+		}
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return true
+		}
+		var opErr *net.OpError
+		return errors.As(err, &opErr)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// RetryAfter returns the amount of time indicated by the Retry-After header, which may be expressed as either a
+// number of seconds or an HTTP date. Dates in the past produce a zero duration.
+func RetryAfter(header http.Header) (time.Duration, bool) {
+	return retryAfter(header, time.Now())
+}
+
+// retryAfter returns the amount of time indicated by the Retry-After header relative to the supplied time.
+func retryAfter(header http.Header, now time.Time) (time.Duration, bool) {
+	v := strings.TrimSpace(header.Get("Retry-After"))
+	if v == "" {
+		return 0, false
+	}
+
+	if s, err := strconv.ParseInt(v, 10, 64); err == nil {
+		if s < 0 {
+			return 0, false
+		}
+		// Clamp large values instead of letting the duration overflow
+		if s > int64(math.MaxInt64/time.Second) {
+			return math.MaxInt64, true
+		}
+		return time.Duration(s) * time.Second, true
+	}
+
+	if t, err := http.ParseTime(v); err == nil {
+		d := t.Sub(now)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+
+	return 0, false
+}
+
+// rewindable ensures the body of the request can be sent multiple times.
+func rewindable(req *http.Request) error {
+	if req.Body == nil || req.Body == http.NoBody || req.GetBody != nil {
+		return nil
+	}
+
+	b, err := ioutil.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return err
+	}
+
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(b)), nil
+	}
+	req.Body, _ = req.GetBody()
+	return nil
+}
+
+// discard drains and closes an unused response body so the connection can be reused.
+func discard(resp *http.Response) {
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 4<<10))
+	_ = resp.Body.Close()
+}
@@ -0,0 +1,352 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyHandler fails with the supplied status code the specified number of times before succeeding.
+func flakyHandler(failures int32, code int, attempts *int32) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if atomic.AddInt32(attempts, 1) <= failures {
+			w.WriteHeader(code)
+			return
+		}
+		_, _ = w.Write(body)
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	cases := []struct {
+		desc             string
+		method           string
+		failures         int32
+		code             int
+		expectedAttempts int32
+		expectedStatus   int
+	}{
+		{
+			desc:             "recovers",
+			method:           http.MethodPut,
+			failures:         2,
+			code:             http.StatusServiceUnavailable,
+			expectedAttempts: 3,
+			expectedStatus:   http.StatusOK,
+		},
+		{
+			desc:             "exhausted",
+			method:           http.MethodGet,
+			failures:         5,
+			code:             http.StatusBadGateway,
+			expectedAttempts: 3,
+			expectedStatus:   http.StatusBadGateway,
+		},
+		{
+			desc:             "not idempotent",
+			method:           http.MethodPost,
+			failures:         1,
+			code:             http.StatusServiceUnavailable,
+			expectedAttempts: 1,
+			expectedStatus:   http.StatusServiceUnavailable,
+		},
+		{
+			desc:             "not transient",
+			method:           http.MethodGet,
+			failures:         1,
+			code:             http.StatusInternalServerError,
+			expectedAttempts: 1,
+			expectedStatus:   http.StatusInternalServerError,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			var attempts int32
+			ts := httptest.NewServer(flakyHandler(c.failures, c.code, &attempts))
+			defer ts.Close()
+
+			client := newTestClient(t, ts, WithRetry(3, time.Millisecond))
+			req, err := http.NewRequest(c.method, client.URL("/").String(), strings.NewReader("payload"))
+			require.NoError(t, err)
+
+			resp, body, err := client.Do(context.Background(), req)
+			require.NoError(t, err)
+			assert.Equal(t, c.expectedStatus, resp.StatusCode)
+			assert.Equal(t, c.expectedAttempts, atomic.LoadInt32(&attempts))
+			if resp.StatusCode == http.StatusOK {
+				assert.Equal(t, "payload", string(body))
+			}
+		})
+	}
+}
+
+func TestWithRetry_ContextCancelled(t *testing.T) {
+	var attempts int32
+	ts := httptest.NewServer(flakyHandler(100, http.StatusServiceUnavailable, &attempts))
+	defer ts.Close()
+
+	client := newTestClient(t, ts, WithRetry(100, time.Hour))
+	req, err := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, _, err = client.Do(ctx, req)
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+}
+
+func TestWithRetry_CancelledVsDeadline(t *testing.T) {
+	var attempts int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		<-r.Context().Done()
+	}))
+	defer ts.Close()
+
+	do := func(ctx context.Context, client Client) error {
+		req, err := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+		require.NoError(t, err)
+		_, _, err = client.Do(ctx, req)
+		return err
+	}
+
+	t.Run("cancelled", func(t *testing.T) {
+		atomic.StoreInt32(&attempts, 0)
+		client := newTestClient(t, ts, WithRetry(3, time.Millisecond))
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(20*time.Millisecond, cancel)
+
+		err := do(ctx, client)
+		assert.True(t, errors.Is(err, context.Canceled))
+		assert.False(t, errors.Is(err, context.DeadlineExceeded))
+		assert.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+	})
+
+	t.Run("caller deadline", func(t *testing.T) {
+		atomic.StoreInt32(&attempts, 0)
+		client := newTestClient(t, ts, WithRetry(3, time.Millisecond))
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		err := do(ctx, client)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		assert.False(t, errors.Is(err, context.Canceled))
+		assert.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+	})
+
+	t.Run("client timeout", func(t *testing.T) {
+		atomic.StoreInt32(&attempts, 0)
+		client := newTestClient(t, ts, WithRetry(3, time.Millisecond), WithTimeout(20*time.Millisecond))
+
+		// The caller is still waiting, so each attempt that times out is retried
+		err := do(context.Background(), client)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		assert.False(t, errors.Is(err, context.Canceled))
+		assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+	})
+}
+
+func TestClient_Do_CancelledWhileReading(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("partial"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts)
+	for _, expected := range []error{context.Canceled, context.DeadlineExceeded} {
+		t.Run(expected.Error(), func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			if expected == context.DeadlineExceeded {
+				ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+			} else {
+				time.AfterFunc(20*time.Millisecond, cancel)
+			}
+			defer cancel()
+
+			req, err := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+			require.NoError(t, err)
+			_, _, err = client.Do(ctx, req)
+			assert.Equal(t, expected, err)
+		})
+	}
+}
+
+func TestWithBackoffRand(t *testing.T) {
+	var attempts int32
+	ts := httptest.NewServer(flakyHandler(3, http.StatusBadGateway, &attempts))
+	defer ts.Close()
+
+	clock := &sleepRecorder{}
+	client := newTestClient(t, ts, WithRetry(4, 100*time.Millisecond), WithBackoffRand(rand.New(rand.NewSource(1))), WithClock(clock))
+
+	req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+	require.NoError(t, err)
+	resp, _, err := client.Do(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// The same seed produces the same "equal jitter" delays
+	r := rand.New(rand.NewSource(1))
+	var expected []time.Duration
+	for d := 100 * time.Millisecond; d <= 400*time.Millisecond; d *= 2 {
+		expected = append(expected, d/2+time.Duration(r.Int63n(int64(d/2)+1)))
+	}
+	assert.Equal(t, expected, clock.delays)
+}
+
+// sleepRecorder is a real clock which records the requested sleeps instead of waiting, the current time may be fixed.
+type sleepRecorder struct {
+	realClock
+	now    time.Time
+	delays []time.Duration
+}
+
+func (c *sleepRecorder) Now() time.Time {
+	if !c.now.IsZero() {
+		return c.now
+	}
+	return c.realClock.Now()
+}
+
+func (c *sleepRecorder) Sleep(_ context.Context, d time.Duration) error {
+	c.delays = append(c.delays, d)
+	return nil
+}
+
+func TestRetryAfter(t *testing.T) {
+	cases := []struct {
+		desc       string
+		retryAfter string
+		expected   time.Duration
+		expectedOk bool
+	}{
+		{
+			desc: "missing",
+		},
+		{
+			desc:       "seconds",
+			retryAfter: "120",
+			expected:   120 * time.Second,
+			expectedOk: true,
+		},
+		{
+			desc:       "huge seconds",
+			retryAfter: "99999999999999999",
+			expected:   math.MaxInt64,
+			expectedOk: true,
+		},
+		{
+			desc:       "past date",
+			retryAfter: "Fri, 31 Dec 1999 23:59:59 GMT",
+			expected:   0,
+			expectedOk: true,
+		},
+		{
+			desc:       "invalid",
+			retryAfter: "soon",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			h := http.Header{}
+			if c.retryAfter != "" {
+				h.Set("Retry-After", c.retryAfter)
+			}
+			actual, ok := RetryAfter(h)
+			assert.Equal(t, c.expected, actual)
+			assert.Equal(t, c.expectedOk, ok)
+		})
+	}
+
+	t.Run("future date", func(t *testing.T) {
+		h := http.Header{}
+		h.Set("Retry-After", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+		actual, ok := RetryAfter(h)
+		assert.True(t, ok)
+		assert.InDelta(t, float64(time.Hour), float64(actual), float64(2*time.Second))
+	})
+}
+
+func TestRetryAfter_Clock(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	var attempts int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.Header().Set("Retry-After", now.Add(time.Minute).Format(http.TimeFormat))
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	clock := &sleepRecorder{now: now}
+	client := newTestClient(t, ts, WithRetry(2, time.Millisecond), WithClock(clock))
+
+	// The Retry-After date and the deadline are both measured using the clock
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+	require.NoError(t, err)
+	resp, _, err := client.Do(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []time.Duration{time.Minute}, clock.delays)
+}
+
+func TestWithMaxRetryAfter(t *testing.T) {
+	var attempts int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts, WithRetry(2, time.Hour), WithMaxRetryAfter(10*time.Millisecond))
+	req, err := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+	require.NoError(t, err)
+
+	resp, _, err := client.Do(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&attempts))
+}
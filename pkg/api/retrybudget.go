@@ -0,0 +1,93 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"sync"
+	"time"
+)
+
+// retryBudgetWindow is the sliding window over which the retry budget is computed.
+const retryBudgetWindow = 10 * time.Second
+
+// WithRetryBudget limits the retries made by the client (see `WithRetry`) so that, over a sliding ten second window,
+// the number of retries stays below `ratio` times the number of original requests plus an allowance of
+// `minRetriesPerSec` retries each second (so retries remain possible when there is little traffic). Once the budget
+// is exhausted, failed requests return immediately without retrying instead of amplifying the load on a struggling
+// server. The budget is shared by all requests made using the client.
+func WithRetryBudget(ratio float64, minRetriesPerSec int) Option {
+	return func(c *httpClient) {
+		c.retryBudget = &retryBudget{ratio: ratio, minPerSec: minRetriesPerSec}
+	}
+}
+
+// retryBudgetBucket counts the requests and retries for a single second of the window.
+type retryBudgetBucket struct {
+	second   int64
+	requests int
+	retries  int
+}
+
+// retryBudget tracks the ratio of retries to requests.
+type retryBudget struct {
+	ratio     float64
+	minPerSec int
+	clock     Clock
+
+	mu      sync.Mutex
+	buckets [int(retryBudgetWindow / time.Second)]retryBudgetBucket
+}
+
+// bucket returns the bucket for the current second, resetting it if it is stale.
+func (b *retryBudget) bucket(now time.Time) *retryBudgetBucket {
+	s := now.Unix()
+	bkt := &b.buckets[s%int64(len(b.buckets))]
+	if bkt.second != s {
+		*bkt = retryBudgetBucket{second: s}
+	}
+	return bkt
+}
+
+// request records an original request.
+func (b *retryBudget) request() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.bucket(clockOf(b.clock).Now()).requests++
+}
+
+// withdraw checks to see if a retry is allowed, recording it if it is.
+func (b *retryBudget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := clockOf(b.clock).Now()
+	cur := b.bucket(now)
+	var requests, retries int
+	for i := range b.buckets {
+		if now.Unix()-b.buckets[i].second < int64(len(b.buckets)) {
+			requests += b.buckets[i].requests
+			retries += b.buckets[i].retries
+		}
+	}
+
+	allowed := float64(b.minPerSec)*retryBudgetWindow.Seconds() + b.ratio*float64(requests)
+	if float64(retries) >= allowed {
+		return false
+	}
+	cur.retries++
+	return true
+}
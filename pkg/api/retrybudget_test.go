@@ -0,0 +1,65 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithRetryBudget(t *testing.T) {
+	var attempts int32
+	ts := httptest.NewServer(flakyHandler(1000, http.StatusServiceUnavailable, &attempts))
+	defer ts.Close()
+
+	client := newTestClient(t, ts, WithRetry(10, time.Millisecond), WithRetryBudget(0.1, 0))
+	for i := 0; i < 10; i++ {
+		req, err := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+		require.NoError(t, err)
+		resp, _, err := client.Do(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	}
+
+	// Only a single retry fits in a 10% budget for 10 requests
+	assert.Equal(t, int32(11), atomic.LoadInt32(&attempts))
+}
+
+func TestRetryBudget_MinRetriesPerSec(t *testing.T) {
+	b := &retryBudget{minPerSec: 2}
+	for i := 0; i < 20; i++ {
+		assert.True(t, b.withdraw())
+	}
+	assert.False(t, b.withdraw())
+
+	// Requests add to the budget
+	for i := 0; i < 10; i++ {
+		b.request()
+	}
+	b.ratio = 0.5
+	for i := 0; i < 5; i++ {
+		assert.True(t, b.withdraw())
+	}
+	assert.False(t, b.withdraw())
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schema
+
+import (
+	"reflect"
+
+	"github.com/thestormforge/optimize-go/pkg/api"
+)
+
+// WithStrictSchemaValidation validates every response body decoded by `api.DoJSON` and the typed API clients against
+// the schema, failing with an error matching `ErrSchemaViolation` if the body does not conform. Bodies are validated
+// using the schema definition with the same name as the Go type they are decoded into (e.g. "ExperimentItem"), see
+// `Codec`. The codec configured on the client using `api.WithCodec` still performs the actual decoding. Validation is
+// disabled unless this option is used; it is intended for tests of the server contract rather than for production use.
+func WithStrictSchemaValidation(s *Schema) api.Option {
+	return api.WithCodecWrapper(func(c api.Codec) api.Codec {
+		return &Codec{Schema: s, Codec: c}
+	})
+}
+
+// Codec validates data against a schema before decoding it.
+type Codec struct {
+	// Schema is used to validate data before it is decoded.
+	Schema *Schema
+	// Codec performs the actual serialization, the default is an `api.JSONCodec`.
+	Codec api.Codec
+}
+
+// Marshal returns the encoding of the supplied value without validation.
+func (c *Codec) Marshal(v any) ([]byte, error) {
+	return c.codec().Marshal(v)
+}
+
+// Unmarshal validates the data using the definition named after the type of v before decoding it. Data decoded into
+// an unnamed type (e.g. a map) is validated using the root schema; it is an error if the schema does not have a
+// definition for a named type.
+func (c *Codec) Unmarshal(data []byte, v any) error {
+	validate := c.Schema.Validate
+	if name := typeName(v); name != "" {
+		validate = func(data []byte) error { return c.Schema.ValidateDefinition(name, data) }
+	}
+	if err := validate(data); err != nil {
+		return err
+	}
+	return c.codec().Unmarshal(data, v)
+}
+
+func (c *Codec) codec() api.Codec {
+	if c.Codec != nil {
+		return c.Codec
+	}
+	return api.JSONCodec{}
+}
+
+// typeName returns the name of the type pointed to by v.
+func typeName(v any) string {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil {
+		return ""
+	}
+	return t.Name()
+}
@@ -0,0 +1,419 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package schema validates API responses against a JSON schema, primarily to detect changes to the server contract
+// when testing against a staging server.
+//
+// Only the structural subset of JSON Schema is supported: "type", "enum", "properties", "required",
+// "additionalProperties", "items", "minimum", "maximum", "minLength", "maxLength", "pattern", "allOf", "anyOf",
+// "oneOf" and "$ref" (limited to "#/$defs/..." and "#/definitions/..."). Other keywords are ignored.
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrSchemaViolation matches errors for responses that do not conform to the schema.
+	ErrSchemaViolation = errors.New("schema violation")
+	// ErrUnknownDefinition matches errors for validations against a definition the schema does not contain.
+	ErrUnknownDefinition = errors.New("unknown schema definition")
+)
+
+// Violation describes a single mismatch between a document and the schema.
+type Violation struct {
+	// Path is the JSON pointer to the offending value, empty for the document itself.
+	Path string
+	// Message describes the mismatch.
+	Message string
+}
+
+func (v Violation) String() string {
+	return "/" + strings.TrimPrefix(v.Path, "/") + ": " + v.Message
+}
+
+// ViolationError lists the mismatches found while validating a document.
+type ViolationError struct {
+	// Definition is the name of the schema definition used for validation, empty for the root schema.
+	Definition string
+	// Violations are the individual mismatches.
+	Violations []Violation
+}
+
+func (e *ViolationError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i := range e.Violations {
+		msgs[i] = e.Violations[i].String()
+	}
+	return fmt.Sprintf("%s: %s", ErrSchemaViolation, strings.Join(msgs, "; "))
+}
+
+// Is allows the error to match `ErrSchemaViolation`.
+func (e *ViolationError) Is(target error) bool {
+	return target == ErrSchemaViolation
+}
+
+// Schema is a compiled JSON schema.
+type Schema struct {
+	root *node
+}
+
+// Compile parses a JSON schema.
+func Compile(data []byte) (*Schema, error) {
+	root := &node{}
+	if err := json.Unmarshal(data, root); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	if err := root.compile(root, map[*node]bool{}); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	return &Schema{root: root}, nil
+}
+
+// MustCompile is like `Compile` but panics if the schema is not valid, for use with embedded schemas.
+func MustCompile(data []byte) *Schema {
+	s, err := Compile(data)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Validate checks the JSON document against the root schema, returning a `*ViolationError` if it does not conform.
+func (s *Schema) Validate(data []byte) error {
+	return s.validate("", s.root, data)
+}
+
+// ValidateDefinition checks the JSON document against a named definition of the schema (from "$defs" or
+// "definitions"). If the schema does not contain the definition, an error matching `ErrUnknownDefinition` is returned.
+func (s *Schema) ValidateDefinition(name string, data []byte) error {
+	def := s.root.definition(name)
+	if def == nil {
+		return fmt.Errorf("%w %q", ErrUnknownDefinition, name)
+	}
+	return s.validate(name, def, data)
+}
+
+func (s *Schema) validate(name string, n *node, data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return err
+	}
+
+	var vs []Violation
+	n.validate("", doc, &vs)
+	if len(vs) > 0 {
+		return &ViolationError{Definition: name, Violations: vs}
+	}
+	return nil
+}
+
+// node is a single (sub-)schema.
+type node struct {
+	Type                 types            `json:"type"`
+	Enum                 []interface{}    `json:"enum"`
+	Properties           map[string]*node `json:"properties"`
+	Required             []string         `json:"required"`
+	AdditionalProperties *additional      `json:"additionalProperties"`
+	Items                *node            `json:"items"`
+	Minimum              *json.Number     `json:"minimum"`
+	Maximum              *json.Number     `json:"maximum"`
+	MinLength            *int             `json:"minLength"`
+	MaxLength            *int             `json:"maxLength"`
+	Pattern              string           `json:"pattern"`
+	AllOf                []*node          `json:"allOf"`
+	AnyOf                []*node          `json:"anyOf"`
+	OneOf                []*node          `json:"oneOf"`
+	Ref                  string           `json:"$ref"`
+	Defs                 map[string]*node `json:"$defs"`
+	Definitions          map[string]*node `json:"definitions"`
+
+	pattern *regexp.Regexp
+	ref     *node
+}
+
+// types is the list of allowed JSON types, which may be expressed as a single string.
+type types []string
+
+func (t *types) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*t = types{s}
+		return nil
+	}
+	return json.Unmarshal(b, (*[]string)(t))
+}
+
+// additional is the value of "additionalProperties", which may be a boolean or a schema.
+type additional struct {
+	allowed bool
+	schema  *node
+}
+
+func (a *additional) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &a.allowed); err == nil {
+		return nil
+	}
+	a.allowed = true
+	a.schema = &node{}
+	return json.Unmarshal(b, a.schema)
+}
+
+// definition returns the named definition.
+func (n *node) definition(name string) *node {
+	if d, ok := n.Defs[name]; ok {
+		return d
+	}
+	return n.Definitions[name]
+}
+
+// compile resolves references and regular expressions.
+func (n *node) compile(root *node, seen map[*node]bool) error {
+	if n == nil || seen[n] {
+		return nil
+	}
+	seen[n] = true
+
+	if n.Ref != "" {
+		var name string
+		switch {
+		case strings.HasPrefix(n.Ref, "#/$defs/"):
+			name = strings.TrimPrefix(n.Ref, "#/$defs/")
+		case strings.HasPrefix(n.Ref, "#/definitions/"):
+			name = strings.TrimPrefix(n.Ref, "#/definitions/")
+		default:
+			return fmt.Errorf("unsupported reference %q", n.Ref)
+		}
+		if n.ref = root.definition(name); n.ref == nil {
+			return fmt.Errorf("unknown reference %q", n.Ref)
+		}
+	}
+
+	if n.Pattern != "" {
+		var err error
+		if n.pattern, err = regexp.Compile(n.Pattern); err != nil {
+			return err
+		}
+	}
+
+	children := []*node{n.Items}
+	children = append(children, n.AllOf...)
+	children = append(children, n.AnyOf...)
+	children = append(children, n.OneOf...)
+	for _, m := range []map[string]*node{n.Properties, n.Defs, n.Definitions} {
+		for _, c := range m {
+			children = append(children, c)
+		}
+	}
+	if n.AdditionalProperties != nil {
+		children = append(children, n.AdditionalProperties.schema)
+	}
+	for _, c := range children {
+		if err := c.compile(root, seen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validate appends any violations of the value at the supplied JSON pointer.
+func (n *node) validate(path string, v interface{}, vs *[]Violation) {
+	if n.ref != nil {
+		n.ref.validate(path, v, vs)
+	}
+
+	if len(n.Type) > 0 && !n.Type.match(v) {
+		*vs = append(*vs, Violation{Path: path, Message: fmt.Sprintf("expected %s, got %s", strings.Join(n.Type, " or "), typeOf(v))})
+		return
+	}
+
+	if len(n.Enum) > 0 {
+		found := false
+		for _, e := range n.Enum {
+			if equal(e, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			*vs = append(*vs, Violation{Path: path, Message: fmt.Sprintf("value %s is not one of the allowed values", encode(v))})
+		}
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		n.validateObject(path, v, vs)
+	case []interface{}:
+		if n.Items != nil {
+			for i := range v {
+				n.Items.validate(fmt.Sprintf("%s/%d", path, i), v[i], vs)
+			}
+		}
+	case string:
+		l := len([]rune(v))
+		if n.MinLength != nil && l < *n.MinLength {
+			*vs = append(*vs, Violation{Path: path, Message: fmt.Sprintf("length %d is less than %d", l, *n.MinLength)})
+		}
+		if n.MaxLength != nil && l > *n.MaxLength {
+			*vs = append(*vs, Violation{Path: path, Message: fmt.Sprintf("length %d is greater than %d", l, *n.MaxLength)})
+		}
+		if n.pattern != nil && !n.pattern.MatchString(v) {
+			*vs = append(*vs, Violation{Path: path, Message: fmt.Sprintf("value %q does not match %q", v, n.Pattern)})
+		}
+	case json.Number:
+		if n.Minimum != nil && compare(v, *n.Minimum) < 0 {
+			*vs = append(*vs, Violation{Path: path, Message: fmt.Sprintf("value %s is less than %s", v, *n.Minimum)})
+		}
+		if n.Maximum != nil && compare(v, *n.Maximum) > 0 {
+			*vs = append(*vs, Violation{Path: path, Message: fmt.Sprintf("value %s is greater than %s", v, *n.Maximum)})
+		}
+	}
+
+	for _, s := range n.AllOf {
+		s.validate(path, v, vs)
+	}
+	if len(n.AnyOf) > 0 && n.matching(n.AnyOf, path, v) == 0 {
+		*vs = append(*vs, Violation{Path: path, Message: "value does not match any of the allowed schemas"})
+	}
+	if len(n.OneOf) > 0 {
+		if c := n.matching(n.OneOf, path, v); c != 1 {
+			*vs = append(*vs, Violation{Path: path, Message: fmt.Sprintf("value matches %d schemas, expected exactly one", c)})
+		}
+	}
+}
+
+func (n *node) validateObject(path string, v map[string]interface{}, vs *[]Violation) {
+	for _, r := range n.Required {
+		if _, ok := v[r]; !ok {
+			*vs = append(*vs, Violation{Path: path, Message: fmt.Sprintf("missing required property %q", r)})
+		}
+	}
+
+	keys := make([]string, 0, len(v))
+	for k := range v {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		p := path + "/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(k)
+		switch s, ok := n.Properties[k]; {
+		case ok:
+			s.validate(p, v[k], vs)
+		case n.AdditionalProperties == nil:
+		case !n.AdditionalProperties.allowed:
+			*vs = append(*vs, Violation{Path: p, Message: "unexpected property"})
+		case n.AdditionalProperties.schema != nil:
+			n.AdditionalProperties.schema.validate(p, v[k], vs)
+		}
+	}
+}
+
+// matching returns the number of schemas the value conforms to.
+func (n *node) matching(schemas []*node, path string, v interface{}) int {
+	c := 0
+	for _, s := range schemas {
+		var ignored []Violation
+		if s.validate(path, v, &ignored); len(ignored) == 0 {
+			c++
+		}
+	}
+	return c
+}
+
+// match checks to see if the value is one of the allowed types.
+func (t types) match(v interface{}) bool {
+	actual := typeOf(v)
+	for _, allowed := range t {
+		if allowed == actual || (allowed == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// typeOf returns the JSON type of a decoded value.
+func typeOf(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if r, ok := new(big.Rat).SetString(v.String()); ok && r.IsInt() {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return reflect.TypeOf(v).String()
+	}
+}
+
+// compare compares two JSON numbers exactly.
+func compare(a, b json.Number) int {
+	ra, ok1 := new(big.Rat).SetString(a.String())
+	rb, ok2 := new(big.Rat).SetString(b.String())
+	if !ok1 || !ok2 {
+		return 0
+	}
+	return ra.Cmp(rb)
+}
+
+// equal compares two decoded JSON values, treating numbers with the same value as equal.
+func equal(a, b interface{}) bool {
+	if na, ok := number(a); ok {
+		nb, ok := number(b)
+		return ok && compare(na, nb) == 0
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// number returns the decoded value as a JSON number.
+func number(v interface{}) (json.Number, bool) {
+	switch v := v.(type) {
+	case json.Number:
+		return v, true
+	case float64:
+		return json.Number(strconv.FormatFloat(v, 'g', -1, 64)), true
+	default:
+		return "", false
+	}
+}
+
+// encode returns the JSON representation of a decoded value for use in messages.
+func encode(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
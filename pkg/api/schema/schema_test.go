@@ -0,0 +1,173 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schema
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thestormforge/optimize-go/pkg/api"
+	"github.com/thestormforge/optimize-go/pkg/api/experiments"
+)
+
+const testSchema = `{
+	"$defs": {
+		"Experiment": {
+			"type": "object",
+			"required": ["metrics", "parameters"],
+			"properties": {
+				"displayName": {"type": "string", "minLength": 1},
+				"budget": {"type": "integer", "minimum": 1},
+				"metrics": {"type": "array", "items": {"$ref": "#/$defs/Metric"}},
+				"parameters": {"type": "array"}
+			}
+		},
+		"Metric": {
+			"type": "object",
+			"required": ["name"],
+			"additionalProperties": false,
+			"properties": {
+				"name": {"type": "string", "pattern": "^[a-z-]+$"},
+				"minimize": {"type": "boolean"},
+				"optimize": {"type": ["boolean", "null"]}
+			}
+		}
+	}
+}`
+
+func TestSchema_Validate(t *testing.T) {
+	s := MustCompile([]byte(testSchema))
+
+	cases := []struct {
+		desc       string
+		doc        string
+		violations []string
+	}{
+		{
+			desc: "valid",
+			doc:  `{"displayName":"my exp","budget":10,"metrics":[{"name":"cost","minimize":true}],"parameters":[]}`,
+		},
+		{
+			desc:       "missing required",
+			doc:        `{"metrics":[]}`,
+			violations: []string{`/: missing required property "parameters"`},
+		},
+		{
+			desc: "mismatches",
+			doc:  `{"displayName":"","budget":1.5,"metrics":[{"name":"Cost","extra":1},{"minimize":"yes"}],"parameters":{}}`,
+			violations: []string{
+				`/budget: expected integer, got number`,
+				`/displayName: length 0 is less than 1`,
+				`/metrics/0/extra: unexpected property`,
+				`/metrics/0/name: value "Cost" does not match "^[a-z-]+$"`,
+				`/metrics/1: missing required property "name"`,
+				`/metrics/1/minimize: expected boolean, got string`,
+				`/parameters: expected array, got object`,
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			err := s.ValidateDefinition("Experiment", []byte(c.doc))
+			if len(c.violations) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			var verr *ViolationError
+			require.True(t, errors.As(err, &verr))
+			assert.True(t, errors.Is(err, ErrSchemaViolation))
+			var actual []string
+			for _, v := range verr.Violations {
+				actual = append(actual, v.String())
+			}
+			assert.Equal(t, c.violations, actual)
+		})
+	}
+}
+
+func TestSchema_ValidateDefinition_Unknown(t *testing.T) {
+	s := MustCompile([]byte(testSchema))
+	err := s.ValidateDefinition("Trial", []byte(`{}`))
+	assert.True(t, errors.Is(err, ErrUnknownDefinition))
+	assert.EqualError(t, err, `unknown schema definition "Trial"`)
+}
+
+func TestCompile(t *testing.T) {
+	_, err := Compile([]byte(`{"items":{"$ref":"#/$defs/Missing"}}`))
+	assert.EqualError(t, err, `invalid schema: unknown reference "#/$defs/Missing"`)
+
+	_, err = Compile([]byte(`{"$ref":"https://example.com/schema.json"}`))
+	assert.EqualError(t, err, `invalid schema: unsupported reference "https://example.com/schema.json"`)
+}
+
+func TestWithStrictSchemaValidation(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/experiments/valid":
+			_, _ = w.Write([]byte(`{"metrics":[{"name":"cost"}],"parameters":[]}`))
+		case "/experiments/drifted":
+			_, _ = w.Write([]byte(`{"metrics":[{"metricName":"cost"}],"parameters":[]}`))
+		}
+	}))
+	defer ts.Close()
+
+	endpoints, err := api.Endpoints(ts.URL, nil)
+	require.NoError(t, err)
+	strict := WithStrictSchemaValidation(MustCompile([]byte(testSchema)))
+
+	// Validation wraps the configured codec regardless of the order of the options
+	for desc, opts := range map[string][]api.Option{
+		"default codec": {strict},
+		"codec before":  {api.WithCodec(&countingCodec{}), strict},
+		"codec after":   {strict, api.WithCodec(&countingCodec{})},
+	} {
+		t.Run(desc, func(t *testing.T) {
+			client, err := api.NewClient(context.Background(), api.StaticTokenConfig("", endpoints), opts...)
+			require.NoError(t, err)
+			a := experiments.NewAPI(client)
+
+			_, err = a.GetExperiment(context.Background(), "valid")
+			assert.NoError(t, err)
+
+			_, err = a.GetExperiment(context.Background(), "drifted")
+			assert.True(t, errors.Is(err, ErrSchemaViolation))
+			assert.EqualError(t, err, `schema violation: /metrics/0: missing required property "name"; /metrics/0/metricName: unexpected property`)
+
+			if cc, ok := api.ClientCodec(client).(*Codec).Codec.(*countingCodec); ok {
+				assert.Equal(t, 1, cc.unmarshals, "configured codec was not used")
+			}
+		})
+	}
+}
+
+// countingCodec records the number of values decoded.
+type countingCodec struct {
+	api.JSONCodec
+	unmarshals int
+}
+
+func (c *countingCodec) Unmarshal(data []byte, v any) error {
+	c.unmarshals++
+	return c.JSONCodec.Unmarshal(data, v)
+}
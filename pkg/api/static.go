@@ -0,0 +1,65 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// StaticTokenConfig returns a configuration that authorizes every request using a long-lived bearer token. Endpoints
+// are resolved by appending the remainder of the endpoint to the URL of the longest matching prefix in the supplied
+// map (for example, "/experiments/" mapped to "https://api.example.com/v1/experiments/").
+func StaticTokenConfig(token string, endpoints map[string]*url.URL) Config {
+	return &staticTokenConfig{token: token, endpoints: endpoints}
+}
+
+type staticTokenConfig struct {
+	token     string
+	endpoints map[string]*url.URL
+}
+
+// Endpoints returns a resolver for the configured endpoint prefixes.
+func (c *staticTokenConfig) Endpoints() (func(string) *url.URL, error) {
+	return prefixResolver(c.endpoints), nil
+}
+
+// Authorize returns a transport that adds the bearer token to each request.
+func (c *staticTokenConfig) Authorize(_ context.Context, transport http.RoundTripper) (http.RoundTripper, error) {
+	if c.token == "" {
+		return transport, nil
+	}
+	return &bearerTransport{authorization: "Bearer " + c.token, base: transport}, nil
+}
+
+// bearerTransport adds a fixed Authorization header to each request.
+type bearerTransport struct {
+	authorization string
+	base          http.RoundTripper
+}
+
+// RoundTrip sets the Authorization header on a copy of the request.
+func (t *bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if isCrossOriginRedirect(req) {
+		return transport(t.base).RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", t.authorization)
+	return transport(t.base).RoundTrip(req)
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaticTokenConfig(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.Path + " " + r.Header.Get("Authorization")))
+	}))
+	defer ts.Close()
+
+	base, err := url.Parse(ts.URL + "/v1/")
+	require.NoError(t, err)
+	experiments, err := url.Parse(ts.URL + "/v2/experiments/")
+	require.NoError(t, err)
+
+	cfg := StaticTokenConfig("secret", map[string]*url.URL{
+		"/":             base,
+		"/experiments/": experiments,
+	})
+	client, err := NewClient(context.Background(), cfg)
+	require.NoError(t, err)
+
+	assert.Equal(t, ts.URL+"/v2/experiments/foo", client.URL("/experiments/foo").String())
+	assert.Equal(t, ts.URL+"/v1/accounts/", client.URL("/accounts/").String())
+
+	req, err := http.NewRequest(http.MethodGet, client.URL("/experiments/").String(), nil)
+	require.NoError(t, err)
+	_, body, err := client.Do(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "/v2/experiments/ Bearer secret", string(body))
+}
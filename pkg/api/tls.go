@@ -0,0 +1,89 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrCertificatePinMismatch is returned when the server certificate does not match any of the pinned fingerprints.
+var ErrCertificatePinMismatch = errors.New("server certificate does not match any pinned fingerprint")
+
+// WithClientCertificate configures the default transport to present the supplied certificate for mutual TLS
+// authentication. This option is ignored if the client is created with an explicit transport.
+func WithClientCertificate(cert tls.Certificate) Option {
+	return withTLSConfig(func(cfg *tls.Config) {
+		cfg.Certificates = append(cfg.Certificates, cert)
+	})
+}
+
+// WithRootCAs configures the default transport to verify server certificates using the supplied pool instead of the
+// system roots. This option is ignored if the client is created with an explicit transport.
+func WithRootCAs(pool *x509.CertPool) Option {
+	return withTLSConfig(func(cfg *tls.Config) {
+		cfg.RootCAs = pool
+	})
+}
+
+// WithMinTLSVersion configures the default transport to refuse connections using a TLS version lower than the
+// supplied version (e.g. `tls.VersionTLS13`). This option is ignored if the client is created with an explicit
+// transport.
+func WithMinTLSVersion(v uint16) Option {
+	return withTLSConfig(func(cfg *tls.Config) {
+		cfg.MinVersion = v
+	})
+}
+
+// WithPinnedCertificates configures the default transport to only accept servers whose leaf certificate has one of
+// the supplied SHA-256 fingerprints. Pinning is an additional check, the certificate chain is still verified as usual
+// and the pin is checked on every connection, including resumed TLS sessions. Connections to a server with an
+// unpinned certificate fail with an error matching `ErrCertificatePinMismatch`. This option is ignored if the client
+// is created with an explicit transport.
+func WithPinnedCertificates(fingerprints [][]byte) Option {
+	return withTLSConfig(func(cfg *tls.Config) {
+		cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return ErrCertificatePinMismatch
+			}
+			sum := sha256.Sum256(cs.PeerCertificates[0].Raw)
+			for _, fp := range fingerprints {
+				if bytes.Equal(fp, sum[:]) {
+					return nil
+				}
+			}
+			return fmt.Errorf("%w (sha256:%x)", ErrCertificatePinMismatch, sum)
+		}
+	})
+}
+
+// withTLSConfig returns an option that modifies the TLS configuration of the default transport.
+func withTLSConfig(f func(*tls.Config)) Option {
+	return func(c *httpClient) {
+		c.transportOptions = append(c.transportOptions, func(t *http.Transport) {
+			if t.TLSClientConfig == nil {
+				t.TLSClientConfig = &tls.Config{}
+			}
+			f(t.TLSClientConfig)
+		})
+	}
+}
@@ -0,0 +1,201 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestCertificate returns a new self-signed client certificate.
+func newTestCertificate(t *testing.T) (tls.Certificate, *x509.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	leaf, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, leaf
+}
+
+func TestWithClientCertificate(t *testing.T) {
+	cert, leaf := newTestCertificate(t)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(leaf)
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	ts.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	ts.Config.ErrorLog = log.New(io.Discard, "", 0)
+	ts.StartTLS()
+	defer ts.Close()
+
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(ts.Certificate())
+
+	t.Run("certificate", func(t *testing.T) {
+		client := newTestClient(t, ts, WithRootCAs(rootCAs), WithClientCertificate(cert))
+		req, err := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+		require.NoError(t, err)
+
+		_, body, err := client.Do(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, "test client", string(body))
+	})
+
+	t.Run("no certificate", func(t *testing.T) {
+		client := newTestClient(t, ts, WithRootCAs(rootCAs))
+		req, err := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+		require.NoError(t, err)
+
+		_, _, err = client.Do(context.Background(), req)
+		var uerr *url.Error
+		assert.True(t, errors.As(err, &uerr))
+	})
+
+	t.Run("untrusted server", func(t *testing.T) {
+		client := newTestClient(t, ts, WithClientCertificate(cert))
+		req, err := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+		require.NoError(t, err)
+
+		_, _, err = client.Do(context.Background(), req)
+		var verr *tls.CertificateVerificationError
+		assert.True(t, errors.As(err, &verr))
+	})
+}
+
+func TestWithPinnedCertificates(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.TLS.ServerName))
+	}))
+	ts.Config.ErrorLog = log.New(io.Discard, "", 0)
+	defer ts.Close()
+
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(ts.Certificate())
+
+	pin := sha256.Sum256(ts.Certificate().Raw)
+	other := sha256.Sum256([]byte("other"))
+
+	cases := []struct {
+		desc     string
+		pins     [][]byte
+		rootCAs  *x509.CertPool
+		expected error
+	}{
+		{desc: "pinned", pins: [][]byte{other[:], pin[:]}, rootCAs: rootCAs},
+		{desc: "mismatch", pins: [][]byte{other[:]}, rootCAs: rootCAs, expected: ErrCertificatePinMismatch},
+		{desc: "untrusted", pins: [][]byte{pin[:]}},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			client := newTestClient(t, ts, WithRootCAs(c.rootCAs), WithPinnedCertificates(c.pins), WithMinTLSVersion(tls.VersionTLS13))
+			req, err := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+			require.NoError(t, err)
+
+			resp, _, err := client.Do(context.Background(), req)
+			switch {
+			case c.expected != nil:
+				assert.True(t, errors.Is(err, c.expected))
+			case c.rootCAs == nil:
+				var verr *tls.CertificateVerificationError
+				assert.True(t, errors.As(err, &verr))
+			default:
+				if assert.NoError(t, err) {
+					assert.Equal(t, uint16(tls.VersionTLS13), resp.TLS.Version)
+				}
+			}
+		})
+	}
+}
+
+func TestWithPinnedCertificates_Resumed(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.TLS.ServerName))
+	}))
+	ts.Config.ErrorLog = log.New(io.Discard, "", 0)
+	defer ts.Close()
+
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(ts.Certificate())
+	sessions := tls.NewLRUClientSessionCache(1)
+
+	pin := sha256.Sum256(ts.Certificate().Raw)
+	other := sha256.Sum256([]byte("other"))
+
+	// Both transports share a session cache so the second handshake resumes the first session
+	get := func(pins [][]byte) (*http.Response, error) {
+		c := &httpClient{}
+		WithPinnedCertificates(pins)(c)
+		transport := &http.Transport{DisableKeepAlives: true}
+		for _, opt := range c.transportOptions {
+			opt(transport)
+		}
+		transport.TLSClientConfig.RootCAs = rootCAs
+		transport.TLSClientConfig.ClientSessionCache = sessions
+		defer transport.CloseIdleConnections()
+
+		resp, err := (&http.Client{Transport: transport}).Get(ts.URL)
+		if err != nil {
+			return nil, err
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return resp, resp.Body.Close()
+	}
+
+	resp, err := get([][]byte{pin[:]})
+	require.NoError(t, err)
+	require.False(t, resp.TLS.DidResume)
+
+	resp, err = get([][]byte{pin[:]})
+	require.NoError(t, err)
+	require.True(t, resp.TLS.DidResume)
+
+	_, err = get([][]byte{other[:]})
+	assert.True(t, errors.Is(err, ErrCertificatePinMismatch))
+}
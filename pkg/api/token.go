@@ -0,0 +1,156 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// DefaultTokenRefreshSkew is the amount of time before expiration that access tokens are refreshed.
+const DefaultTokenRefreshSkew = 30 * time.Second
+
+// TokenSourceConfig is implemented by configurations that authorize requests using OAuth2 access tokens. When a
+// configuration supplies a token source, the client caches tokens and refreshes them before they expire instead of
+// using the transport returned by `Authorize`.
+type TokenSourceConfig interface {
+	Config
+	// TokenSource returns a source of access tokens. The client only asks for a token when it does not have one or
+	// the one it has is about to expire, so the source should obtain a new token on each call whenever possible. A
+	// nil source indicates access tokens are not used, in which case the transport returned by `Authorize` is used.
+	TokenSource(ctx context.Context) (oauth2.TokenSource, error)
+}
+
+// WithTokenRefreshSkew sets how long before expiration cached access tokens are refreshed. This option only applies
+// when the client configuration implements `TokenSourceConfig`.
+func WithTokenRefreshSkew(d time.Duration) Option {
+	return func(c *httpClient) {
+		c.tokenRefreshSkew = d
+	}
+}
+
+// TokenError is returned when an access token could not be obtained for a request.
+type TokenError struct {
+	// Err is the reason the token could not be obtained.
+	Err error
+}
+
+// Error returns the error message.
+func (e *TokenError) Error() string {
+	return "unable to obtain access token: " + e.Err.Error()
+}
+
+// Unwrap returns the reason the token could not be obtained.
+func (e *TokenError) Unwrap() error {
+	return e.Err
+}
+
+// Is allows token errors to match `ErrUnauthorized`.
+func (e *TokenError) Is(target error) bool {
+	return target == ErrUnauthorized
+}
+
+// tokenCache holds a token and refreshes it when it is about to expire.
+type tokenCache struct {
+	src   oauth2.TokenSource
+	skew  time.Duration
+	clock Clock
+
+	mu    sync.Mutex
+	token *oauth2.Token
+}
+
+// Token returns the cached token, refreshing it first if necessary.
+func (c *tokenCache) Token() (*oauth2.Token, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != nil && c.token.AccessToken != "" &&
+		(c.token.Expiry.IsZero() || clockOf(c.clock).Now().Add(c.skew).Before(c.token.Expiry)) {
+		return c.token, nil
+	}
+
+	t, err := c.src.Token()
+	if err != nil {
+		return nil, &TokenError{Err: err}
+	}
+	c.token = t
+	return t, nil
+}
+
+// invalidate discards the supplied token if it is still cached, forcing the next call to obtain a new token.
+func (c *tokenCache) invalidate(t *oauth2.Token) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token == t {
+		c.token = nil
+	}
+}
+
+// tokenTransport authorizes requests using a cached token. If the server rejects the token, it is discarded and the
+// request is retried exactly once with a new token.
+type tokenTransport struct {
+	tokens *tokenCache
+	base   http.RoundTripper
+}
+
+// RoundTrip adds the authorization header to the request.
+func (t *tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if isCrossOriginRedirect(req) {
+		return transport(t.base).RoundTrip(req)
+	}
+
+	token, err := t.tokens.Token()
+	if err != nil {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, err
+	}
+
+	req = req.Clone(req.Context())
+	if err := rewindable(req); err != nil {
+		return nil, err
+	}
+	token.SetAuthHeader(req)
+	resp, err := transport(t.base).RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	// Force a refresh and try again, the original response is returned if we cannot get a new token
+	t.tokens.invalidate(token)
+	retryToken, err := t.tokens.Token()
+	if err != nil || retryToken.AccessToken == token.AccessToken {
+		return resp, nil
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return resp, nil
+		}
+		req.Body = body
+	}
+	discard(resp)
+
+	retryToken.SetAuthHeader(req)
+	return transport(t.base).RoundTrip(req)
+}
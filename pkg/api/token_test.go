@@ -0,0 +1,194 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+// tokenConfig is a test configuration that authorizes requests using tokens from a function.
+type tokenConfig struct {
+	testConfig
+	token func() (*oauth2.Token, error)
+}
+
+func (tc *tokenConfig) TokenSource(context.Context) (oauth2.TokenSource, error) {
+	return tc, nil
+}
+
+func (tc *tokenConfig) Token() (*oauth2.Token, error) {
+	return tc.token()
+}
+
+// noTokenConfig is a test configuration without access tokens.
+type noTokenConfig struct {
+	testConfig
+}
+
+func (nc *noTokenConfig) TokenSource(context.Context) (oauth2.TokenSource, error) {
+	return nil, nil
+}
+
+func TestNewClient_NoTokenSource(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer ts.Close()
+
+	cfg := &noTokenConfig{testConfig: testConfig{base: ts.URL}}
+	client, err := NewClient(context.Background(), cfg)
+	require.NoError(t, err)
+	assert.NotNil(t, cfg.transport, "configuration was not asked to authorize the transport")
+
+	req, err := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+	require.NoError(t, err)
+	_, body, err := client.Do(context.Background(), req)
+	require.NoError(t, err)
+	assert.Empty(t, string(body))
+}
+
+func TestWithTokenRefreshSkew(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer ts.Close()
+
+	var issued int
+	cfg := &tokenConfig{
+		testConfig: testConfig{base: ts.URL},
+		token: func() (*oauth2.Token, error) {
+			issued++
+			return &oauth2.Token{AccessToken: strconv.Itoa(issued), Expiry: time.Now().Add(time.Minute)}, nil
+		},
+	}
+
+	get := func(client Client) string {
+		req, err := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+		require.NoError(t, err)
+		_, body, err := client.Do(context.Background(), req)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	client, err := NewClient(context.Background(), cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer 1", get(client))
+	assert.Equal(t, "Bearer 1", get(client), "token was not cached")
+
+	issued = 0
+	client, err = NewClient(context.Background(), cfg, WithTokenRefreshSkew(2*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer 1", get(client))
+	assert.Equal(t, "Bearer 2", get(client), "token was not refreshed before expiration")
+}
+
+func TestTokenError(t *testing.T) {
+	var called bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer ts.Close()
+
+	refreshErr := errors.New("invalid_grant")
+	cfg := &tokenConfig{
+		testConfig: testConfig{base: ts.URL},
+		token:      func() (*oauth2.Token, error) { return nil, refreshErr },
+	}
+	client, err := NewClient(context.Background(), cfg)
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+	require.NoError(t, err)
+	_, _, err = client.Do(context.Background(), req)
+
+	var tokenErr *TokenError
+	assert.True(t, errors.As(err, &tokenErr))
+	assert.True(t, errors.Is(err, ErrUnauthorized))
+	assert.True(t, errors.Is(err, refreshErr))
+	assert.False(t, called, "request was sent without a token")
+}
+
+func TestTokenTransport_Unauthorized(t *testing.T) {
+	cases := []struct {
+		desc             string
+		rejectAll        bool
+		expectedStatus   int
+		expectedAttempts int
+	}{
+		{
+			desc:             "refreshed",
+			expectedStatus:   http.StatusOK,
+			expectedAttempts: 2,
+		},
+		{
+			desc:             "retried once",
+			rejectAll:        true,
+			expectedStatus:   http.StatusUnauthorized,
+			expectedAttempts: 2,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			var attempts int
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				if c.rejectAll || r.Header.Get("Authorization") == "Bearer 1" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				body, _ := ioutil.ReadAll(r.Body)
+				_, _ = w.Write(body)
+			}))
+			defer ts.Close()
+
+			var issued int
+			cfg := &tokenConfig{
+				testConfig: testConfig{base: ts.URL},
+				token: func() (*oauth2.Token, error) {
+					issued++
+					return &oauth2.Token{AccessToken: strconv.Itoa(issued)}, nil
+				},
+			}
+			client, err := NewClient(context.Background(), cfg)
+			require.NoError(t, err)
+
+			// Hide the body type so the request cannot be rewound without buffering
+			req, err := http.NewRequest(http.MethodPost, client.URL("/").String(), ioutil.NopCloser(strings.NewReader("payload")))
+			require.NoError(t, err)
+
+			resp, body, err := client.Do(context.Background(), req)
+			require.NoError(t, err)
+			assert.Equal(t, c.expectedStatus, resp.StatusCode)
+			assert.Equal(t, c.expectedAttempts, attempts)
+			if resp.StatusCode == http.StatusOK {
+				assert.Equal(t, "payload", string(body))
+			}
+		})
+	}
+}
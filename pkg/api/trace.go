@@ -0,0 +1,152 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ConnectionTimings describes the connection level phases of a request.
+type ConnectionTimings struct {
+	// DNS is the time spent resolving the host name.
+	DNS time.Duration
+	// Connect is the time spent establishing the network connection.
+	Connect time.Duration
+	// TLSHandshake is the time spent performing the TLS handshake.
+	TLSHandshake time.Duration
+	// FirstByte is the time from requesting a connection until the first byte of the response was received.
+	FirstByte time.Duration
+	// Reused indicates an existing connection was used, in which case there are no DNS, connect or TLS timings.
+	Reused bool
+}
+
+// WithClientTrace attaches the trace returned by the supplied function to each request, see `net/http/httptrace`.
+// The trace is composed with any other trace (including a trace already present on the context of the request and
+// the trace used for `WithConnectionTimings`), so every trace receives every event. Tracing adds overhead to every
+// request and is intended for diagnostics.
+func WithClientTrace(trace func(ctx context.Context) *httptrace.ClientTrace) Option {
+	return func(c *httpClient) {
+		c.clientTrace = trace
+	}
+}
+
+// WithConnectionTimings records the DNS, connect and TLS timings of each request in the `Connection` field of the
+// information passed to the logger (see `WithLogger`). The timings are obtained using `net/http/httptrace`, which adds
+// overhead to every request.
+func WithConnectionTimings() Option {
+	return func(c *httpClient) {
+		c.connectionTimings = true
+	}
+}
+
+// NewConnectionTrace returns a trace that reports the connection timings of a request once the first byte of the
+// response is received. When a request is retried, the timings of each attempt are reported.
+func NewConnectionTrace(report func(ConnectionTimings)) *httptrace.ClientTrace {
+	var mu sync.Mutex
+	var start, dnsStart, connectStart, tlsStart time.Time
+	var t ConnectionTimings
+
+	since := func(s time.Time) time.Duration {
+		if s.IsZero() {
+			return 0
+		}
+		return time.Since(s)
+	}
+
+	return &httptrace.ClientTrace{
+		GetConn: func(string) {
+			mu.Lock()
+			defer mu.Unlock()
+			start, t = time.Now(), ConnectionTimings{}
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			mu.Lock()
+			defer mu.Unlock()
+			t.Reused = info.Reused
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			mu.Lock()
+			defer mu.Unlock()
+			dnsStart = time.Now()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			mu.Lock()
+			defer mu.Unlock()
+			t.DNS = since(dnsStart)
+		},
+		ConnectStart: func(string, string) {
+			mu.Lock()
+			defer mu.Unlock()
+			// Multiple addresses may be tried in parallel, measure from the first attempt
+			if connectStart.IsZero() {
+				connectStart = time.Now()
+			}
+		},
+		ConnectDone: func(_, _ string, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				t.Connect = since(connectStart)
+			}
+		},
+		TLSHandshakeStart: func() {
+			mu.Lock()
+			defer mu.Unlock()
+			tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			t.TLSHandshake = since(tlsStart)
+		},
+		GotFirstResponseByte: func() {
+			mu.Lock()
+			t.FirstByte = since(start)
+			result := t
+			start, dnsStart, connectStart, tlsStart = time.Time{}, time.Time{}, time.Time{}, time.Time{}
+			mu.Unlock()
+			report(result)
+		},
+	}
+}
+
+// withTrace adds the configured traces to the context. The returned function returns the most recently reported
+// connection timings, if they are being recorded.
+func (c *httpClient) withTrace(ctx context.Context) (context.Context, func() ConnectionTimings) {
+	if c.clientTrace != nil {
+		if t := c.clientTrace(ctx); t != nil {
+			ctx = httptrace.WithClientTrace(ctx, t)
+		}
+	}
+	if !c.connectionTimings {
+		return ctx, func() ConnectionTimings { return ConnectionTimings{} }
+	}
+
+	var last atomic.Pointer[ConnectionTimings]
+	ctx = httptrace.WithClientTrace(ctx, NewConnectionTrace(func(t ConnectionTimings) { last.Store(&t) }))
+	return ctx, func() ConnectionTimings {
+		if t := last.Load(); t != nil {
+			return *t
+		}
+		return ConnectionTimings{}
+	}
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithClientTrace(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	var optionConns, contextConns int32
+	var mu sync.Mutex
+	var infos []RequestInfo
+	client := newTestClient(t, ts,
+		WithTransport(ts.Client().Transport),
+		WithClientTrace(func(context.Context) *httptrace.ClientTrace {
+			return &httptrace.ClientTrace{GotConn: func(httptrace.GotConnInfo) { atomic.AddInt32(&optionConns, 1) }}
+		}),
+		WithConnectionTimings(),
+		WithLogger(func(_ context.Context, info RequestInfo) {
+			mu.Lock()
+			defer mu.Unlock()
+			infos = append(infos, info)
+		}))
+
+	// A trace on the context is composed with the configured traces
+	ctx := httptrace.WithClientTrace(context.Background(), &httptrace.ClientTrace{
+		GotConn: func(httptrace.GotConnInfo) { atomic.AddInt32(&contextConns, 1) },
+	})
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+		require.NoError(t, err)
+		_, _, err = client.Do(ctx, req)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&optionConns))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&contextConns))
+
+	mu.Lock()
+	defer mu.Unlock()
+	if assert.Len(t, infos, 2) {
+		assert.False(t, infos[0].Connection.Reused)
+		assert.Greater(t, int64(infos[0].Connection.Connect), int64(0))
+		assert.Greater(t, int64(infos[0].Connection.TLSHandshake), int64(0))
+		assert.Greater(t, int64(infos[0].Connection.FirstByte), int64(0))
+
+		assert.True(t, infos[1].Connection.Reused)
+		assert.Zero(t, infos[1].Connection.TLSHandshake)
+	}
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// DefaultMaxIdleConnsPerHost is the number of idle connections kept for the API server by the default transport.
+const DefaultMaxIdleConnsPerHost = 32
+
+// TransportOptions are used to tune the connection pool of the transport created when the client is not supplied with
+// an explicit transport. Zero values leave the corresponding setting unchanged.
+type TransportOptions struct {
+	// MaxIdleConns is the maximum number of idle connections across all hosts.
+	MaxIdleConns int
+	// MaxIdleConnsPerHost is the maximum number of idle connections to keep per host.
+	MaxIdleConnsPerHost int
+	// IdleConnTimeout is the maximum amount of time an idle connection will remain idle before closing itself.
+	IdleConnTimeout time.Duration
+	// DisableKeepAlives prevents reuse of connections between requests.
+	DisableKeepAlives bool
+}
+
+// WithTransportOptions tunes the connection pool settings of the default transport. These options are ignored if the
+// client is created with an explicit transport (see `WithTransport`).
+func WithTransportOptions(opts TransportOptions) Option {
+	return func(c *httpClient) {
+		c.transportOptions = append(c.transportOptions, func(t *http.Transport) {
+			if opts.MaxIdleConns > 0 {
+				t.MaxIdleConns = opts.MaxIdleConns
+			}
+			if opts.MaxIdleConnsPerHost > 0 {
+				t.MaxIdleConnsPerHost = opts.MaxIdleConnsPerHost
+			}
+			if opts.IdleConnTimeout > 0 {
+				t.IdleConnTimeout = opts.IdleConnTimeout
+			}
+			if opts.DisableKeepAlives {
+				t.DisableKeepAlives = true
+			}
+		})
+	}
+}
+
+// WithProxy sets the function used by the default transport to select a proxy for each request. When no proxy option
+// is supplied, the proxy is determined by the environment (see `http.ProxyFromEnvironment`). This option is ignored if
+// the client is created with an explicit transport.
+func WithProxy(proxy func(*http.Request) (*url.URL, error)) Option {
+	return func(c *httpClient) {
+		c.transportOptions = append(c.transportOptions, func(t *http.Transport) {
+			t.Proxy = proxy
+		})
+	}
+}
+
+// WithNoProxy prevents the default transport from using a proxy, including any proxy configured in the environment.
+func WithNoProxy() Option {
+	return WithProxy(nil)
+}
+
+// WithDialContext sets the function used by the default transport to establish connections, for example to resolve
+// the API host to a fixed address. This option is ignored if the client is created with an explicit transport.
+func WithDialContext(dial func(ctx context.Context, network, addr string) (net.Conn, error)) Option {
+	return func(c *httpClient) {
+		c.transportOptions = append(c.transportOptions, func(t *http.Transport) {
+			t.DialContext = dial
+		})
+	}
+}
+
+// WithDialTimeout limits the amount of time the default transport waits for a connection to be established,
+// independent of the overall request timeout. Keep-alives are enabled using the same interval as the default
+// transport.
+func WithDialTimeout(d time.Duration) Option {
+	return WithDialContext((&net.Dialer{Timeout: d, KeepAlive: 30 * time.Second}).DialContext)
+}
+
+// WithForceHTTP1 prevents the default transport from negotiating HTTP/2, all requests use HTTP/1.1. By default, the
+// client behaves the same as the standard library and uses HTTP/2 when the server supports it. This option is ignored
+// (with a warning) if the client is created with an explicit transport, the supplied transport is never modified.
+func WithForceHTTP1() Option {
+	return withHTTPVersion(func(t *http.Transport) {
+		t.ForceAttemptHTTP2 = false
+		t.TLSNextProto = make(map[string]func(authority string, c *tls.Conn) http.RoundTripper)
+		if t.TLSClientConfig != nil {
+			// Stop advertising HTTP/2 using ALPN if the transport was already configured for it
+			t.TLSClientConfig = t.TLSClientConfig.Clone()
+			var protos []string
+			for _, p := range t.TLSClientConfig.NextProtos {
+				if p != "h2" {
+					protos = append(protos, p)
+				}
+			}
+			t.TLSClientConfig.NextProtos = protos
+		}
+	})
+}
+
+// WithHTTP2 allows the default transport to negotiate HTTP/2, reversing `WithForceHTTP1`. This option is ignored (with
+// a warning) if the client is created with an explicit transport.
+func WithHTTP2() Option {
+	return withHTTPVersion(func(t *http.Transport) {
+		t.ForceAttemptHTTP2 = true
+		t.TLSNextProto = nil
+	})
+}
+
+// withHTTPVersion returns an option that modifies the HTTP protocol settings of the default transport.
+func withHTTPVersion(f func(*http.Transport)) Option {
+	return func(c *httpClient) {
+		c.httpVersionOption = true
+		c.transportOptions = append(c.transportOptions, f)
+	}
+}
+
+// newTransport returns a new transport, cloned from the default transport, with the client's transport options applied.
+func (c *httpClient) newTransport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.MaxIdleConnsPerHost = DefaultMaxIdleConnsPerHost
+	for _, opt := range c.transportOptions {
+		opt(t)
+	}
+	return t
+}
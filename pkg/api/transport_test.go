@@ -0,0 +1,196 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithTransportOptions(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		cfg := &testConfig{}
+		_, err := NewClient(context.Background(), cfg)
+		require.NoError(t, err)
+
+		if tr, ok := cfg.transport.(*http.Transport); assert.True(t, ok) {
+			assert.Equal(t, DefaultMaxIdleConnsPerHost, tr.MaxIdleConnsPerHost)
+			assert.NotSame(t, http.DefaultTransport, tr)
+		}
+	})
+
+	t.Run("tuned", func(t *testing.T) {
+		cfg := &testConfig{}
+		_, err := NewClient(context.Background(), cfg, WithTransportOptions(TransportOptions{
+			MaxIdleConns:        10,
+			MaxIdleConnsPerHost: 5,
+			IdleConnTimeout:     time.Second,
+			DisableKeepAlives:   true,
+		}))
+		require.NoError(t, err)
+
+		if tr, ok := cfg.transport.(*http.Transport); assert.True(t, ok) {
+			assert.Equal(t, 10, tr.MaxIdleConns)
+			assert.Equal(t, 5, tr.MaxIdleConnsPerHost)
+			assert.Equal(t, time.Second, tr.IdleConnTimeout)
+			assert.True(t, tr.DisableKeepAlives)
+		}
+	})
+
+	t.Run("explicit transport", func(t *testing.T) {
+		cfg := &testConfig{}
+		explicit := &http.Transport{}
+		_, err := NewClient(context.Background(), cfg, WithTransport(explicit), WithTransportOptions(TransportOptions{MaxIdleConnsPerHost: 5}))
+		require.NoError(t, err)
+
+		assert.Same(t, explicit, cfg.transport)
+		assert.Equal(t, 0, explicit.MaxIdleConnsPerHost)
+	})
+}
+
+func TestNewClientWithTransport(t *testing.T) {
+	cfg := &testConfig{}
+	explicit := &http.Transport{}
+	_, err := NewClientWithTransport(context.Background(), cfg, explicit)
+	require.NoError(t, err)
+
+	assert.Same(t, explicit, cfg.transport)
+}
+
+func TestWithProxy(t *testing.T) {
+	proxyURL, _ := url.Parse("http://proxy.example.com:3128")
+	req, _ := http.NewRequest(http.MethodGet, "http://api.example.com/", nil)
+
+	cases := []struct {
+		desc     string
+		opts     []Option
+		expected *url.URL
+		env      bool
+	}{
+		{desc: "environment", env: true},
+		{desc: "explicit", opts: []Option{WithProxy(http.ProxyURL(proxyURL))}, expected: proxyURL},
+		{desc: "disabled", opts: []Option{WithNoProxy()}},
+		{desc: "tuned", opts: []Option{WithProxy(http.ProxyURL(proxyURL)), WithTransportOptions(TransportOptions{MaxIdleConnsPerHost: 5})}, expected: proxyURL},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			cfg := &testConfig{}
+			_, err := NewClient(context.Background(), cfg, c.opts...)
+			require.NoError(t, err)
+
+			tr, ok := cfg.transport.(*http.Transport)
+			require.True(t, ok)
+			if c.env {
+				assert.NotNil(t, tr.Proxy)
+				return
+			}
+			if c.expected == nil {
+				assert.Nil(t, tr.Proxy)
+				return
+			}
+			if assert.NotNil(t, tr.Proxy) {
+				u, err := tr.Proxy(req)
+				assert.NoError(t, err)
+				assert.Equal(t, c.expected, u)
+			}
+		})
+	}
+}
+
+func TestWithDialContext(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Host))
+	}))
+	defer ts.Close()
+
+	var dialed []string
+	dialer := &net.Dialer{}
+	client, err := NewClient(context.Background(), &testConfig{base: "http://api.example.invalid"},
+		WithDialContext(func(ctx context.Context, network, addr string) (net.Conn, error) {
+			dialed = append(dialed, addr)
+			return dialer.DialContext(ctx, network, ts.Listener.Addr().String())
+		}),
+		WithNoProxy(),
+		WithTransportOptions(TransportOptions{MaxIdleConnsPerHost: 5}))
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+	require.NoError(t, err)
+
+	_, body, err := client.Do(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "api.example.invalid", string(body))
+	assert.Equal(t, []string{"api.example.invalid:80"}, dialed)
+}
+
+func TestWithDialTimeout(t *testing.T) {
+	cfg := &testConfig{}
+	_, err := NewClient(context.Background(), cfg, WithDialTimeout(time.Second), WithTransportOptions(TransportOptions{MaxIdleConnsPerHost: 5}))
+	require.NoError(t, err)
+
+	if tr, ok := cfg.transport.(*http.Transport); assert.True(t, ok) {
+		assert.NotNil(t, tr.DialContext)
+		assert.Equal(t, 5, tr.MaxIdleConnsPerHost)
+	}
+}
+
+func TestWithForceHTTP1(t *testing.T) {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	ts.EnableHTTP2 = true
+	ts.StartTLS()
+	defer ts.Close()
+
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(ts.Certificate())
+
+	cases := []struct {
+		desc     string
+		opts     []Option
+		expected int
+	}{
+		{desc: "default", expected: 2},
+		{desc: "force HTTP/1.1", opts: []Option{WithForceHTTP1()}, expected: 1},
+		{desc: "HTTP/2", opts: []Option{WithForceHTTP1(), WithHTTP2()}, expected: 2},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			client := newTestClient(t, ts, append([]Option{WithRootCAs(rootCAs)}, c.opts...)...)
+			req, err := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+			require.NoError(t, err)
+
+			resp, _, err := client.Do(context.Background(), req)
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, resp.ProtoMajor)
+		})
+	}
+
+	t.Run("explicit transport", func(t *testing.T) {
+		explicit := &http.Transport{}
+		_, err := NewClient(context.Background(), &testConfig{}, WithTransport(explicit), WithForceHTTP1())
+		require.NoError(t, err)
+		assert.Nil(t, explicit.TLSNextProto)
+	})
+}
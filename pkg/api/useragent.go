@@ -0,0 +1,48 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Version is the version of this library.
+const Version = "0.1.0"
+
+// WithUserAgent identifies the product making requests using the client. The resulting User-Agent includes both the
+// supplied product and the version of this library; requests that already specify a User-Agent are not modified.
+func WithUserAgent(product, version string) Option {
+	return func(c *httpClient) {
+		c.userAgent = fmt.Sprintf("%s/%s (optimize-go/%s)", product, version, Version)
+	}
+}
+
+// userAgentTransport sets the User-Agent header on outbound requests.
+type userAgentTransport struct {
+	userAgent string
+	base      http.RoundTripper
+}
+
+// RoundTrip adds the User-Agent header if it is not already present.
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", t.userAgent)
+	}
+	return transport(t.base).RoundTrip(req)
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithUserAgent(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.UserAgent()))
+	}))
+	defer ts.Close()
+
+	client := newTestClient(t, ts, WithUserAgent("redskyctl", "1.2.3"))
+
+	req, err := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+	require.NoError(t, err)
+	_, body, err := client.Do(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "redskyctl/1.2.3 (optimize-go/"+Version+")", string(body))
+	assert.Empty(t, req.Header.Get("User-Agent"))
+
+	req, err = http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+	require.NoError(t, err)
+	req.Header.Set("User-Agent", "custom/1.0")
+	_, body, err = client.Do(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "custom/1.0", string(body))
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// HeaderAPIVersion is the name of the response header the server may use to report the version of the representation.
+const HeaderAPIVersion = "API-Version"
+
+// WithAPIVersion requests a specific version of the API representation by adding a version parameter to the JSON
+// media types of the Accept header, e.g. "application/json; version=2". Requests without an Accept header are sent
+// with "Accept: application/json; version=2". If the server cannot produce the requested version it responds with a
+// "406 Not Acceptable", which matches `ErrUnsupportedAPIVersion`.
+func WithAPIVersion(v string) Option {
+	return func(c *httpClient) {
+		c.apiVersion = v
+	}
+}
+
+// APIVersion returns the version of the representation reported by the server, either using the version parameter
+// of the Content-Type or using the API-Version header.
+func APIVersion(header http.Header) string {
+	if _, params, err := mime.ParseMediaType(header.Get("Content-Type")); err == nil && params["version"] != "" {
+		return params["version"]
+	}
+	return header.Get(HeaderAPIVersion)
+}
+
+// setAcceptVersion adds the version parameter to the JSON media types of the Accept header.
+func setAcceptVersion(header http.Header, version string) {
+	accept := header.Get("Accept")
+	if accept == "" {
+		accept = "application/json"
+	}
+
+	ranges := strings.Split(accept, ",")
+	for i, r := range ranges {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(r))
+		if err != nil || mt != "application/json" || params["version"] != "" {
+			continue
+		}
+		params["version"] = version
+		ranges[i] = mime.FormatMediaType(mt, params)
+	}
+	header.Set("Accept", strings.Join(ranges, ","))
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithAPIVersion(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("Accept") {
+		case "application/json; version=2", "text/event-stream,application/json; version=2":
+			w.Header().Set("Content-Type", "application/json; version=2")
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			w.WriteHeader(http.StatusNotAcceptable)
+		}
+	}))
+	defer ts.Close()
+
+	cases := []struct {
+		desc     string
+		version  string
+		accept   string
+		expected error
+	}{
+		{desc: "default accept", version: "2"},
+		{desc: "json accept", version: "2", accept: "application/json"},
+		{desc: "multiple", version: "2", accept: "text/event-stream, application/json"},
+		{desc: "unsupported", version: "3", expected: ErrUnsupportedAPIVersion},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			client := newTestClient(t, ts, WithAPIVersion(c.version))
+			req, err := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+			require.NoError(t, err)
+			if c.accept != "" {
+				req.Header.Set("Accept", c.accept)
+			}
+
+			resp, body, err := client.Do(context.Background(), req)
+			require.NoError(t, err)
+			if c.expected != nil {
+				assert.True(t, errors.Is(NewError(resp, body), c.expected))
+				return
+			}
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, "2", APIVersion(resp.Header))
+		})
+	}
+}
+
+func TestAPIVersion(t *testing.T) {
+	assert.Equal(t, "2", APIVersion(http.Header{"Content-Type": {"application/json; version=2"}}))
+	h := http.Header{"Content-Type": {"application/json"}}
+	h.Set(HeaderAPIVersion, "2021-01")
+	assert.Equal(t, "2021-01", APIVersion(h))
+	assert.Empty(t, APIVersion(http.Header{}))
+}
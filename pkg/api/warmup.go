@@ -0,0 +1,123 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Warmup pre-populates the idle connection pool of a client created with `NewClient`, see `(*httpClient).Warmup`.
+// Clients which do not support warming up are left unchanged.
+func Warmup(ctx context.Context, c Client, n int) error {
+	if wc, ok := c.(interface {
+		Warmup(context.Context, int) error
+	}); ok {
+		return wc.Warmup(ctx, n)
+	}
+	return nil
+}
+
+// Warmup opens up to `n` connections to the API server and returns them to the idle connection pool, so the first
+// requests after a period of inactivity do not pay the cost of establishing a connection (e.g. the TLS handshake).
+// The number of connections is limited by the `MaxIdleConnsPerHost` (and `MaxConnsPerHost`) of the transport, only a
+// single connection is opened if keep-alives are disabled. Each connection is established using a HEAD request to the
+// health endpoint sent directly through the transport: the response itself is ignored, but the first connection
+// error is returned so callers can determine that the server is reachable.
+func (c *httpClient) Warmup(ctx context.Context, n int) error {
+	if c.closed.Load() {
+		return ErrClientClosed
+	}
+	u := c.URL(endpointHealth)
+	if u == nil {
+		return ErrNoHealthEndpoint
+	}
+
+	if t, ok := c.base.(*http.Transport); ok {
+		limit := t.MaxIdleConnsPerHost
+		if limit <= 0 {
+			limit = http.DefaultMaxIdleConnsPerHost
+		}
+		if t.MaxConnsPerHost > 0 && t.MaxConnsPerHost < limit {
+			limit = t.MaxConnsPerHost
+		}
+		if t.DisableKeepAlives {
+			limit = 1
+		}
+		if n > limit {
+			n = limit
+		}
+	}
+	if n <= 0 {
+		return nil
+	}
+
+	if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) > DefaultPingTimeout {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultPingTimeout)
+		defer cancel()
+	}
+
+	// Hold each connection until every request has one, otherwise connections would be reused between requests
+	pending := int32(n)
+	ready := make(chan struct{})
+	done := func() {
+		if atomic.AddInt32(&pending, -1) == 0 {
+			close(ready)
+		}
+	}
+
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		go func() {
+			var once sync.Once
+			trace := &httptrace.ClientTrace{
+				GotConn: func(httptrace.GotConnInfo) {
+					once.Do(done)
+					select {
+					case <-ready:
+					case <-ctx.Done():
+					}
+				},
+			}
+
+			req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), http.MethodHead, u.String(), nil)
+			if err == nil {
+				var resp *http.Response
+				if resp, err = c.base.RoundTrip(req); err == nil {
+					discard(resp)
+				} else {
+					err = newTransportError(req, err)
+				}
+			}
+			once.Do(done)
+			errs <- err
+		}()
+	}
+
+	var err error
+	for i := 0; i < n; i++ {
+		if e := <-errs; e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWarmup(t *testing.T) {
+	var conns, requests int32
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		assert.Equal(t, http.MethodHead, r.Method)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	ts.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	ts.Start()
+	defer ts.Close()
+
+	client := newTestClient(t, ts, WithTransportOptions(TransportOptions{MaxIdleConnsPerHost: 3}))
+
+	require.NoError(t, Warmup(context.Background(), client, 5))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&conns), "warmup is limited to the idle connections per host")
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+
+	// The idle connections are reused
+	require.NoError(t, Warmup(context.Background(), client, 3))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&conns))
+	assert.Equal(t, int32(6), atomic.LoadInt32(&requests))
+
+	// Connection errors are returned
+	ts.Close()
+	assert.Error(t, Warmup(context.Background(), newTestClient(t, ts), 2))
+}
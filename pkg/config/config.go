@@ -28,6 +28,7 @@ import (
 	"strings"
 
 	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/thestormforge/optimize-go/pkg/api"
 	"github.com/thestormforge/optimize-go/pkg/oauth2/authorizationcode"
 	"github.com/thestormforge/optimize-go/pkg/oauth2/devicecode"
 	"github.com/thestormforge/optimize-go/pkg/oauth2/registration"
@@ -37,7 +38,7 @@ import (
 )
 
 // audience is the logical identifier of the Red Sky API
-const audience = "https://api.carbonrelay.io/v1/"
+const audience = api.DefaultAudience
 
 // Loader is used to initially populate a Red Sky configuration
 type Loader func(cfg *RedSkyConfig) error
@@ -282,12 +283,12 @@ func (rsc *RedSkyConfig) RevocationInfo() (*RevocationInformation, error) {
 // RegisterClient performs dynamic client registration
 func (rsc *RedSkyConfig) RegisterClient(ctx context.Context, client *registration.ClientMetadata) (*registration.ClientInformationResponse, error) {
 	// We can't use the initial token because we don't know if we have a valid token, instead we need to authorize the context client
-	src, err := rsc.tokenSource(ctx)
+	src, err := rsc.TokenSource(ctx)
 	if err != nil {
 		return nil, err
 	}
 	if src != nil {
-		ctx = context.WithValue(ctx, oauth2.HTTPClient, oauth2.NewClient(ctx, src))
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, oauth2.NewClient(ctx, oauth2.ReuseTokenSource(nil, src)))
 	}
 
 	// Get the current server configuration for the registration endpoint address
@@ -342,18 +343,20 @@ func (rsc *RedSkyConfig) NewDeviceAuthorization() (*devicecode.Config, error) {
 // Authorize configures the supplied transport
 func (rsc *RedSkyConfig) Authorize(ctx context.Context, transport http.RoundTripper) (http.RoundTripper, error) {
 	// Get the token source and use it to wrap the transport
-	src, err := rsc.tokenSource(ctx)
+	src, err := rsc.TokenSource(ctx)
 	if err != nil {
 		return nil, err
 	}
 	if src != nil {
-		return &oauth2.Transport{Source: src, Base: transport}, nil
+		return &oauth2.Transport{Source: oauth2.ReuseTokenSource(nil, src), Base: transport}, nil
 	}
 	return transport, nil
 }
 
-func (rsc *RedSkyConfig) tokenSource(ctx context.Context) (oauth2.TokenSource, error) {
-	// TODO We could make RedSkyConfig implement the TokenSource interface, but we need a way to handle the context
+// TokenSource returns a source of new access tokens for the current authorization, the previously stored token is
+// returned for the first call if it is still valid. Callers are responsible for caching the tokens; a nil source is
+// returned if the current authorization does not use access tokens.
+func (rsc *RedSkyConfig) TokenSource(ctx context.Context) (oauth2.TokenSource, error) {
 	r := rsc.Reader()
 	srv, err := CurrentServer(r)
 	if err != nil {
@@ -369,14 +372,14 @@ func (rsc *RedSkyConfig) tokenSource(ctx context.Context) (oauth2.TokenSource, e
 	}
 
 	if az.Credential.ClientCredential != nil {
-		cc := clientcredentials.Config{
+		cc := &clientcredentials.Config{
 			ClientID:       az.Credential.ClientID,
 			ClientSecret:   az.Credential.ClientSecret,
 			TokenURL:       srv.Authorization.TokenEndpoint,
 			EndpointParams: url.Values{"audience": []string{audience}},
 			AuthStyle:      oauth2.AuthStyleInParams,
 		}
-		return cc.TokenSource(ctx), nil
+		return api.TokenSourceFunc(func() (*oauth2.Token, error) { return cc.Token(ctx) }), nil
 	}
 
 	if az.Credential.TokenCredential != nil {
@@ -395,7 +398,7 @@ func (rsc *RedSkyConfig) tokenSource(ctx context.Context) (oauth2.TokenSource, e
 			Expiry:       az.Credential.Expiry,
 		}
 		return &updateTokenSource{
-			src: c.TokenSource(ctx, t),
+			src: api.NewRefreshTokenSource(ctx, c, t),
 			cfg: rsc,
 			az:  azName,
 		}, nil
@@ -17,6 +17,8 @@ limitations under the License.
 package config
 
 import (
+	"context"
+	"net/http"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -82,3 +84,17 @@ func TestEndpoints_Resolve(t *testing.T) {
 		})
 	}
 }
+
+func TestRedSkyConfig_TokenSource_NoCredential(t *testing.T) {
+	cfg := &RedSkyConfig{}
+	require.NoError(t, defaultLoader(cfg))
+
+	src, err := cfg.TokenSource(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, src)
+
+	// Without access tokens the transport is used as-is
+	rt, err := cfg.Authorize(context.Background(), http.DefaultTransport)
+	require.NoError(t, err)
+	assert.Equal(t, http.DefaultTransport, rt)
+}
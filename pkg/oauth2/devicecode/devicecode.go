@@ -22,6 +22,7 @@ package devicecode
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -35,6 +36,16 @@ import (
 	"golang.org/x/oauth2/clientcredentials"
 )
 
+// defaultInterval is the polling interval used when the server does not specify one.
+const defaultInterval = 5 * time.Second
+
+var (
+	// ErrAccessDenied is returned when the end user denies the authorization request.
+	ErrAccessDenied = errors.New("device: access denied")
+	// ErrExpiredToken is returned when the device code expires before the end user completes the authorization.
+	ErrExpiredToken = errors.New("device: device code expired")
+)
+
 // UserInstruction is a function used to tell the end user how to complete the authorization.
 type UserInstruction func(userCode, verificationURI, verificationURIComplete string)
 
@@ -66,8 +77,8 @@ type errorResponse struct {
 // TODO Hide Client and TokenSource functions from the client credential configuration
 
 // Token uses the device flow to retrieve a token. This function will poll continuously until
-// the end user performs the verification or the device code issued by the authorization server
-// expires.
+// the end user performs the verification, the device code issued by the authorization server
+// expires or the context is done.
 func (c *Config) Token(ctx context.Context, prompt UserInstruction) (*oauth2.Token, error) {
 	// Get the device code
 	v := url.Values{
@@ -92,7 +103,11 @@ func (c *Config) Token(ctx context.Context, prompt UserInstruction) (*oauth2.Tok
 	prompt(dar.UserCode, dar.VerificationURI, dar.VerificationURIComplete)
 
 	// Wait for the response to come back
-	t, err := requestToken(ctx, c.Config, dar.DeviceCode, time.Duration(dar.Interval)*time.Second)
+	var expiry time.Time
+	if dar.ExpiresIn > 0 {
+		expiry = time.Now().Add(time.Duration(dar.ExpiresIn) * time.Second)
+	}
+	t, err := requestToken(ctx, c.Config, dar.DeviceCode, time.Duration(dar.Interval)*time.Second, expiry)
 	if err != nil {
 		return nil, err
 	}
@@ -138,40 +153,66 @@ func doDeviceAuthorizationRoundTrip(ctx context.Context, req *http.Request) (*de
 	return dar, nil
 }
 
-func requestToken(ctx context.Context, cfg clientcredentials.Config, deviceCode string, interval time.Duration) (*oauth2.Token, error) {
-	if cfg.EndpointParams == nil {
-		cfg.EndpointParams = url.Values{}
+func requestToken(ctx context.Context, cfg clientcredentials.Config, deviceCode string, interval time.Duration, expiry time.Time) (*oauth2.Token, error) {
+	if interval <= 0 {
+		interval = defaultInterval
 	}
-	cfg.EndpointParams.Set("grant_type", "urn:ietf:params:oauth:grant-type:device_code")
-	cfg.EndpointParams.Set("device_code", deviceCode)
-	ts := cfg.TokenSource(ctx)
+
+	// Copy the parameters so we do not modify the caller's configuration
+	v := url.Values{}
+	for k, p := range cfg.EndpointParams {
+		v[k] = p
+	}
+	v.Set("grant_type", "urn:ietf:params:oauth:grant-type:device_code")
+	v.Set("device_code", deviceCode)
+	cfg.EndpointParams = v
+
 	for {
-		t, err := ts.Token()
-		if err != nil {
-			if err := handleDeviceAccessTokenResponse(err, &interval); err != nil {
-				return nil, err
-			}
-			continue
+		t, err := cfg.Token(ctx)
+		if err == nil {
+			return t, nil
+		}
+		if err := handleDeviceAccessTokenResponse(err, &interval); err != nil {
+			return nil, err
+		}
+		if !expiry.IsZero() && time.Now().Add(interval).After(expiry) {
+			return nil, ErrExpiredToken
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
 		}
-		return t, nil
 	}
 }
 
+// handleDeviceAccessTokenResponse returns nil if the token request should be retried, adjusting the polling interval
+// as requested by the server.
 func handleDeviceAccessTokenResponse(err error, interval *time.Duration) error {
-	if rErr, ok := err.(*oauth2.RetrieveError); ok {
-		errResp := &errorResponse{}
-		if err := json.Unmarshal(rErr.Body, errResp); err != nil {
-			return err
-		}
+	var rErr *oauth2.RetrieveError
+	if !errors.As(err, &rErr) {
+		return err
+	}
 
-		if errResp.Error == "slow_down" {
-			*interval = *interval + (5 * time.Second)
-		}
+	errResp := &errorResponse{}
+	if json.Unmarshal(rErr.Body, errResp) != nil {
+		return err
+	}
 
-		if errResp.Error == "authorization_pending" || errResp.Error == "slow_down" {
-			time.Sleep(*interval)
-			return nil
-		}
+	switch errResp.Error {
+	case "authorization_pending":
+		return nil
+	case "slow_down":
+		*interval += 5 * time.Second
+		return nil
+	case "access_denied":
+		return ErrAccessDenied
+	case "expired_token":
+		return ErrExpiredToken
+	default:
+		return err
 	}
-	return err
 }
@@ -0,0 +1,97 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package devicecode
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+func TestConfig_Token(t *testing.T) {
+	cases := []struct {
+		desc          string
+		responses     []string
+		expectedToken string
+		expectedErr   error
+	}{
+		{
+			desc:          "approved",
+			responses:     []string{`{"error":"authorization_pending"}`, `{"access_token":"abc","token_type":"bearer"}`},
+			expectedToken: "abc",
+		},
+		{
+			desc:        "denied",
+			responses:   []string{`{"error":"access_denied"}`},
+			expectedErr: ErrAccessDenied,
+		},
+		{
+			desc:        "expired",
+			responses:   []string{`{"error":"expired_token"}`},
+			expectedErr: ErrExpiredToken,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			var polls int
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.NoError(t, r.ParseForm())
+				w.Header().Set("Content-Type", "application/json")
+				switch r.URL.Path {
+				case "/device":
+					_, _ = w.Write([]byte(`{"device_code":"dc","user_code":"UC","verification_uri":"https://example.com/activate","expires_in":60,"interval":1}`))
+				case "/token":
+					assert.Equal(t, "dc", r.PostForm.Get("device_code"))
+					resp := c.responses[polls]
+					polls++
+					if strings.Contains(resp, `"error"`) {
+						w.WriteHeader(http.StatusBadRequest)
+					}
+					_, _ = w.Write([]byte(resp))
+				}
+			}))
+			defer ts.Close()
+
+			cfg := &Config{
+				Config: clientcredentials.Config{
+					ClientID:  "client",
+					TokenURL:  ts.URL + "/token",
+					AuthStyle: oauth2.AuthStyleInParams,
+				},
+				DeviceAuthorizationURL: ts.URL + "/device",
+			}
+
+			var userCode string
+			tok, err := cfg.Token(context.Background(), func(uc, _, _ string) { userCode = uc })
+			assert.Equal(t, "UC", userCode)
+			if c.expectedErr != nil {
+				assert.Equal(t, c.expectedErr, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, c.expectedToken, tok.AccessToken)
+			assert.Equal(t, len(c.responses), polls)
+		})
+	}
+}